@@ -0,0 +1,284 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+deepflow-wasm-gen generate a wasm plugin parser from a yaml protocol spec, see Spec for the format:
+
+	go run github.com/deepflowio/deepflow-wasm-go-sdk/cmd/deepflow-wasm-gen -spec demo.yaml -out .
+
+two files are generated, $(name)_parser.go implement OnCheckPayload and OnParsePayload, $(name)_parser_test.go test
+the decoding with the test cases of the spec.
+*/
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+type genField struct {
+	Field
+	GoName string
+	GoType string
+	Tag    string
+}
+
+type genTest struct {
+	TestCase
+	Request bool
+}
+
+type genSpec struct {
+	*Spec
+	Source   string
+	Prefix   string
+	Fields   []genField
+	Tests    []genTest
+	Request  string
+	Response string
+	// the expression convert the field to the info
+	RequestID  string
+	Endpoint   string
+	StatusCode string
+	Attributes []genAttr
+	Imports    []string
+}
+
+type genAttr struct {
+	Key  string
+	Expr string
+}
+
+// snake_case or kebab-case to CamelCase
+func goName(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if r == '_' || r == '-' || r == ' ' || r == '.' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (s *Spec) tag(f *Field) string {
+	var opts []string
+	if _, ok := intTypes[f.Type]; ok {
+		opts = append(opts, f.Type)
+		order := f.ByteOrder
+		if order == "" {
+			order = s.ByteOrder
+		}
+		if order == "le" && f.Type != "u8" && f.Type != "i8" && !strings.HasSuffix(f.Type, "varint") {
+			opts = append(opts, "le")
+		}
+		return strings.Join(opts, ",")
+	}
+	switch {
+	case f.Magic != "":
+		opts = append(opts, "magic="+f.Magic)
+	case f.Len == "rest":
+		opts = append(opts, "rest")
+	default:
+		if _, err := strconv.Atoi(f.Len); err == nil {
+			opts = append(opts, "len="+f.Len)
+		} else {
+			opts = append(opts, "len="+goName(f.Len))
+		}
+	}
+	return strings.Join(opts, ",")
+}
+
+func discriminatorExpr(d Discriminator) string {
+	var exprs []string
+	for _, v := range d.Values {
+		exprs = append(exprs, fmt.Sprintf("m.%s == %d", goName(d.Field), v))
+	}
+	return strings.Join(exprs, " || ")
+}
+
+func newGenSpec(spec *Spec, source string) *genSpec {
+	g := &genSpec{
+		Spec:    spec,
+		Source:  source,
+		Prefix:  goName(spec.Name),
+		Request: discriminatorExpr(spec.Request),
+	}
+	if spec.Response.Field != "" {
+		g.Response = discriminatorExpr(spec.Response)
+	} else {
+		g.Response = "!m.IsRequest()"
+	}
+
+	imports := map[string]bool{}
+	for i := range spec.Fields {
+		f := &spec.Fields[i]
+		gf := genField{Field: *f, GoName: goName(f.Name), Tag: spec.tag(f)}
+		switch f.Type {
+		case "bytes":
+			gf.GoType = "[]byte"
+		case "string":
+			gf.GoType = "string"
+		default:
+			gf.GoType = intTypes[f.Type]
+		}
+		g.Fields = append(g.Fields, gf)
+	}
+
+	toString := func(name string) string {
+		f := spec.field(name)
+		switch f.Type {
+		case "string":
+			return "m." + goName(name)
+		case "bytes":
+			imports["encoding/hex"] = true
+			return "hex.EncodeToString(m." + goName(name) + ")"
+		}
+		imports["strconv"] = true
+		if strings.HasPrefix(intTypes[f.Type], "u") {
+			return "strconv.FormatUint(uint64(m." + goName(name) + "), 10)"
+		}
+		return "strconv.FormatInt(int64(m." + goName(name) + "), 10)"
+	}
+	if spec.RequestID != "" {
+		g.RequestID = "uint32(m." + goName(spec.RequestID) + ")"
+	}
+	if spec.StatusCode != "" {
+		g.StatusCode = "m." + goName(spec.StatusCode)
+	}
+	if spec.Endpoint != "" {
+		if spec.field(spec.Endpoint).Type == "bytes" {
+			g.Endpoint = "string(m." + goName(spec.Endpoint) + ")"
+		} else {
+			g.Endpoint = toString(spec.Endpoint)
+		}
+	}
+	for _, name := range spec.Attributes {
+		g.Attributes = append(g.Attributes, genAttr{Key: name, Expr: toString(name)})
+	}
+	for imp := range imports {
+		g.Imports = append(g.Imports, imp)
+	}
+	sort.Strings(g.Imports)
+
+	for _, t := range spec.Tests {
+		g.Tests = append(g.Tests, genTest{TestCase: t, Request: t.Direction == "request"})
+	}
+	return g
+}
+
+func render(name, text string, data interface{}) ([]byte, error) {
+	tmpl, err := template.New(name).Funcs(template.FuncMap{
+		"quote": strconv.Quote,
+		"join": func(v []int64) string {
+			s := make([]string, len(v))
+			for i, n := range v {
+				s[i] = strconv.FormatInt(n, 10)
+			}
+			return strings.Join(s, ", ")
+		},
+		"ports": func(v []uint16) string {
+			s := make([]string, len(v))
+			for i, p := range v {
+				s[i] = strconv.Itoa(int(p))
+			}
+			return strings.Join(s, ", ")
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format %s fail: %v\n%s", name, err, buf.String())
+	}
+	return src, nil
+}
+
+type genFile struct {
+	name string
+	src  []byte
+}
+
+// the parser and its test of the validated spec, source is the spec file name shown in the generated code
+func generate(spec *Spec, source string) ([]genFile, error) {
+	g := newGenSpec(spec, source)
+	base := strings.ToLower(strings.Trim(goName(spec.Name), "_"))
+
+	var files []genFile
+	for _, f := range []struct {
+		name string
+		tmpl string
+	}{
+		{base + "_parser.go", tParser},
+		{base + "_parser_test.go", tParserTest},
+	} {
+		src, err := render(f.name, f.tmpl, g)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, genFile{name: f.name, src: src})
+	}
+	return files, nil
+}
+
+func main() {
+	log.SetPrefix("deepflow-wasm-gen: ")
+	log.SetFlags(0)
+	specPath := flag.String("spec", "", "the yaml protocol spec")
+	out := flag.String("out", ".", "the output directory")
+	flag.Parse()
+
+	if *specPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	spec, err := loadSpec(*specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	files, err := generate(spec, filepath.Base(*specPath))
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, f := range files {
+		path := filepath.Join(*out, f.name)
+		if err := os.WriteFile(path, f.src, 0644); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("generate %s", path)
+	}
+}
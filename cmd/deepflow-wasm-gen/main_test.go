@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// the generated code is compared with testdata/$(file).golden, run with -update to regenerate them
+func TestGenerateGolden(t *testing.T) {
+	for _, path := range []string{"../../example/spec_gen/demo.yaml", "testdata/kv.yaml"} {
+		spec, err := loadSpec(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files, err := generate(spec, filepath.Base(path))
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		for _, f := range files {
+			golden := filepath.Join("testdata", f.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, f.src, 0644); err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(f.src, want) {
+				t.Errorf("%s: %s differ from %s, run go test -update if expected", path, f.name, golden)
+			}
+		}
+	}
+}
+
+func TestDuplicatedValues(t *testing.T) {
+	spec, err := loadSpec("testdata/kv.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := generate(spec, "kv.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser := string(files[0].src)
+	if !strings.Contains(parser, "case 0, 200:") {
+		t.Errorf("the success codes are not deduplicated in %s", files[0].name)
+	}
+	if strings.Count(parser, "m.MsgType == 3") != 1 {
+		t.Errorf("the request values are not deduplicated in %s", files[0].name)
+	}
+}
@@ -0,0 +1,352 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"go/token"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Spec describe a binary protocol with fixed layout, the same layout is used by request and response:
+
+	name: DemoRPC
+	protocol_num: 1
+	transport: tcp
+	ports: [9000]
+	byte_order: be
+	fields:
+	  - {name: magic, type: bytes, magic: "0xcafe"}
+	  - {name: msg_type, type: u8}
+	  - {name: req_id, type: u32}
+	  - {name: endpoint_len, type: u16}
+	  - {name: endpoint, type: string, len: endpoint_len}
+	  - {name: code, type: i32}
+	  - {name: body, type: bytes, len: rest}
+	request: {field: msg_type, values: [1]}
+	response: {field: msg_type, values: [2]}
+	request_id: req_id
+	endpoint: endpoint
+	status_code: code
+	success_codes: [0]
+	attributes: [msg_type]
+	tests:
+	  - name: request
+	    payload: "cafe010000000700052f7573657200000000"
+	    direction: request
+	    request_id: 7
+	    endpoint: /user
+*/
+type Spec struct {
+	// the protocol string returned by OnCheckPayload
+	Name string `yaml:"name"`
+	// the go package of the generated code, default main
+	Package string `yaml:"package"`
+	// the protocol number returned by OnCheckPayload, default 1
+	ProtocolNum uint8 `yaml:"protocol_num"`
+	// tcp, udp or any, default tcp
+	Transport string `yaml:"transport"`
+	// the server ports, empty indicate any
+	Ports []uint16 `yaml:"ports"`
+	// be or le, default be, can be overridden by field
+	ByteOrder string  `yaml:"byte_order"`
+	Fields    []Field `yaml:"fields"`
+
+	Request Discriminator `yaml:"request"`
+	// optional, any message not request is response if absent
+	Response Discriminator `yaml:"response"`
+
+	RequestID    string   `yaml:"request_id"`
+	Endpoint     string   `yaml:"endpoint"`
+	StatusCode   string   `yaml:"status_code"`
+	SuccessCodes []int64  `yaml:"success_codes"`
+	Attributes   []string `yaml:"attributes"`
+
+	// whether generate the main function which register the parser
+	Main bool `yaml:"main"`
+
+	Tests []TestCase `yaml:"tests"`
+}
+
+type Field struct {
+	Name string `yaml:"name"`
+	// u8, u16, u32, u64, i8, i16, i32, i64, uvarint, varint, bytes or string
+	Type string `yaml:"type"`
+	// the size of bytes and string, a number, a previous field name or rest
+	Len string `yaml:"len"`
+	// the expected value of bytes and string, hex with 0x prefix or literal
+	Magic     string `yaml:"magic"`
+	ByteOrder string `yaml:"byte_order"`
+}
+
+type Discriminator struct {
+	Field  string  `yaml:"field"`
+	Values []int64 `yaml:"values"`
+}
+
+type TestCase struct {
+	Name string `yaml:"name"`
+	// hex encoded payload
+	Payload string `yaml:"payload"`
+	// request or response
+	Direction  string  `yaml:"direction"`
+	RequestID  *uint32 `yaml:"request_id"`
+	Endpoint   *string `yaml:"endpoint"`
+	StatusCode *int32  `yaml:"status_code"`
+}
+
+func loadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec := &Spec{}
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(spec); err != nil {
+		return nil, fmt.Errorf("parse %s fail: %v", path, err)
+	}
+	if err := spec.validate(); err != nil {
+		return nil, fmt.Errorf("invalid spec %s: %v", path, err)
+	}
+	return spec, nil
+}
+
+var intTypes = map[string]string{
+	"u8":      "uint8",
+	"u16":     "uint16",
+	"u32":     "uint32",
+	"u64":     "uint64",
+	"i8":      "int8",
+	"i16":     "int16",
+	"i32":     "int32",
+	"i64":     "int64",
+	"uvarint": "uint64",
+	"varint":  "int64",
+}
+
+// the methods of the generated message, the field can not use the same name
+var reservedNames = map[string]bool{
+	"IsRequest":      true,
+	"IsResponse":     true,
+	"L7ProtocolInfo": true,
+}
+
+// the range of the integer types, uint64 is limited by the int64 of the spec values
+var intRanges = map[string][2]int64{
+	"uint8":  {0, math.MaxUint8},
+	"uint16": {0, math.MaxUint16},
+	"uint32": {0, math.MaxUint32},
+	"uint64": {0, math.MaxInt64},
+	"int8":   {math.MinInt8, math.MaxInt8},
+	"int16":  {math.MinInt16, math.MaxInt16},
+	"int32":  {math.MinInt32, math.MaxInt32},
+	"int64":  {math.MinInt64, math.MaxInt64},
+}
+
+func checkValues(f *Field, values []int64) error {
+	r := intRanges[intTypes[f.Type]]
+	for _, v := range values {
+		if v < r[0] || v > r[1] {
+			return fmt.Errorf("value %d overflow %s field %s", v, f.Type, f.Name)
+		}
+	}
+	return nil
+}
+
+func (s *Spec) field(name string) *Field {
+	for i := range s.Fields {
+		if s.Fields[i].Name == name {
+			return &s.Fields[i]
+		}
+	}
+	return nil
+}
+
+func (s *Spec) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(s.Name) > 16 {
+		return fmt.Errorf("name %s is longer than 16 bytes", s.Name)
+	}
+	if prefix := goName(s.Name); !token.IsIdentifier(prefix) || !token.IsExported(prefix) {
+		return fmt.Errorf("name %s is not a valid go identifier as %s", s.Name, prefix)
+	}
+	if s.Package == "" {
+		s.Package = "main"
+	}
+	if s.ProtocolNum == 0 {
+		s.ProtocolNum = 1
+	}
+	switch s.Transport {
+	case "":
+		s.Transport = "tcp"
+	case "tcp", "udp", "any":
+	default:
+		return fmt.Errorf("unknown transport %s", s.Transport)
+	}
+	if err := checkByteOrder(s.ByteOrder); err != nil {
+		return err
+	}
+	if len(s.Fields) == 0 {
+		return fmt.Errorf("fields is required")
+	}
+	if len(s.SuccessCodes) == 0 {
+		s.SuccessCodes = []int64{0}
+	}
+
+	seen := map[string]bool{}
+	goNames := map[string]string{}
+	for i, f := range s.Fields {
+		if f.Name == "" {
+			return fmt.Errorf("field %d: name is required", i)
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("field %s: duplicated", f.Name)
+		}
+		name := goName(f.Name)
+		if !token.IsIdentifier(name) || !token.IsExported(name) {
+			return fmt.Errorf("field %s: %s is not a valid go identifier", f.Name, name)
+		}
+		if reservedNames[name] {
+			return fmt.Errorf("field %s: %s is reserved by the generated methods", f.Name, name)
+		}
+		if other, ok := goNames[name]; ok {
+			return fmt.Errorf("field %s: %s conflict with field %s", f.Name, name, other)
+		}
+		goNames[name] = f.Name
+		if err := checkByteOrder(f.ByteOrder); err != nil {
+			return fmt.Errorf("field %s: %v", f.Name, err)
+		}
+		switch {
+		case intTypes[f.Type] != "":
+			if f.Len != "" || f.Magic != "" {
+				return fmt.Errorf("field %s: len and magic are only valid for bytes and string", f.Name)
+			}
+		case f.Type == "bytes" || f.Type == "string":
+			if f.Magic != "" {
+				if f.Len != "" {
+					return fmt.Errorf("field %s: the len of magic is implied", f.Name)
+				}
+				if strings.HasPrefix(f.Magic, "0x") {
+					if _, err := hex.DecodeString(f.Magic[2:]); err != nil {
+						return fmt.Errorf("field %s: invalid magic: %v", f.Name, err)
+					}
+				}
+				break
+			}
+			if f.Len == "" {
+				return fmt.Errorf("field %s: len is required", f.Name)
+			}
+			if _, err := strconv.Atoi(f.Len); err == nil || f.Len == "rest" {
+				break
+			}
+			ref := s.field(f.Len)
+			if ref == nil || !seen[f.Len] || intTypes[ref.Type] == "" {
+				return fmt.Errorf("field %s: len %s must be a number, rest or a previous integer field", f.Name, f.Len)
+			}
+		default:
+			return fmt.Errorf("field %s: unknown type %s", f.Name, f.Type)
+		}
+		seen[f.Name] = true
+	}
+
+	if s.Request.Field == "" || len(s.Request.Values) == 0 {
+		return fmt.Errorf("request discriminator is required")
+	}
+	for _, d := range []Discriminator{s.Request, s.Response} {
+		if d.Field == "" {
+			continue
+		}
+		f := s.field(d.Field)
+		if f == nil || intTypes[f.Type] == "" {
+			return fmt.Errorf("discriminator %s must be an integer field", d.Field)
+		}
+		if err := checkValues(f, d.Values); err != nil {
+			return fmt.Errorf("discriminator: %v", err)
+		}
+	}
+	for _, ref := range []struct {
+		name    string
+		integer bool
+	}{{s.RequestID, true}, {s.StatusCode, true}, {s.Endpoint, false}} {
+		if ref.name == "" {
+			continue
+		}
+		f := s.field(ref.name)
+		if f == nil {
+			return fmt.Errorf("field %s not found", ref.name)
+		}
+		if ref.integer && intTypes[f.Type] == "" {
+			return fmt.Errorf("field %s must be an integer field", ref.name)
+		}
+	}
+	if s.StatusCode != "" {
+		if err := checkValues(s.field(s.StatusCode), s.SuccessCodes); err != nil {
+			return fmt.Errorf("success codes: %v", err)
+		}
+	}
+	// the duplicated code generate the duplicated case
+	s.SuccessCodes = dedup(s.SuccessCodes)
+	s.Request.Values = dedup(s.Request.Values)
+	s.Response.Values = dedup(s.Response.Values)
+	for _, name := range s.Attributes {
+		if s.field(name) == nil {
+			return fmt.Errorf("attribute field %s not found", name)
+		}
+	}
+	for i, t := range s.Tests {
+		if _, err := hex.DecodeString(t.Payload); err != nil {
+			return fmt.Errorf("test %d: invalid payload: %v", i, err)
+		}
+		switch t.Direction {
+		case "request", "response":
+		default:
+			return fmt.Errorf("test %d: direction must be request or response", i)
+		}
+	}
+	return nil
+}
+
+// remove the duplicated values and keep the order
+func dedup(values []int64) []int64 {
+	seen := make(map[int64]bool, len(values))
+	var result []int64
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func checkByteOrder(order string) error {
+	switch order {
+	case "", "be", "le":
+		return nil
+	}
+	return fmt.Errorf("unknown byte order %s", order)
+}
@@ -0,0 +1,236 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+const tParser = `// Code generated by deepflow-wasm-gen from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/bin"
+)
+
+const (
+	{{.Prefix}}ProtocolNum = {{.ProtocolNum}}
+	{{.Prefix}}ProtocolStr = {{quote .Name}}
+)
+
+type {{.Prefix}}Message struct {
+{{- range .Fields}}
+	{{.GoName}} {{.GoType}} ` + "`" + `bin:"{{.Tag}}"` + "`" + `
+{{- end}}
+}
+
+func Decode{{.Prefix}}(payload []byte) (*{{.Prefix}}Message, error) {
+	m := &{{.Prefix}}Message{}
+	if err := bin.Unmarshal(payload, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *{{.Prefix}}Message) IsRequest() bool {
+	return {{.Request}}
+}
+
+func (m *{{.Prefix}}Message) IsResponse() bool {
+	return {{.Response}}
+}
+
+// return nil if the message is neither request nor response
+func (m *{{.Prefix}}Message) L7ProtocolInfo() *sdk.L7ProtocolInfo {
+	info := &sdk.L7ProtocolInfo{
+		L7ProtocolStr: {{.Prefix}}ProtocolStr,
+	}
+{{- if .RequestID}}
+	requestID := {{.RequestID}}
+	info.RequestID = &requestID
+{{- end}}
+	switch {
+	case m.IsRequest():
+		info.Req = &sdk.Request{
+{{- if .Endpoint}}
+			Endpoint: {{.Endpoint}},
+{{- end}}
+		}
+	case m.IsResponse():
+		info.Resp = &sdk.Response{}
+{{- if .StatusCode}}
+		code := int32({{.StatusCode}})
+		status := sdk.RespStatusServerErr
+		switch {{.StatusCode}} {
+		case {{join .SuccessCodes}}:
+			status = sdk.RespStatusOk
+		}
+		info.Resp.Code = &code
+		info.Resp.Status = &status
+{{- end}}
+	default:
+		return nil
+	}
+{{- range .Attributes}}
+	info.Kv = append(info.Kv, sdk.KeyVal{
+		Key: {{quote .Key}},
+		Val: {{.Expr}},
+	})
+{{- end}}
+	return info
+}
+
+type {{.Prefix}}Parser struct{}
+
+func (p {{.Prefix}}Parser) OnCheckPayload(ctx *sdk.ParseCtx) (uint8, string, uint8) {
+{{- if eq .Transport "tcp"}}
+	if ctx.L4 != sdk.TCP {
+		return 0, "", 0
+	}
+{{- else if eq .Transport "udp"}}
+	if ctx.L4 != sdk.UDP {
+		return 0, "", 0
+	}
+{{- end}}
+{{- if .Ports}}
+	switch ctx.DstPort {
+	case {{ports .Ports}}:
+	default:
+		return 0, "", 0
+	}
+{{- end}}
+	payload, err := ctx.GetPayload()
+	if err != nil {
+		return 0, "", 0
+	}
+	m, err := Decode{{.Prefix}}(payload)
+	if err != nil || !m.IsRequest() {
+		return 0, "", 0
+	}
+	return {{.Prefix}}ProtocolNum, {{.Prefix}}ProtocolStr, 0
+}
+
+func (p {{.Prefix}}Parser) OnParsePayload(ctx *sdk.ParseCtx) sdk.Action {
+	if ctx.L7 != {{.Prefix}}ProtocolNum {
+		return sdk.ActionNext()
+	}
+	payload, err := ctx.GetPayload()
+	if err != nil {
+		return sdk.ActionAbortWithErr(err)
+	}
+	m, err := Decode{{.Prefix}}(payload)
+	if err != nil {
+		return sdk.ActionAbortWithErr(err)
+	}
+	info := m.L7ProtocolInfo()
+	if info == nil {
+		return sdk.ActionAbort()
+	}
+	return sdk.ParseActionAbortWithL7Info([]*sdk.L7ProtocolInfo{info})
+}
+{{- if .Main}}
+
+func main() {
+	sdk.Info("{{.Name}} wasm plugin load")
+	sdk.SetHandler({{.Prefix}}Parser{})
+}
+{{- end}}
+`
+
+const tParserTest = `// Code generated by deepflow-wasm-gen from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+var {{.Prefix | printf "test%sCases"}} = []struct {
+	name       string
+	payload    string
+	request    bool
+	requestID  *uint32
+	endpoint   *string
+	statusCode *int32
+}{
+{{- range .Tests}}
+	{
+		name:    {{quote .Name}},
+		payload: {{quote .Payload}},
+		request: {{.Request}},
+{{- if .RequestID}}
+		requestID: func() *uint32 { v := uint32({{.RequestID}}); return &v }(),
+{{- end}}
+{{- if .Endpoint}}
+		endpoint: func() *string { v := {{quote .Endpoint}}; return &v }(),
+{{- end}}
+{{- if .StatusCode}}
+		statusCode: func() *int32 { v := int32({{.StatusCode}}); return &v }(),
+{{- end}}
+	},
+{{- end}}
+}
+
+func Test{{.Prefix}}Decode(t *testing.T) {
+	for _, c := range {{.Prefix | printf "test%sCases"}} {
+		payload, err := hex.DecodeString(c.payload)
+		if err != nil {
+			t.Fatalf("%s: invalid payload: %v", c.name, err)
+		}
+		m, err := Decode{{.Prefix}}(payload)
+		if err != nil {
+			t.Fatalf("%s: decode fail: %v", c.name, err)
+		}
+		if m.IsRequest() != c.request {
+			t.Errorf("%s: is request got %v, want %v", c.name, m.IsRequest(), c.request)
+		}
+		info := m.L7ProtocolInfo()
+		if info == nil {
+			t.Fatalf("%s: neither request nor response", c.name)
+		}
+		if c.requestID != nil && (info.RequestID == nil || *info.RequestID != *c.requestID) {
+			t.Errorf("%s: unexpected request id %v", c.name, info.RequestID)
+		}
+		if c.endpoint != nil && (info.Req == nil || info.Req.Endpoint != *c.endpoint) {
+			t.Errorf("%s: unexpected request %+v", c.name, info.Req)
+		}
+		if c.statusCode != nil && (info.Resp == nil || info.Resp.Code == nil || *info.Resp.Code != *c.statusCode) {
+			t.Errorf("%s: unexpected response %+v", c.name, info.Resp)
+		}
+	}
+}
+
+// the truncated or corrupted payload must fail without panic
+func Test{{.Prefix}}Malformed(t *testing.T) {
+	for _, c := range {{.Prefix | printf "test%sCases"}} {
+		payload, _ := hex.DecodeString(c.payload)
+		for i := 0; i < len(payload); i++ {
+			if m, err := Decode{{.Prefix}}(payload[:i]); err == nil {
+				m.L7ProtocolInfo()
+			}
+			corrupted := append([]byte{}, payload...)
+			corrupted[i] ^= 0xff
+			if m, err := Decode{{.Prefix}}(corrupted); err == nil {
+				m.L7ProtocolInfo()
+			}
+		}
+	}
+}
+`
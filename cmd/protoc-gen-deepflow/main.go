@@ -0,0 +1,211 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+protoc-gen-deepflow generate the pbrpc service descriptors for the wasm plugin, the messages must be generated by
+protoc-gen-go and protoc-gen-go-vtproto with the unmarshal feature in the same go package:
+
+	go build -o protoc-gen-deepflow github.com/deepflowio/deepflow-wasm-go-sdk/cmd/protoc-gen-deepflow
+	protoc --go_out=./pb --go-vtproto_out=./pb --go-vtproto_opt=features=unmarshal \
+		--deepflow_out=./pb --plugin=protoc-gen-deepflow=./protoc-gen-deepflow ./demo.proto
+
+for every proto file with service or wrapper, a <name>.deepflow.go is generated which contains:
+
+	<Service>_DeepflowService: the *pbrpc.Service of each service, registered into pbrpc.DefaultRegistry in init
+	<Message>_DeepflowWrapper: the *pbrpc.Wrapper of each message which only has a oneof of messages, for zmtp
+	<Message>.FillL7ProtocolInfo: fill the L7ProtocolInfo by the fields annotated by deepflow/options.proto
+
+besides the standard options of protoc-gen-go such as paths=source_relative and M, the plugin accept:
+
+	register=false: do not register the services in init
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const (
+	sdkPackage   = protogen.GoImportPath("github.com/deepflowio/deepflow-wasm-go-sdk/sdk")
+	pbrpcPackage = protogen.GoImportPath("github.com/deepflowio/deepflow-wasm-go-sdk/sdk/pbrpc")
+)
+
+func main() {
+	var flags flag.FlagSet
+	register := flags.Bool("register", true, "register the services into pbrpc.DefaultRegistry in init")
+
+	protogen.Options{
+		ParamFunc: flags.Set,
+	}.Run(func(gen *protogen.Plugin) error {
+		gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+		idents := packageIdents(gen)
+		for _, f := range gen.Files {
+			if !f.Generate {
+				continue
+			}
+			if err := generateFile(gen, f, idents[f.GoImportPath], *register); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// collect the identifiers generated by protoc-gen-go for every go package, use to avoid name collision
+func packageIdents(gen *protogen.Plugin) map[protogen.GoImportPath]map[string]bool {
+	idents := make(map[protogen.GoImportPath]map[string]bool)
+	var addMessages func(names map[string]bool, messages []*protogen.Message)
+	addMessages = func(names map[string]bool, messages []*protogen.Message) {
+		for _, m := range messages {
+			names[m.GoIdent.GoName] = true
+			for _, o := range m.Oneofs {
+				for _, field := range o.Fields {
+					names[field.GoIdent.GoName] = true
+				}
+			}
+			for _, e := range m.Enums {
+				names[e.GoIdent.GoName] = true
+			}
+			addMessages(names, m.Messages)
+		}
+	}
+	for _, f := range gen.Files {
+		names, ok := idents[f.GoImportPath]
+		if !ok {
+			names = make(map[string]bool)
+			idents[f.GoImportPath] = names
+		}
+		addMessages(names, f.Messages)
+		for _, e := range f.Enums {
+			names[e.GoIdent.GoName] = true
+		}
+		for _, s := range f.Services {
+			names[s.GoName] = true
+		}
+	}
+	return idents
+}
+
+// append '_' until the name is not used in the package, the same way protoc-gen-go resolve the collision
+func uniqueName(names map[string]bool, name string) string {
+	for names[name] {
+		name += "_"
+	}
+	names[name] = true
+	return name
+}
+
+// a wrapper is a message which only has a oneof, and every field of the oneof is a message
+func isWrapper(m *protogen.Message) bool {
+	if len(m.Oneofs) != 1 || m.Oneofs[0].Desc.IsSynthetic() || len(m.Fields) == 0 {
+		return false
+	}
+	for _, field := range m.Fields {
+		if field.Oneof != m.Oneofs[0] || field.Message == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func wrappers(messages []*protogen.Message) []*protogen.Message {
+	var result []*protogen.Message
+	for _, m := range messages {
+		if isWrapper(m) {
+			result = append(result, m)
+		}
+		result = append(result, wrappers(m.Messages)...)
+	}
+	return result
+}
+
+func generateFile(gen *protogen.Plugin, f *protogen.File, names map[string]bool, register bool) error {
+	wrapperMessages := wrappers(f.Messages)
+	fillers, err := fillers(f.Messages, map[*protogen.Message]bool{})
+	if err != nil {
+		return fmt.Errorf("%s: %v", f.Desc.Path(), err)
+	}
+	if len(f.Services) == 0 && len(wrapperMessages) == 0 && len(fillers) == 0 {
+		return nil
+	}
+	g := gen.NewGeneratedFile(f.GeneratedFilenamePrefix+".deepflow.go", f.GoImportPath)
+	g.P("// Code generated by protoc-gen-deepflow. DO NOT EDIT.")
+	g.P("// source: ", path.Clean(f.Desc.Path()))
+	g.P()
+	g.P("package ", f.GoPackageName)
+	g.P()
+
+	message := g.QualifiedGoIdent(pbrpcPackage.Ident("Message"))
+	var services []string
+	for _, s := range f.Services {
+		name := uniqueName(names, s.GoName+"_DeepflowService")
+		services = append(services, name)
+		g.P("// ", name, " describe the service ", s.Desc.FullName())
+		g.P("var ", name, " = &", pbrpcPackage.Ident("Service"), "{")
+		g.P("Name: ", fmt.Sprintf("%q", s.Desc.FullName()), ",")
+		g.P("Methods: []*", pbrpcPackage.Ident("Method"), "{")
+		for _, m := range s.Methods {
+			g.P("{")
+			g.P("Name: ", fmt.Sprintf("%q", m.Desc.Name()), ",")
+			if m.Desc.IsStreamingClient() {
+				g.P("ClientStreaming: true,")
+			}
+			if m.Desc.IsStreamingServer() {
+				g.P("ServerStreaming: true,")
+			}
+			g.P("NewRequest: func() ", message, " { return new(", m.Input.GoIdent, ") },")
+			g.P("NewResponse: func() ", message, " { return new(", m.Output.GoIdent, ") },")
+			g.P("},")
+		}
+		g.P("},")
+		g.P("}")
+		g.P()
+	}
+
+	for _, m := range wrapperMessages {
+		name := uniqueName(names, m.GoIdent.GoName+"_DeepflowWrapper")
+		g.P("// ", name, " describe the wrapper message ", m.Desc.FullName())
+		g.P("var ", name, " = &", pbrpcPackage.Ident("Wrapper"), "{")
+		g.P("Name: ", fmt.Sprintf("%q", m.Desc.FullName()), ",")
+		g.P("New: func() ", message, " { return new(", m.GoIdent, ") },")
+		var numbers []string
+		for _, field := range m.Fields {
+			numbers = append(numbers, fmt.Sprint(field.Desc.Number()))
+		}
+		g.P("Fields: []int32{", strings.Join(numbers, ", "), "},")
+		g.P("}")
+		g.P()
+	}
+
+	for _, m := range fillers {
+		generateFiller(g, m)
+	}
+
+	if register && len(services) > 0 {
+		g.P("func init() {")
+		for _, name := range services {
+			g.P(pbrpcPackage.Ident("Register"), "(", name, ")")
+		}
+		g.P("}")
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/pbrpc/deepflow"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+type fieldType = descriptorpb.FieldDescriptorProto_Type
+
+const (
+	typeString  = descriptorpb.FieldDescriptorProto_TYPE_STRING
+	typeBytes   = descriptorpb.FieldDescriptorProto_TYPE_BYTES
+	typeBool    = descriptorpb.FieldDescriptorProto_TYPE_BOOL
+	typeInt32   = descriptorpb.FieldDescriptorProto_TYPE_INT32
+	typeInt64   = descriptorpb.FieldDescriptorProto_TYPE_INT64
+	typeSint64  = descriptorpb.FieldDescriptorProto_TYPE_SINT64
+	typeUint32  = descriptorpb.FieldDescriptorProto_TYPE_UINT32
+	typeFixed64 = descriptorpb.FieldDescriptorProto_TYPE_FIXED64
+	typeFloat   = descriptorpb.FieldDescriptorProto_TYPE_FLOAT
+	typeDouble  = descriptorpb.FieldDescriptorProto_TYPE_DOUBLE
+	typeEnum    = descriptorpb.FieldDescriptorProto_TYPE_ENUM
+	typeMessage = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+)
+
+type fieldOpt func(*descriptorpb.FieldDescriptorProto)
+
+func columns(c *deepflow.Columns) fieldOpt {
+	return func(f *descriptorpb.FieldDescriptorProto) {
+		f.Options = &descriptorpb.FieldOptions{}
+		proto.SetExtension(f.Options, deepflow.E_Columns, c)
+	}
+}
+
+func typeName(name string) fieldOpt {
+	return func(f *descriptorpb.FieldDescriptorProto) {
+		f.TypeName = proto.String(".fixture." + name)
+	}
+}
+
+func repeated(f *descriptorpb.FieldDescriptorProto) {
+	f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+}
+
+func oneof(index int32) fieldOpt {
+	return func(f *descriptorpb.FieldDescriptorProto) {
+		f.OneofIndex = proto.Int32(index)
+	}
+}
+
+func field(name string, number int32, t fieldType, opts ...fieldOpt) *descriptorpb.FieldDescriptorProto {
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(name),
+		Number:   proto.Int32(number),
+		Type:     t.Enum(),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+func message(name string, fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+	return &descriptorpb.DescriptorProto{Name: proto.String(name), Field: fields}
+}
+
+func fixtureFile(messages ...*descriptorpb.DescriptorProto) *descriptorpb.FileDescriptorProto {
+	return &descriptorpb.FileDescriptorProto{
+		Name:        proto.String("fixture/fixture.proto"),
+		Package:     proto.String("fixture"),
+		Syntax:      proto.String("proto3"),
+		Dependency:  []string{"deepflow/options.proto"},
+		Options:     &descriptorpb.FileOptions{GoPackage: proto.String("example.com/fixture;fixture")},
+		MessageType: messages,
+	}
+}
+
+/*
+the fixture use every option of deepflow/options.proto, it is equivalent to:
+
+	enum Kind { KIND_UNKNOWN = 0; KIND_READ = 1; }
+	message Header {
+	  string trace_id = 1 [(deepflow.columns) = {trace_id: true}];
+	  string span_id = 2 [(deepflow.columns) = {span_id: true, attr: ""}];
+	}
+	message Request {
+	  Header header = 1;
+	  string business_id = 2 [(deepflow.columns) = {attr: "business_id"}];
+	  bool retry = 3 [(deepflow.columns) = {attr: ""}];
+	  int64 user = 4 [(deepflow.columns) = {attr: "user"}];
+	  uint32 shard = 5 [(deepflow.columns) = {attr: "shard"}];
+	  float ratio = 6 [(deepflow.columns) = {attr: "ratio"}];
+	  double score = 7 [(deepflow.columns) = {attr: "score"}];
+	  Kind kind = 8 [(deepflow.columns) = {attr: "kind"}];
+	  bytes token = 9 [(deepflow.columns) = {attr: "token"}];
+	  repeated Header history = 10;
+	}
+	message Response {
+	  int32 code = 1 [(deepflow.columns) = {resp_code: true, biz_code: true}];
+	  string message = 2 [(deepflow.columns) = {exception: true}];
+	}
+	message StatusResponse { string status = 1 [(deepflow.columns) = {resp_code: true}]; }
+	message RawResponse {
+	  bytes status = 1 [(deepflow.columns) = {resp_code: true}];
+	  sint64 biz = 2 [(deepflow.columns) = {biz_code: true}];
+	  fixed64 elapsed = 3 [(deepflow.columns) = {attr: "elapsed"}];
+	}
+	message Envelope { oneof body { Request request = 1; Response response = 2; } }
+	message Demo_DeepflowService {}
+	service Demo {
+	  rpc Call(Request) returns (Response);
+	  rpc Watch(Request) returns (stream Response);
+	  rpc Upload(stream Request) returns (Response);
+	}
+*/
+func fixture() *descriptorpb.FileDescriptorProto {
+	envelope := message("Envelope",
+		field("request", 1, typeMessage, typeName("Request"), oneof(0)),
+		field("response", 2, typeMessage, typeName("Response"), oneof(0)))
+	envelope.OneofDecl = []*descriptorpb.OneofDescriptorProto{{Name: proto.String("body")}}
+
+	f := fixtureFile(
+		message("Header",
+			field("trace_id", 1, typeString, columns(&deepflow.Columns{TraceId: proto.Bool(true)})),
+			field("span_id", 2, typeString, columns(&deepflow.Columns{SpanId: proto.Bool(true), Attr: proto.String("")}))),
+		message("Request",
+			field("header", 1, typeMessage, typeName("Header")),
+			field("business_id", 2, typeString, columns(&deepflow.Columns{Attr: proto.String("business_id")})),
+			field("retry", 3, typeBool, columns(&deepflow.Columns{Attr: proto.String("")})),
+			field("user", 4, typeInt64, columns(&deepflow.Columns{Attr: proto.String("user")})),
+			field("shard", 5, typeUint32, columns(&deepflow.Columns{Attr: proto.String("shard")})),
+			field("ratio", 6, typeFloat, columns(&deepflow.Columns{Attr: proto.String("ratio")})),
+			field("score", 7, typeDouble, columns(&deepflow.Columns{Attr: proto.String("score")})),
+			field("kind", 8, typeEnum, typeName("Kind"), columns(&deepflow.Columns{Attr: proto.String("kind")})),
+			field("token", 9, typeBytes, columns(&deepflow.Columns{Attr: proto.String("token")})),
+			field("history", 10, typeMessage, typeName("Header"), repeated)),
+		message("Response",
+			field("code", 1, typeInt32, columns(&deepflow.Columns{RespCode: proto.Bool(true), BizCode: proto.Bool(true)})),
+			field("message", 2, typeString, columns(&deepflow.Columns{Exception: proto.Bool(true)}))),
+		message("StatusResponse",
+			field("status", 1, typeString, columns(&deepflow.Columns{RespCode: proto.Bool(true)}))),
+		message("RawResponse",
+			field("status", 1, typeBytes, columns(&deepflow.Columns{RespCode: proto.Bool(true)})),
+			field("biz", 2, typeSint64, columns(&deepflow.Columns{BizCode: proto.Bool(true)})),
+			field("elapsed", 3, typeFixed64, columns(&deepflow.Columns{Attr: proto.String("elapsed")}))),
+		envelope,
+		message("Demo_DeepflowService"),
+	)
+	f.EnumType = []*descriptorpb.EnumDescriptorProto{{
+		Name: proto.String("Kind"),
+		Value: []*descriptorpb.EnumValueDescriptorProto{
+			{Name: proto.String("KIND_UNKNOWN"), Number: proto.Int32(0)},
+			{Name: proto.String("KIND_READ"), Number: proto.Int32(1)},
+		},
+	}}
+	method := func(name string, clientStreaming, serverStreaming bool) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{
+			Name:            proto.String(name),
+			InputType:       proto.String(".fixture.Request"),
+			OutputType:      proto.String(".fixture.Response"),
+			ClientStreaming: proto.Bool(clientStreaming),
+			ServerStreaming: proto.Bool(serverStreaming),
+		}
+	}
+	f.Service = []*descriptorpb.ServiceDescriptorProto{{
+		Name:   proto.String("Demo"),
+		Method: []*descriptorpb.MethodDescriptorProto{method("Call", false, false), method("Watch", false, true), method("Upload", true, false)},
+	}}
+	return f
+}
+
+// run the plugin the same way as protoc, the request is marshaled so the options are decoded from the wire
+func newPlugin(t *testing.T, parameter string, f *descriptorpb.FileDescriptorProto) *protogen.Plugin {
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{f.GetName()},
+		Parameter:      proto.String(parameter),
+		ProtoFile: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(descriptorpb.File_google_protobuf_descriptor_proto),
+			protodesc.ToFileDescriptorProto(deepflow.File_deepflow_options_proto),
+			f,
+		},
+	}
+	data, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = &pluginpb.CodeGeneratorRequest{}
+	if err := proto.Unmarshal(data, req); err != nil {
+		t.Fatal(err)
+	}
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gen
+}
+
+func generate(t *testing.T, parameter string, f *descriptorpb.FileDescriptorProto, register bool) (*pluginpb.CodeGeneratorResponse, error) {
+	gen := newPlugin(t, parameter, f)
+	idents := packageIdents(gen)
+	for _, file := range gen.Files {
+		if !file.Generate {
+			continue
+		}
+		if err := generateFile(gen, file, idents[file.GoImportPath], register); err != nil {
+			return nil, err
+		}
+	}
+	return gen.Response(), nil
+}
+
+func TestGenerateGolden(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		register bool
+		golden   string
+	}{
+		{"register", true, "testdata/fixture.deepflow.go.golden"},
+		{"no register", false, "testdata/fixture_noregister.deepflow.go.golden"},
+	} {
+		resp, err := generate(t, "paths=source_relative", fixture(), c.register)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if resp.GetError() != "" || len(resp.File) != 1 {
+			t.Fatalf("%s: unexpected response %v", c.name, resp)
+		}
+		if name := resp.File[0].GetName(); name != "fixture/fixture.deepflow.go" {
+			t.Errorf("%s: got file %s", c.name, name)
+		}
+		content := []byte(resp.File[0].GetContent())
+		if *update {
+			if err := os.WriteFile(c.golden, content, 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(c.golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(content, want) {
+			t.Errorf("%s: the generated code differ from %s, run go test -update if expected", c.name, c.golden)
+		}
+	}
+}
+
+func TestGenerateBadColumns(t *testing.T) {
+	cases := []struct {
+		name  string
+		field *descriptorpb.FieldDescriptorProto
+		err   string
+	}{
+		{"resp_code on bool", field("ok", 1, typeBool, columns(&deepflow.Columns{RespCode: proto.Bool(true)})),
+			"resp_code require an integer or string field"},
+		{"repeated", field("ids", 1, typeString, repeated, columns(&deepflow.Columns{TraceId: proto.Bool(true)})),
+			"trace_id is not supported by repeated or map field"},
+		{"message", field("inner", 1, typeMessage, typeName("Inner"), columns(&deepflow.Columns{Attr: proto.String("")})),
+			"attr is not supported by message field"},
+	}
+	for _, c := range cases {
+		f := fixtureFile(message("Inner"), message("Bad", c.field))
+		if _, err := generate(t, "", f, true); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: got error %v, want %s", c.name, err, c.err)
+		}
+	}
+
+	// nothing to generate
+	resp, err := generate(t, "", fixtureFile(message("Plain", field("name", 1, typeString))), true)
+	if err != nil || len(resp.File) != 0 {
+		t.Errorf("got files %v and error %v", resp.GetFile(), err)
+	}
+}
@@ -0,0 +1,187 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/pbrpc/deepflow"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const strconvPackage = protogen.GoImportPath("strconv")
+
+type column struct {
+	option string
+	// the pbrpc helper to call
+	setter string
+	// the attr name, only for the attr option
+	attr string
+}
+
+// the (deepflow.columns) option of the field, in the order of options.proto
+func fieldColumns(field *protogen.Field) []column {
+	c, _ := proto.GetExtension(field.Desc.Options(), deepflow.E_Columns).(*deepflow.Columns)
+	if c == nil {
+		return nil
+	}
+	var columns []column
+	if c.GetTraceId() {
+		columns = append(columns, column{option: "trace_id", setter: "SetTraceID"})
+	}
+	if c.GetSpanId() {
+		columns = append(columns, column{option: "span_id", setter: "SetSpanID"})
+	}
+	if c.Attr != nil {
+		attr := c.GetAttr()
+		if attr == "" {
+			attr = string(field.Desc.Name())
+		}
+		columns = append(columns, column{option: "attr", setter: "AddAttr", attr: attr})
+	}
+	if c.GetBizCode() {
+		columns = append(columns, column{option: "biz_code", setter: "SetBizCode"})
+	}
+	if c.GetRespCode() {
+		columns = append(columns, column{option: "resp_code", setter: "SetRespCode"})
+	}
+	if c.GetException() {
+		columns = append(columns, column{option: "exception", setter: "SetException"})
+	}
+	return columns
+}
+
+func checkColumns(field *protogen.Field, columns []column) error {
+	for _, c := range columns {
+		if field.Desc.IsList() || field.Desc.IsMap() {
+			return fmt.Errorf("field %s: (deepflow.columns).%s is not supported by repeated or map field", field.Desc.FullName(), c.option)
+		}
+		switch field.Desc.Kind() {
+		case protoreflect.MessageKind, protoreflect.GroupKind:
+			return fmt.Errorf("field %s: (deepflow.columns).%s is not supported by message field", field.Desc.FullName(), c.option)
+		case protoreflect.StringKind, protoreflect.BytesKind,
+			protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+			protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+			protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		default:
+			if c.option == "resp_code" {
+				return fmt.Errorf("field %s: (deepflow.columns).resp_code require an integer or string field", field.Desc.FullName())
+			}
+		}
+	}
+	return nil
+}
+
+// a singular message field is filled recursively if its message is fillable
+func isNestedFiller(field *protogen.Field, visiting map[*protogen.Message]bool) bool {
+	if field.Message == nil || field.Desc.IsList() || field.Desc.IsMap() {
+		return false
+	}
+	return isFiller(field.Message, visiting)
+}
+
+func isFiller(m *protogen.Message, visiting map[*protogen.Message]bool) bool {
+	if visiting[m] {
+		return false
+	}
+	visiting[m] = true
+	defer delete(visiting, m)
+	for _, field := range m.Fields {
+		if len(fieldColumns(field)) > 0 || isNestedFiller(field, visiting) {
+			return true
+		}
+	}
+	return false
+}
+
+// return all the fillable messages and validate the options
+func fillers(messages []*protogen.Message, visiting map[*protogen.Message]bool) ([]*protogen.Message, error) {
+	var result []*protogen.Message
+	for _, m := range messages {
+		if m.Desc.IsMapEntry() {
+			continue
+		}
+		for _, field := range m.Fields {
+			if err := checkColumns(field, fieldColumns(field)); err != nil {
+				return nil, err
+			}
+		}
+		if isFiller(m, visiting) {
+			result = append(result, m)
+		}
+		nested, err := fillers(m.Messages, visiting)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, nested...)
+	}
+	return result, nil
+}
+
+// the go expression to format the field as string
+func formatValue(g *protogen.GeneratedFile, field *protogen.Field, getter string) string {
+	switch field.Desc.Kind() {
+	case protoreflect.StringKind:
+		return getter
+	case protoreflect.BytesKind:
+		return "string(" + getter + ")"
+	case protoreflect.BoolKind:
+		return g.QualifiedGoIdent(strconvPackage.Ident("FormatBool")) + "(" + getter + ")"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return g.QualifiedGoIdent(strconvPackage.Ident("FormatInt")) + "(int64(" + getter + "), 10)"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return g.QualifiedGoIdent(strconvPackage.Ident("FormatUint")) + "(uint64(" + getter + "), 10)"
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return g.QualifiedGoIdent(strconvPackage.Ident("FormatFloat")) + "(float64(" + getter + "), 'g', -1, 64)"
+	case protoreflect.EnumKind:
+		return getter + ".String()"
+	}
+	return getter
+}
+
+func generateFiller(g *protogen.GeneratedFile, m *protogen.Message) {
+	g.P("// FillL7ProtocolInfo fill the columns annotated by deepflow options")
+	g.P("func (x *", m.GoIdent, ") FillL7ProtocolInfo(info *", sdkPackage.Ident("L7ProtocolInfo"), ") {")
+	g.P("if x == nil {")
+	g.P("return")
+	g.P("}")
+	for _, field := range m.Fields {
+		getter := "x.Get" + field.GoName + "()"
+		for _, c := range fieldColumns(field) {
+			switch {
+			case c.option == "attr":
+				g.P(pbrpcPackage.Ident(c.setter), "(info, ", fmt.Sprintf("%q", c.attr), ", ", formatValue(g, field, getter), ")")
+			case c.option == "resp_code" && field.Desc.Kind() == protoreflect.StringKind:
+				g.P(pbrpcPackage.Ident("SetRespCodeString"), "(info, ", getter, ")")
+			case c.option == "resp_code" && field.Desc.Kind() == protoreflect.BytesKind:
+				g.P(pbrpcPackage.Ident("SetRespCodeString"), "(info, string(", getter, "))")
+			case c.option == "resp_code":
+				g.P(pbrpcPackage.Ident(c.setter), "(info, int32(", getter, "))")
+			default:
+				g.P(pbrpcPackage.Ident(c.setter), "(info, ", formatValue(g, field, getter), ")")
+			}
+		}
+		if isNestedFiller(field, map[*protogen.Message]bool{}) {
+			g.P(pbrpcPackage.Ident("Fill"), "(info, ", getter, ")")
+		}
+	}
+	g.P("}")
+	g.P()
+}
@@ -97,7 +97,7 @@ func (p dnsParser) OnParsePayload(ctx *sdk.ParseCtx) sdk.Action {
 			}
 		}
 		if req == nil {
-			sdk.Warn("%s:%d -> %s:%d dns question no A or AAAA record ", ctx.SrcIP.IP, ctx.SrcPort, ctx.DstIP.IP, ctx.DstPort)
+			sdk.Warn("%s -> %s dns question no A or AAAA record ", ctx.Src(), ctx.Dst())
 			return sdk.ActionAbort()
 		}
 	case sdk.DirectionResponse:
@@ -119,7 +119,7 @@ func (p dnsParser) OnParsePayload(ctx *sdk.ParseCtx) sdk.Action {
 			}
 		}
 		if resp == nil {
-			sdk.Warn("%s:%d -> %s:%d dns response no A or AAAA record ", ctx.SrcIP.IP, ctx.SrcPort, ctx.DstIP.IP, ctx.DstPort)
+			sdk.Warn("%s -> %s dns response no A or AAAA record ", ctx.Src(), ctx.Dst())
 			return sdk.ActionAbort()
 		}
 	default:
@@ -18,19 +18,33 @@ package main
 
 import (
 	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
-	"github.com/valyala/fastjson"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/jsonpath"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/status"
 	_ "github.com/wasilibs/nottinygc"
 )
 
+// the rules extract the fields of the json return value, see jsonpath.ParseRules for the format
+const EXTRACT_RULES = `
+$.status_code -> resp_code
+$.exception   -> exception
+$.exception   -> attr custom_exception
+`
+
 func main() {
 	sdk.Info("dubbo-plugin loaded")
-	parser := DubboParser{}
+	extractor, err := jsonpath.Compile(EXTRACT_RULES)
+	if err != nil {
+		sdk.Error("compile extract rules fail: %v", err)
+		return
+	}
+	parser := DubboParser{extractor: extractor}
 	parser.Parser = interface{}(parser).(sdk.Parser)
 	sdk.SetParser(parser)
 }
 
 type DubboParser struct {
 	sdk.DefaultParser
+	extractor *jsonpath.Extractor
 }
 
 func (p DubboParser) HookIn() []sdk.HookBitmap {
@@ -39,8 +53,14 @@ func (p DubboParser) HookIn() []sdk.HookBitmap {
 	}
 }
 
-func (p DubboParser) CustomMessageHookIn() uint64 {
-	return sdk.CustomMessageHookProtocol(sdk.PROTOCOL_DUBBO, true)
+func (p DubboParser) CustomMessageSubscriptions() []sdk.CustomMessageSubscription {
+	return []sdk.CustomMessageSubscription{
+		{
+			Protocol:   sdk.PROTOCOL_DUBBO,
+			Directions: sdk.MessageRequest,
+			HookPoint:  sdk.ProtocolParse,
+		},
+	}
 }
 
 func (p DubboParser) OnCustomReq(ctx *sdk.CustomMessageCtx) sdk.Action {
@@ -76,38 +96,24 @@ func (p DubboParser) OnCustomResp(ctx *sdk.CustomMessageCtx) sdk.Action {
 		return sdk.ActionAbort()
 	}
 	dubboReturnValue := payload[17:]
-	status := sdk.RespStatusOk
+	respStatus := sdk.RespStatusOk
 	status_code := int32(0)
-	exception := ""
-	// Assuming the returned value bytes are data serialized in JSON format, the content is: {"status code": 500, "exception": "internal error"}
-	// Extract the status_code and exception from the data
-	if fastjson.Exists(dubboReturnValue, "status_code") {
-		code := fastjson.GetInt(dubboReturnValue, "status_code")
-		status_code = int32(code)
-		if status_code >= 500 {
-			status = sdk.RespStatusServerErr
-		} else if status_code >= 400 && status_code < 500 {
-			status = sdk.RespStatusClientErr
-		}
+	info := &sdk.L7ProtocolInfo{
+		Resp: &sdk.Response{
+			Code:   &status_code,             // Overwrite the status code in the response message, for example, if the original Dubbo status code is 20, override it with a custom status code
+			Status: &respStatus,              // Rewrite the response status to a custom status
+			Result: string(dubboReturnValue), // The entire payload can be placed into Response.Result for easier troubleshooting
+		},
 	}
-	if fastjson.Exists(dubboReturnValue, "exception") {
-		exception = fastjson.GetString(dubboReturnValue, "exception")
+	// Assuming the returned value bytes are data serialized in JSON format, the content is: {"status code": 500, "exception": "internal error"}
+	// Extract the status_code and exception from the data by the rules, the truncated json is parsed as far as possible
+	p.extractor.Fill(info, dubboReturnValue)
+	// the status_code in the return value is the http code, the code below 400 include the absent one is ok
+	if code := *info.Resp.Code; code >= 400 {
+		respStatus = status.HTTP(code)
 	}
 
-	return sdk.ParseActionAbortWithL7Info([]*sdk.L7ProtocolInfo{
-		{
-			Resp: &sdk.Response{
-				Code:      &status_code,             // Overwrite the status code in the response message, for example, if the original Dubbo status code is 20, override it with a custom status code
-				Status:    &status,                  // Rewrite the response status to a custom status
-				Result:    string(dubboReturnValue), // The entire payload can be placed into Response.Result for easier troubleshooting
-				Exception: exception,                // Rewrite exception
-			},
-			Kv: []sdk.KeyVal{{
-				Key: "custom_exception", // Add extra information
-				Val: exception,
-			}},
-		},
-	})
+	return sdk.ParseActionAbortWithL7Info([]*sdk.L7ProtocolInfo{info})
 }
 
 func (p DubboParser) OnCustomMessage(ctx *sdk.CustomMessageCtx) sdk.Action {
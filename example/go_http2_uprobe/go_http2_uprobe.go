@@ -8,11 +8,15 @@ import (
 
 	"github.com/deepflowio/deepflow-wasm-go-sdk/example/go_http2_uprobe/pb"
 	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/bin"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/pbrpc"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/status"
 	_ "github.com/wasilibs/nottinygc"
 )
 
 //go:generate mkdir -p ./pb
-//go:generate protoc --go_out=./pb ./pb.proto
+//go:generate go build -o ./pb/protoc-gen-deepflow ../../cmd/protoc-gen-deepflow
+//go:generate protoc -I . -I ../../sdk/pbrpc --go_out=./pb --deepflow_out=./pb --plugin=protoc-gen-deepflow=./pb/protoc-gen-deepflow --go-vtproto_out=./pb --go-vtproto_opt=features=unmarshal ./pb.proto
 func main() {
 	sdk.SetParser(parser{})
 	sdk.Warn("plugin loaded")
@@ -83,58 +87,36 @@ func (p parser) OnParsePayload(ctx *sdk.ParseCtx) sdk.Action {
 		}
 
 		var (
-			attr     = []sdk.KeyVal{}
-			traceID  string
+			msg      pbrpc.Message
 			infoReq  *sdk.Request
 			infoResp *sdk.Response
 		)
 
 		switch ctx.Direction {
 		case sdk.DirectionResponse:
-			resp := &pb.OrderResponse{}
-			if err := resp.UnmarshalVT(data); err != nil {
-				return sdk.ActionAbort()
-			}
-
-			attr = []sdk.KeyVal{
-				{
-					Key: "msg",
-					Val: resp.Msg,
-				},
-			}
+			msg = &pb.OrderResponse{}
 			infoResp = &sdk.Response{
 				Status: &defaultStatus,
 			}
 		case sdk.DirectionRequest:
-			req := &pb.OrderRequest{}
-			if err := req.UnmarshalVT(data); err != nil {
-				return sdk.ActionAbort()
-			}
-			attr = []sdk.KeyVal{
-				{
-					Key: "business_id",
-					Val: req.BusinessId,
-				},
-			}
-			traceID = req.BusinessId
+			msg = &pb.OrderRequest{}
 			infoReq = &sdk.Request{}
 		default:
 			return sdk.ActionAbort()
 		}
+		if err := msg.UnmarshalVT(data); err != nil {
+			return sdk.ActionAbort()
+		}
 
 		info := &sdk.L7ProtocolInfo{
 			RequestID:     &streamID,
 			Req:           infoReq,
 			Resp:          infoResp,
-			Kv:            attr,
 			ProtocolMerge: true,
 			IsEnd:         true,
 		}
-		if traceID != "" {
-			info.Trace = &sdk.Trace{
-				TraceID: traceID,
-			}
-		}
+		// the trace id and attributes are annotated by the deepflow options in pb.proto
+		pbrpc.Fill(info, msg)
 		return sdk.ParseActionAbortWithL7Info([]*sdk.L7ProtocolInfo{info})
 	default:
 		return sdk.ActionNext()
@@ -156,19 +138,21 @@ header value len (4 bytes)
 header key value (xxx bytes)
 header value value (xxx bytes)
 */
-func parseHeader(payload []byte) (uint32, string, string, error) {
-	if len(payload) < 16 {
-		return 0, "", "", errors.New("header payload too short")
-	}
+type header struct {
+	Fd       uint32 `bin:"le"`
+	StreamID uint32 `bin:"le"`
+	KeyLen   uint32 `bin:"le"`
+	ValLen   uint32 `bin:"le"`
+	Key      string `bin:"len=KeyLen"`
+	Val      string `bin:"len=ValLen"`
+}
 
-	streamID := binary.LittleEndian.Uint32(payload[4:8])
-	keyLen := int(binary.LittleEndian.Uint32(payload[8:12]))
-	valLen := int(binary.LittleEndian.Uint32(payload[12:16]))
-	if keyLen < 0 || keyLen < 0 || keyLen+valLen+16 > len(payload) {
-		return 0, "", "", fmt.Errorf("header kv length too short, key len: %d, val len: %d, payload len: %d", keyLen, valLen, len(payload))
+func parseHeader(payload []byte) (uint32, string, string, error) {
+	var hdr header
+	if err := bin.Unmarshal(payload, &hdr); err != nil {
+		return 0, "", "", fmt.Errorf("parse header fail, payload len: %d, %v", len(payload), err)
 	}
-
-	return streamID, string(payload[16 : 16+keyLen]), string(payload[16+keyLen : 16+keyLen+valLen]), nil
+	return hdr.StreamID, hdr.Key, hdr.Val, nil
 }
 
 /*
@@ -208,18 +192,18 @@ func onHeader(info *sdk.L7ProtocolInfo, key string, val string) error {
 		}
 		statusCode := int32(code)
 		info.Resp.Code = &statusCode
-
-		var getStatus = func(statusCode int32) sdk.RespStatus {
-			if statusCode >= 200 && statusCode < 400 {
-				return sdk.RespStatusOk
-			}
-			if statusCode >= 400 && statusCode < 500 {
-				return sdk.RespStatusClientErr
-			}
-			return sdk.RespStatusServerErr
+		respStatus := status.HTTP(statusCode)
+		info.Resp.Status = &respStatus
+	case "grpc-status":
+		code, err := strconv.ParseInt(val, 10, 32)
+		if err != nil {
+			return err
+		}
+		respStatus := status.GRPC(int32(code))
+		info.Resp.Status = &respStatus
+		if respStatus != sdk.RespStatusOk {
+			info.Resp.Exception = status.GRPCName(int32(code))
 		}
-		status := getStatus(statusCode)
-		info.Resp.Status = &status
 	}
 	return nil
 }
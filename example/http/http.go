@@ -17,21 +17,31 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
-	"io"
-	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
-	"github.com/valyala/fastjson"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/endpoint"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/http1"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/jsonpath"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/proxyclient"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/tracectx"
 	_ "github.com/wasilibs/nottinygc"
 )
 
+// the rules extract the user info of the json response, see jsonpath.ParseRules for the format
+const USER_INFO_RULES = `
+# the user info is only valid when code is 0
+$.code               -> attr code int
+$.data.user_id       -> attr user_id int
+$.data.register_time -> attr register_time int
+`
+
 type httpHook struct {
 	sdk.DefaultParser
+	userInfo *jsonpath.Extractor
 }
 
 func (p httpHook) HookIn() []sdk.HookBitmap {
@@ -58,12 +68,12 @@ func (p httpHook) OnHttpReq(ctx *sdk.HttpReqCtx) sdk.Action {
 		return sdk.ActionAbortWithErr(err)
 	}
 
-	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(payload)))
+	req, err := http1.ParseRequest(payload)
 	if err != nil {
 		return sdk.ActionAbortWithErr(err)
 	}
 
-	query := req.URL.Query()
+	query, _ := url.ParseQuery(string(req.Query()))
 
 	attr := []sdk.KeyVal{
 		{
@@ -83,9 +93,8 @@ func (p httpHook) OnHttpReq(ctx *sdk.HttpReqCtx) sdk.Action {
 		trace   *sdk.Trace
 	)
 
-	traceInfo, ok := req.Header["Custom-Trace-Info"]
-	if ok && len(traceInfo) != 0 {
-		s := strings.Split(traceInfo[0], ",")
+	if traceInfo := req.HeaderString("Custom-Trace-Info"); traceInfo != "" {
+		s := strings.Split(traceInfo, ",")
 		if len(s) == 2 {
 			t := strings.Split(s[0], ":")
 			if len(t) == 2 {
@@ -105,9 +114,19 @@ func (p httpHook) OnHttpReq(ctx *sdk.HttpReqCtx) sdk.Action {
 			TraceID: traceID,
 			SpanID:  spanID,
 		}
+	} else {
+		// fallback to the standard formats such as traceparent, b3 and sw8
+		result := tracectx.Extract(req.HeaderString)
+		trace = result.Trace()
+		attr = append(attr, result.Baggage...)
 	}
 
-	return sdk.HttpReqActionAbortWithResult(nil, trace, attr)
+	// the real client behind the proxies and the x-request-id
+	client := proxyclient.ResolveFunc(baseCtx.Src().Addr(), req.Values)
+	trace = client.Apply(trace)
+	attr = append(attr, client.Attrs()...)
+
+	return sdk.HttpReqActionAbortWithResult(&sdk.Request{Endpoint: endpoint.Normalize(ctx.Path)}, trace, attr)
 }
 
 /*
@@ -122,41 +141,53 @@ func (p httpHook) OnHttpResp(ctx *sdk.HttpRespCtx) sdk.Action {
 	if baseCtx.SrcPort != 8080 {
 		return sdk.ActionNext()
 	}
-	payload, err := baseCtx.GetPayload()
+	attr, err := p.parseUserInfo(baseCtx)
 	if err != nil {
 		return sdk.ActionAbortWithErr(err)
 	}
+	// the empty endpoint is kept for the agent
+	result := &sdk.Response{}
+	if ctx.Endpoint != "" {
+		result.Endpoint = endpoint.Normalize(ctx.Endpoint)
+	}
+	return sdk.HttpRespActionAbortWithResult(result, nil, attr)
+}
 
-	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(payload)), nil)
+// the user info in the body of the response, nil if code is not 0
+func (p httpHook) parseUserInfo(baseCtx *sdk.ParseCtx) ([]sdk.KeyVal, error) {
+	payload, err := baseCtx.GetPayload()
 	if err != nil {
-		return sdk.ActionAbortWithErr(err)
+		return nil, err
+	}
+
+	resp, err := http1.ParseResponse(payload)
+	if err != nil {
+		return nil, err
 	}
-	body, err := io.ReadAll(resp.Body)
+	body, err := resp.DecodeBody(nil)
 	if err != nil {
-		return sdk.ActionAbortWithErr(err)
+		return nil, err
 	}
-	if fastjson.Exists(body, "code") && fastjson.Exists(body, "data") {
-		code := fastjson.GetInt(body, "code")
-		if code == 0 {
-			userID := fastjson.GetInt(body, "data", "user_id")
-			t := fastjson.GetInt(body, "data", "register_time")
-
-			return sdk.HttpRespActionAbortWithResult(nil, nil, []sdk.KeyVal{
-				{
-					Key: "user_id",
-					Val: strconv.Itoa(userID),
-				},
-
-				{
-					Key: "register_time",
-					Val: time.Unix(int64(t), 0).String(),
-				},
-			})
+	info := &sdk.L7ProtocolInfo{}
+	p.userInfo.Fill(info, body)
+
+	var attr []sdk.KeyVal
+	code := ""
+	for _, kv := range info.Kv {
+		switch kv.Key {
+		case "code":
+			code = kv.Val
+		case "register_time":
+			t, _ := strconv.ParseInt(kv.Val, 10, 64)
+			attr = append(attr, sdk.KeyVal{Key: kv.Key, Val: time.Unix(t, 0).String()})
+		default:
+			attr = append(attr, kv)
 		}
-
 	}
-	return sdk.ActionAbort()
-
+	if code != "0" {
+		return nil, nil
+	}
+	return attr, nil
 }
 
 func (p httpHook) OnCheckPayload(baseCtx *sdk.ParseCtx) (uint8, string, uint8) {
@@ -169,6 +200,14 @@ func (p httpHook) OnParsePayload(baseCtx *sdk.ParseCtx) sdk.Action {
 
 func main() {
 	sdk.Warn("wasm register http hook")
-	sdk.SetParser(httpHook{})
+	// the username is personal data, mask it like the password before sending to deepflow
+	sdk.DefaultRedactor.Deny("username")
+	sdk.SetRedactor(sdk.DefaultRedactor)
+	userInfo, err := jsonpath.Compile(USER_INFO_RULES)
+	if err != nil {
+		sdk.Error("compile user info rules fail: %v", err)
+		return
+	}
+	sdk.SetParser(httpHook{userInfo: userInfo})
 
 }
@@ -17,59 +17,11 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
-	"compress/gzip"
-	"encoding/json"
-	"io"
-	"net/http"
-	"strings"
-
 	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/rewrite"
 	_ "github.com/wasilibs/nottinygc"
 )
 
-func main() {
-	sdk.Info("on http status rewrite wasm plugin init")
-	sdk.SetParser(parser{})
-}
-
-type parser struct {
-	sdk.DefaultParser
-}
-
-func (p parser) OnHttpReq(ctx *sdk.HttpReqCtx) sdk.Action {
-	return sdk.ActionNext()
-}
-
-func (p parser) OnHttpResp(ctx *sdk.HttpRespCtx) sdk.Action {
-	payload, err := ctx.BaseCtx.GetPayload()
-	if err != nil {
-		return sdk.ActionAbortWithErr(err)
-	}
-	r, _ := http.ReadResponse(bufio.NewReader(bytes.NewReader(payload)), nil)
-	if r == nil {
-		return sdk.ActionAbort()
-	}
-	return onResp(r)
-}
-
-func (p parser) OnCheckPayload(ctx *sdk.ParseCtx) (protoNum uint8, protoStr string, direction uint8) {
-	return 0, "", 0
-}
-
-func (p parser) OnParsePayload(ctx *sdk.ParseCtx) sdk.Action {
-	return sdk.ActionNext()
-}
-
-func (p parser) HookIn() []sdk.HookBitmap {
-	return []sdk.HookBitmap{
-		sdk.HOOK_POINT_HTTP_RESP,
-	}
-}
-
-const BODY_START = `{"OPT_STATUS":"`
-
 /*
 this demo use for convert and rewrite the response code according to the http response data in deepflow server.
 deepflow server use the json key "OPT_STATUS" indicate the response status, "OPT_STATUS": "SUCCESS" is success,
@@ -78,113 +30,60 @@ otherwise assume fail and set the http status code to 500, the field map to deep
 	response_code   -> http status code
 	response_result -> if "OPT_STATUS": "SUCCESS" will leave it empty, otherwise will set to the whole http response body
 	response_status -> http code in [200, 400) will act as Ok, [400, 500) will act as client error, [500,-) will act as server error
-*/
-func onResp(r *http.Response) sdk.Action {
-	var getStatus = func(statusCode int32) sdk.RespStatus {
-		if statusCode >= 200 && statusCode < 400 {
-			return sdk.RespStatusOk
-		}
-		if statusCode >= 400 && statusCode < 500 {
-			return sdk.RespStatusClientErr
-		}
-		return sdk.RespStatusServerErr
-	}
-
-	var normalResp = func() sdk.Action {
-		code := int32(r.StatusCode)
-		status := getStatus(code)
-		return sdk.ParseActionAbortWithL7Info([]*sdk.L7ProtocolInfo{
-			{
-				Resp: &sdk.Response{
-					Status: &status,
-					Code:   &code,
-				},
-			},
-		})
-	}
-
-	var (
-		buf  []byte
-		body []byte
-	)
-	switch r.Header.Get("Content-Encoding") {
-	case "gzip":
-		g, err := gzip.NewReader(r.Body)
-		if err != nil {
-			sdk.Warn("%v", err)
-			return normalResp()
-		}
+	attribute op_stat -> the OPT_STATUS if present
 
-		body, _ = io.ReadAll(g)
-		g.Close()
-	default:
-		body, _ = io.ReadAll(r.Body)
-	}
-
-	if len(body) == 0 {
-		return normalResp()
-	}
-
-	m := make(map[string]interface{})
-	json.Unmarshal(body, &m)
-
-	var status string
-	_status, ok := m["OPT_STATUS"]
-	if ok {
-		status, ok = _status.(string)
-		if !ok {
-			return normalResp()
-		}
-	}
-
-	/*
-		due to tcp fragment, it is possible to receive the incomplete json data. if can not get the json key,
-		try to get the OPT_STATUS from json start like `{"OPT_STATUS": "SOME STATUS"`, parse the key as much as possible.
-		FIXME: remove the incomplete json data parse after agent implement tcp reassemble.
-	*/
-	if status == "" {
-		if !strings.HasPrefix(string(body), BODY_START) {
-			return normalResp()
-		}
-		buf = body[len(BODY_START):]
-
-		for i := 0; i < len(buf); i++ {
-			if buf[i] == '"' {
-				status = string(buf[:i])
-				break
-			}
-		}
-	}
-	attr := []sdk.KeyVal{
-		{
-			Key: "op_stat",
-			Val: status,
-		},
-	}
-
-	var (
-		code   = int32(r.StatusCode)
-		result string
-	)
-	switch status {
-	case "SUCCESS":
+the json body truncated by tcp fragment such as `{"OPT_STATUS": "SOME STATUS", "DA` is still matched, because the
+rule engine scan the json as far as possible. the agent call the hook with the first packet of the response only,
+so the body in the following packets is never seen by the plugin and sdk.StreamBuffer does not help here, it is
+for the protocols parsed by the plugin, see example/krpc. the last rule match all the others, so the response without
+OPT_STATUS keep the http status code.
+*/
+const RULES = `[
+  {
+    "name": "opt_status_fail_2xx",
+    "match": {
+      "status": "2xx",
+      "body": [{"key": "$.OPT_STATUS", "op": "ne", "value": "SUCCESS"}]
+    },
+    "set": {
+      "code": "500",
+      "result": "${body}",
+      "attrs": {"op_stat": "${body:$.OPT_STATUS}"}
+    }
+  },
+  {
+    "name": "opt_status_fail",
+    "match": {
+      "body": [{"key": "$.OPT_STATUS", "op": "ne", "value": "SUCCESS"}]
+    },
+    "set": {
+      "result": "${body}",
+      "attrs": {"op_stat": "${body:$.OPT_STATUS}"}
+    }
+  },
+  {
+    "name": "opt_status_success",
+    "match": {
+      "body": [{"key": "$.OPT_STATUS", "op": "eq", "value": "SUCCESS"}]
+    },
+    "set": {
+      "attrs": {"op_stat": "${body:$.OPT_STATUS}"}
+    }
+  },
+  {
+    "name": "http_status",
+    "match": {},
+    "set": {}
+  }
+]`
 
-	default:
-		if code >= 200 && code < 300 {
-			code = 500
-		}
-		result = string(body)
+func main() {
+	sdk.Info("on http status rewrite wasm plugin init")
+	engine, err := rewrite.Load([]byte(RULES))
+	if err != nil {
+		sdk.Error("load rewrite rules fail: %v", err)
+		return
 	}
-
-	respStatus := getStatus(code)
-	return sdk.ParseActionAbortWithL7Info([]*sdk.L7ProtocolInfo{
-		{
-			Resp: &sdk.Response{
-				Status: &respStatus,
-				Code:   &code,
-				Result: result,
-			},
-			Kv: attr,
-		},
-	})
+	// no rule refer the host, so only HOOK_POINT_HTTP_RESP is hooked in
+	sdk.SetHandler(engine.Handler())
 }
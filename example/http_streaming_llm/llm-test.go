@@ -6,9 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/http1"
 	"io"
-	"net/http"
-	"regexp"
 	"strings"
 )
 
@@ -39,12 +38,12 @@ type StreamInfo struct {
 	(2) body stream=True
 */
 func checker(payload []byte) (protoNum uint8, protoStr string, direction uint8) {
-	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(payload)))
+	req, err := http1.ParseRequest(payload)
 	if err != nil {
 		return 0, "", 0
 	}
 
-	query := req.URL.Path
+	query := string(req.Path())
 	if strings.Contains(query, "/generate_stream") {
 		sdk.Warn(fmt.Sprintf("check: %s", query))
 		return 1, "http_stream"
@@ -109,14 +108,14 @@ func (p *llmParser) OnParsePayload(baseCtx *sdk.ParseCtx) sdk.Action {
 	}
 	switch baseCtx.Direction {
 	case sdk.DirectionRequest:
-		req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(payload)))
+		req, err := http1.ParseRequest(payload)
 		if err != nil {
 			return sdk.ActionNext()
 		}
 		p.httpStream[flowId].reqTime = baseCtx.Time
 		info := &sdk.L7ProtocolInfo{
 			Req: &sdk.Request{
-				Resource: req.URL.Path,
+				Resource: string(req.Path()),
 			},
 			Resp: &sdk.Response{},
 		}
@@ -128,8 +127,7 @@ func (p *llmParser) OnParsePayload(baseCtx *sdk.ParseCtx) sdk.Action {
 		if err == io.EOF {
 			return sdk.ActionNext()
 		}
-		regex := regexp.MustCompile(`^HTTP/[1-2]\.[01] \d{3} .*$`)
-		if regex.MatchString(string(bs)) {
+		if _, err := http1.ParseResponse(bs); err == nil {
 			return sdk.ActionNext()
 		}
 		// 结束流式响应处理
@@ -6,9 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/http1"
 	"io"
-	"net/http"
-	"regexp"
 	"strings"
 )
 
@@ -39,12 +38,12 @@ type StreamInfo struct {
 	(2) body stream=True
 */
 func checker(payload []byte) (protoNum uint8, protoStr string, direction uint8) {
-	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(payload)))
+	req, err := http1.ParseRequest(payload)
 	if err != nil {
 		return 0, "", 0
 	}
 
-	query := req.URL.Path
+	query := string(req.Path())
 	if strings.Contains(query, "/generate_stream") {
 		sdk.Warn(fmt.Sprintf("check: %s", query))
 		return 1, "http_stream", 0
@@ -112,22 +111,22 @@ func (p *llmParser) OnParsePayload(baseCtx *sdk.ParseCtx) sdk.Action {
 
 	switch baseCtx.Direction {
 	case sdk.DirectionRequest:
-		streamId = fmt.Sprintf("%s:%d->%s:%d %d", baseCtx.DstIP.String(), baseCtx.DstPort, baseCtx.SrcIP.String(), baseCtx.SrcPort, flowId)
+		streamId = fmt.Sprintf("%s->%s %d", baseCtx.Dst(), baseCtx.Src(), flowId)
 		sdk.Warn("parse-req-start:" + streamId)
-		req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(payload)))
+		req, err := http1.ParseRequest(payload)
 		if err != nil {
 			return sdk.ActionNext()
 		}
 		p.httpStream[flowId].reqTime = baseCtx.Time
 		info := &sdk.L7ProtocolInfo{
 			Req: &sdk.Request{
-				Resource: req.URL.Path,
+				Resource: string(req.Path()),
 			},
 			Resp: &sdk.Response{},
 		}
 		return sdk.ParseActionAbortWithL7Info([]*sdk.L7ProtocolInfo{info})
 	case sdk.DirectionResponse:
-		streamId = fmt.Sprintf("%s:%d->%s:%d %d", baseCtx.SrcIP.String(), baseCtx.SrcPort, baseCtx.DstIP.String(), baseCtx.DstPort, flowId)
+		streamId = fmt.Sprintf("%s->%s %d", baseCtx.Src(), baseCtx.Dst(), flowId)
 		sdk.Warn("parse-resp-start:" + streamId)
 		// 开始流式响应处理： 分块传输
 		r := bufio.NewReader(bytes.NewReader(payload))
@@ -136,8 +135,7 @@ func (p *llmParser) OnParsePayload(baseCtx *sdk.ParseCtx) sdk.Action {
 			sdk.Warn("parse-resp-end-01")
 			return sdk.ActionNext()
 		}
-		regex := regexp.MustCompile(`^HTTP/[1-2]\.[01] \d{3} .*$`)
-		if regex.MatchString(string(bs)) {
+		if _, err := http1.ParseResponse(bs); err == nil {
 			// http响应状态行判断
 			sdk.Warn("parse-resp-end-00")
 			return sdk.ActionNext()
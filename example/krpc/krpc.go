@@ -25,6 +25,7 @@ import (
 
 	"github.com/deepflowio/deepflow-wasm-go-sdk/example/krpc/pb"
 	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/status"
 	_ "github.com/wasilibs/nottinygc"
 )
 
@@ -33,10 +34,20 @@ const (
 	KRPC_DIR_REQ     int32 = 1
 	KRPC_DIR_RESP    int32 = 2
 	KRPC_PROTOCOL          = 1
+	// the status table of the ret code, which can be replaced by status.Load
+	KRPC_STATUS_TABLE = "krpc"
 )
 
+func init() {
+	// the ret code 0 is ok and the others are server error
+	status.Register(KRPC_STATUS_TABLE, status.NewTable(sdk.RespStatusServerErr).Add(0, 0, sdk.RespStatusOk))
+}
+
 var protocolErr = errors.New("unknown protocol")
 
+// the krpc head may be split into multi packets, it is reassembled before parse
+var streams = sdk.NewStreamBuffer(0, 0)
+
 type KrpcInfo struct {
 	Rrt      uint64
 	MsgType  sdk.Direction
@@ -72,11 +83,7 @@ func (k *KrpcInfo) fillFromPb(meta *pb.KrpcMeta) error {
 		k.ParentSpanId = meta.Trace.ParentSpanId
 	}
 
-	if k.RetCode == 0 {
-		k.Status = sdk.RespStatusOk
-	} else {
-		k.Status = sdk.RespStatusServerErr
-	}
+	k.Status, _ = status.Classify(KRPC_STATUS_TABLE, int64(k.RetCode))
 	return nil
 }
 
@@ -116,6 +123,14 @@ func (k *KrpcInfo) parse(payload []byte, check bool) error {
 	return err
 }
 
+// report whether data is the start of a krpc packet but not contain the whole head
+func headIncomplete(data []byte) bool {
+	if len(data) < KRPC_FIX_HDR_LEN {
+		return bytes.HasPrefix([]byte("KR"), data[:min(len(data), 2)])
+	}
+	return bytes.Equal(data[:2], []byte("KR")) && int(binary.BigEndian.Uint16(data[2:]))+KRPC_FIX_HDR_LEN > len(data)
+}
+
 func (k *KrpcInfo) isHeartBeat() bool {
 	// reference https://github.com/bruceran/krpc/blob/master/doc/develop.md#krpc%E7%BD%91%E7%BB%9C%E5%8C%85%E5%8D%8F%E8%AE%AE
 	return k.Sequence == 0 && k.MsgId == 1 && k.ServId == 1
@@ -150,12 +165,18 @@ func (p parser) OnParsePayload(ctx *sdk.ParseCtx) sdk.Action {
 		return sdk.ActionNext()
 	}
 
-	payload, err := ctx.GetPayload()
+	data, err := streams.Append(ctx)
 	if err != nil {
 		return sdk.ActionAbortWithErr(err)
 	}
+	if headIncomplete(data) {
+		// wait for the rest of the head
+		return sdk.ActionAbort()
+	}
+	// only the head is parsed, the body in the following packets is dropped by the magic check
+	streams.Consume(ctx, len(data))
 	info := KrpcInfo{}
-	if err := info.parse(payload, false); err != nil {
+	if err := info.parse(data, false); err != nil {
 		return sdk.ActionAbort()
 	}
 
@@ -5,11 +5,13 @@ go 1.21
 toolchain go1.21.5
 
 require (
+	github.com/andybalholm/brotli v1.1.1
+	github.com/klauspost/compress v1.17.11
 	github.com/planetscale/vtprotobuf v0.6.0
-	github.com/valyala/fastjson v1.6.4
 	github.com/wasilibs/nottinygc v0.7.1
 	golang.org/x/net v0.14.0
 	google.golang.org/protobuf v1.36.5
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
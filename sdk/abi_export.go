@@ -124,9 +124,15 @@ func onCustomMessage() bool {
 		return false
 	}
 	ctx := deserializeCustomMessageCtx(paramBuf[:ctxSize], customMessageInfo[:messageCtxSize])
-	if ctx == nil {
+	if ctx == nil || !customMessageSubscribed(vmParser, ctx) {
 		return false
 	}
+	switch ctx.HookPoint {
+	case SessionFilter:
+		return onSessionFilter(ctx)
+	case Sampling:
+		return onSampling(ctx)
+	}
 	act := vmParser.OnCustomMessage(ctx)
 	if act == nil {
 		return false
@@ -150,6 +156,46 @@ func onCustomMessage() bool {
 	return act.abort()
 }
 
+/*
+the session filter and sampling result is always final, so abort the traversal once the result is written. the
+result is written by host_read_str_result since ABI_VERSION_CUSTOM_RESULT, the parser which not implement the
+handler of the hook point leave the decision to the other plugins.
+*/
+func onSessionFilter(ctx *CustomMessageCtx) bool {
+	h, ok := vmParser.(SessionFilterHandler)
+	if !ok || !implements(vmParser, SessionFilter) {
+		return false
+	}
+	data := serializeSessionFilterResult(h.OnSessionFilter(ctx))
+	return hostReadStrResult(&data[0], len(data))
+}
+
+func onSampling(ctx *CustomMessageCtx) bool {
+	h, ok := vmParser.(SamplingHandler)
+	if !ok || !implements(vmParser, Sampling) {
+		return false
+	}
+	result := h.OnSampling(ctx)
+	if result.Decision == SamplingDefault {
+		return false
+	}
+	data := serializeSamplingResult(result)
+	return hostReadStrResult(&data[0], len(data))
+}
+
+// customHookImplementer is implemented by the parser adapting the handler of SetHandler, which implement all the
+// handler interfaces but only dispatch the hook points the handler implemented
+type customHookImplementer interface {
+	implements(hookPoint uint16) bool
+}
+
+func implements(p Parser, hookPoint uint16) bool {
+	if i, ok := p.(customHookImplementer); ok {
+		return i.implements(hookPoint)
+	}
+	return true
+}
+
 //export check_payload
 func checkPayload() int32 {
 	if vmParser == nil {
@@ -219,18 +265,19 @@ func parsePayload() bool {
 	return act.abort()
 }
 
+// agent not support abi version negotiation assume the plugin is ABI_VERSION_INITIAL
+//
+//export get_abi_version
+func getAbiVersion() uint32 {
+	return ABI_VERSION
+}
+
 //export get_hook_bitmap
 func getHookBitmap() *byte {
 	if vmParser == nil {
 		return nil
 	}
-	b := vmParser.HookIn()
-	hookBit := [2]uint64{0, 0}
-
-	for _, v := range b {
-		hookBit[0] |= v[0]
-		hookBit[1] |= v[1]
-	}
+	hookBit := mergeHookBitmap(vmParser.HookIn())
 
 	bitmap := [16]byte{}
 	binary.BigEndian.PutUint64(bitmap[:8], hookBit[0])
@@ -244,6 +291,15 @@ func getCustomMessageHook() *byte {
 		return nil
 	}
 	data := [8]byte{}
-	binary.BigEndian.PutUint64(data[:], vmParser.CustomMessageHookIn())
+	binary.BigEndian.PutUint64(data[:], customMessageHookMask(vmParser))
+	return &data[0]
+}
+
+//export get_custom_message_hooks
+func getCustomMessageHooks() *byte {
+	if vmParser == nil {
+		return nil
+	}
+	data := serializeCustomMessageHooks(customMessageHooks(vmParser))
 	return &data[0]
 }
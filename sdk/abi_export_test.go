@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+import (
+	"bytes"
+	"testing"
+)
+
+// captureStrResult collect the results written by host_read_str_result until the test end
+func captureStrResult(t *testing.T) *[][]byte {
+	results := &[][]byte{}
+	testStrResult = func(b []byte) bool {
+		*results = append(*results, append([]byte{}, b...))
+		return true
+	}
+	t.Cleanup(func() { testStrResult = nil })
+	return results
+}
+
+// the parser registered by SetParser which implement the handlers directly
+type customResultParser struct {
+	DefaultParser
+	sampling SamplingResult
+}
+
+func (p customResultParser) OnSessionFilter(*CustomMessageCtx) bool { return true }
+
+func (p customResultParser) OnSampling(*CustomMessageCtx) SamplingResult { return p.sampling }
+
+type rateHandler struct{}
+
+func (rateHandler) OnSampling(*CustomMessageCtx) SamplingResult { return SampleRate(0.25) }
+
+func TestSerializeCustomResult(t *testing.T) {
+	cases := []struct {
+		name string
+		got  []byte
+		want []byte
+	}{
+		{"keep", serializeSessionFilterResult(true), []byte{0, 1, 1}},
+		{"drop", serializeSessionFilterResult(false), []byte{0, 1, 0}},
+		{"sample keep", serializeSamplingResult(SampleKeep()), []byte{0, 2, 1, 0, 0, 0, 0}},
+		{"sample drop", serializeSamplingResult(SampleDrop()), []byte{0, 2, 2, 0, 0, 0, 0}},
+		{"sample rate", serializeSamplingResult(SampleRate(0.5)), []byte{0, 2, 3, 0, 0x07, 0xa1, 0x20}},
+		{"sample rate clamped", serializeSamplingResult(SamplingResult{Decision: SamplingRate, Rate: 2000000}), []byte{0, 2, 3, 0, 0x0f, 0x42, 0x40}},
+	}
+	for _, c := range cases {
+		if !bytes.Equal(c.got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestCustomResultDispatch(t *testing.T) {
+	restoreParser(t)
+	cases := []struct {
+		name     string
+		register func()
+		filter   []byte
+		sampling []byte
+	}{
+		{"handler filter", func() { SetHandler(filterHandler{}) }, []byte{0, 1, 0}, nil},
+		{"handler sampling", func() { SetHandler(samplingHandler{}) }, nil, []byte{0, 2, 2, 0, 0, 0, 0}},
+		{"handler sampling rate", func() { SetHandler(rateHandler{}) }, nil, []byte{0, 2, 3, 0, 0x03, 0xd0, 0x90}},
+		{"handler without custom result", func() { SetHandler(customHandler{}) }, nil, nil},
+		{"parser", func() { SetParser(customResultParser{sampling: SampleKeep()}) }, []byte{0, 1, 1}, []byte{0, 2, 1, 0, 0, 0, 0}},
+		{"parser sampling default", func() { SetParser(customResultParser{}) }, []byte{0, 1, 1}, nil},
+		{"default parser", func() { SetParser(DefaultParser{}) }, nil, nil},
+	}
+	for _, c := range cases {
+		c.register()
+		results := captureStrResult(t)
+		if written := onSessionFilter(&CustomMessageCtx{HookPoint: SessionFilter}); written != (c.filter != nil) {
+			t.Errorf("%s: session filter written %v", c.name, written)
+		}
+		if written := onSampling(&CustomMessageCtx{HookPoint: Sampling}); written != (c.sampling != nil) {
+			t.Errorf("%s: sampling written %v", c.name, written)
+		}
+		var want [][]byte
+		for _, w := range [][]byte{c.filter, c.sampling} {
+			if w != nil {
+				want = append(want, w)
+			}
+		}
+		if len(*results) != len(want) {
+			t.Errorf("%s: got results %v, want %v", c.name, *results, want)
+			continue
+		}
+		for i := range want {
+			if !bytes.Equal((*results)[i], want[i]) {
+				t.Errorf("%s: got result %v, want %v", c.name, (*results)[i], want[i])
+			}
+		}
+	}
+}
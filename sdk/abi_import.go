@@ -1,3 +1,5 @@
+//go:build tinygo
+
 /*
  * Copyright (c) 2022 Yunshan Networks
  *
@@ -0,0 +1,56 @@
+//go:build !tinygo
+
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+import "unsafe"
+
+// the host functions only exist in the agent, the stubs make the sdk can be built and tested with the standard go toolchain
+
+// testLog receive the logs in the tests, the logs are dropped if nil
+var testLog func(s string, level uint8)
+
+func wasmLog(b *byte, length int, level uint8) {
+	if testLog != nil {
+		testLog(string(unsafe.Slice(b, length)), level)
+	}
+}
+
+func vmReadCtxBase(b *byte, length int) int { return 0 }
+
+func vmReadPayload(b *byte, length int) int { return -1 }
+
+func vmReadHttpReqInfo(b *byte, length int) int { return 0 }
+
+func vmReadHttpRespInfo(b *byte, length int) int { return 0 }
+
+func vmReadCustomMessageInfo(b *byte, length int) int { return 0 }
+
+func hostReadL7ProtocolInfo(b *byte, length int) bool { return false }
+
+func hostReadHttpResult(b *byte, length int) bool { return false }
+
+// testStrResult receive the result written by host_read_str_result in the tests
+var testStrResult func(b []byte) bool
+
+func hostReadStrResult(b *byte, length int) bool {
+	if testStrResult != nil {
+		return testStrResult(unsafe.Slice(b, length))
+	}
+	return false
+}
@@ -0,0 +1,248 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Package bin provide the bounds checked binary decoding, the malformed payload never panic with index out of range:
+
+	r := bin.NewReader(payload)
+	magic := r.Bytes(2)
+	hdrLen := r.U16()
+	body := r.Bytes(int(hdrLen))
+	if err := r.Err(); err != nil {
+		return err
+	}
+
+the error is sticky, once a read fail all the following reads return zero value, so the error only need to be checked
+once after a sequence of reads.
+*/
+package bin
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	ErrShortBuffer = errors.New("bin: short buffer")
+	ErrBadVarint   = errors.New("bin: bad varint")
+)
+
+type Reader struct {
+	b   []byte
+	off int
+	err error
+}
+
+func NewReader(b []byte) *Reader {
+	return &Reader{b: b}
+}
+
+// the first error encountered
+func (r *Reader) Err() error {
+	return r.err
+}
+
+func (r *Reader) Offset() int {
+	return r.off
+}
+
+func (r *Reader) Len() int {
+	return len(r.b) - r.off
+}
+
+// the unread bytes, alias the underlying buffer
+func (r *Reader) Remaining() []byte {
+	return r.b[r.off:]
+}
+
+// Fail set the sticky error if not set, use to report the semantic error such as unexpected magic
+func (r *Reader) Fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *Reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.b)-r.off {
+		r.err = ErrShortBuffer
+		return nil
+	}
+	b := r.b[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+// Peek return the next n bytes without advance, nil if not enough, the sticky error is not set
+func (r *Reader) Peek(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.b)-r.off {
+		return nil
+	}
+	return r.b[r.off : r.off+n]
+}
+
+func (r *Reader) Skip(n int) {
+	r.next(n)
+}
+
+// Bytes return the next n bytes, alias the underlying buffer
+func (r *Reader) Bytes(n int) []byte {
+	return r.next(n)
+}
+
+func (r *Reader) String(n int) string {
+	return string(r.next(n))
+}
+
+func (r *Reader) U8() uint8 {
+	b := r.next(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *Reader) U16() uint16 {
+	return r.U16BE()
+}
+
+func (r *Reader) U32() uint32 {
+	return r.U32BE()
+}
+
+func (r *Reader) U64() uint64 {
+	return r.U64BE()
+}
+
+func (r *Reader) U16BE() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *Reader) U32BE() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *Reader) U64BE() uint64 {
+	if b := r.next(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *Reader) U16LE() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *Reader) U32LE() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *Reader) U64LE() uint64 {
+	if b := r.next(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+// unsigned varint as protobuf
+func (r *Reader) Uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.b[r.off:])
+	switch {
+	case n == 0:
+		r.err = ErrShortBuffer
+		return 0
+	case n < 0:
+		r.err = ErrBadVarint
+		return 0
+	}
+	r.off += n
+	return v
+}
+
+// zigzag encoded signed varint
+func (r *Reader) Varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.b[r.off:])
+	switch {
+	case n == 0:
+		r.err = ErrShortBuffer
+		return 0
+	case n < 0:
+		r.err = ErrBadVarint
+		return 0
+	}
+	r.off += n
+	return v
+}
+
+// the length prefixed bytes, the length is u8, u16 be and u32 be respectively
+func (r *Reader) Bytes8() []byte {
+	return r.next(int(r.U8()))
+}
+
+func (r *Reader) Bytes16() []byte {
+	return r.next(int(r.U16BE()))
+}
+
+func (r *Reader) Bytes32() []byte {
+	n := r.U32BE()
+	if n > uint32(len(r.b)) {
+		r.Fail(ErrShortBuffer)
+		return nil
+	}
+	return r.next(int(n))
+}
+
+// bytes prefixed with unsigned varint length
+func (r *Reader) BytesVarint() []byte {
+	n := r.Uvarint()
+	if n > uint64(len(r.b)) {
+		r.Fail(ErrShortBuffer)
+		return nil
+	}
+	return r.next(int(n))
+}
+
+func (r *Reader) String8() string {
+	return string(r.Bytes8())
+}
+
+func (r *Reader) String16() string {
+	return string(r.Bytes16())
+}
+
+func (r *Reader) String32() string {
+	return string(r.Bytes32())
+}
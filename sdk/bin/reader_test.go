@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bin
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	data := []byte{
+		0x01,
+		0x02, 0x03,
+		0x04, 0x05, 0x06, 0x07,
+		0x03, 0x04,
+		0x96, 0x01,
+		0x03, // zigzag -2
+		0x02, 'h', 'i',
+		0x00, 0x01, 'x',
+	}
+	r := NewReader(data)
+	got := []uint64{
+		uint64(r.U8()),
+		uint64(r.U16()),
+		uint64(r.U32LE()),
+		uint64(r.U16LE()),
+		r.Uvarint(),
+		uint64(r.Varint()),
+	}
+	want := []uint64{0x01, 0x0203, 0x07060504, 0x0403, 150, 0xfffffffffffffffe}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("read %d: got %#x, want %#x", i, got[i], want[i])
+		}
+	}
+	if s := r.String8(); s != "hi" {
+		t.Errorf("String8 got %q", s)
+	}
+	if p := r.Peek(3); string(p) != "\x00\x01x" || r.Offset() != 15 {
+		t.Errorf("Peek got %q at %d", p, r.Offset())
+	}
+	if s := r.String16(); s != "x" || r.Len() != 0 || r.Err() != nil {
+		t.Errorf("String16 got %q, len %d, err %v", s, r.Len(), r.Err())
+	}
+}
+
+func TestReaderMalformed(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		read func(r *Reader)
+		err  error
+	}{
+		{"short u8", nil, func(r *Reader) { r.U8() }, ErrShortBuffer},
+		{"short u16", []byte{1}, func(r *Reader) { r.U16BE() }, ErrShortBuffer},
+		{"short u32", []byte{1, 2, 3}, func(r *Reader) { r.U32LE() }, ErrShortBuffer},
+		{"short u64", []byte{1, 2, 3, 4, 5, 6, 7}, func(r *Reader) { r.U64() }, ErrShortBuffer},
+		{"truncated uvarint", []byte{0x80, 0x80}, func(r *Reader) { r.Uvarint() }, ErrShortBuffer},
+		{"overflow uvarint", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, func(r *Reader) { r.Uvarint() }, ErrBadVarint},
+		{"truncated varint", []byte{0x80}, func(r *Reader) { r.Varint() }, ErrShortBuffer},
+		{"short bytes8", []byte{3, 'a'}, func(r *Reader) { r.Bytes8() }, ErrShortBuffer},
+		{"short bytes16", []byte{0, 3, 'a'}, func(r *Reader) { r.Bytes16() }, ErrShortBuffer},
+		{"huge bytes32", []byte{0xff, 0xff, 0xff, 0xff, 'a'}, func(r *Reader) { r.Bytes32() }, ErrShortBuffer},
+		{"huge bytes varint", []byte{0xff, 0xff, 0xff, 0xff, 0x0f, 'a'}, func(r *Reader) { r.BytesVarint() }, ErrShortBuffer},
+		{"negative bytes", []byte{1}, func(r *Reader) { r.Bytes(-1) }, ErrShortBuffer},
+		{"skip beyond", []byte{1}, func(r *Reader) { r.Skip(2) }, ErrShortBuffer},
+	}
+	for _, c := range cases {
+		r := NewReader(c.data)
+		c.read(r)
+		if r.Err() != c.err {
+			t.Errorf("%s: got error %v, want %v", c.name, r.Err(), c.err)
+		}
+	}
+}
+
+func TestReaderStickyError(t *testing.T) {
+	r := NewReader([]byte{1, 2, 3})
+	r.U32()
+	if v := r.U8(); v != 0 || r.Offset() != 0 {
+		t.Errorf("read after error got %d at %d", v, r.Offset())
+	}
+	if r.Peek(1) != nil {
+		t.Error("peek after error should be nil")
+	}
+	semantic := errors.New("bad magic")
+	r = NewReader([]byte{1, 2})
+	r.Fail(semantic)
+	r.Fail(ErrShortBuffer)
+	if r.Err() != semantic || r.U8() != 0 {
+		t.Errorf("the first error is kept, got %v", r.Err())
+	}
+}
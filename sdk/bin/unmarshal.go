@@ -0,0 +1,431 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var ErrMagic = errors.New("bin: magic mismatch")
+
+/*
+Unmarshal decode the payload into the struct pointed by v according to the `bin` tag of the fields, the fields are
+decoded in order. the tag is a comma separated list of options:
+
+	u8, u16, u32, u64, i8, i16, i32, i64: the integer encoding, inferred from the field type if absent
+	uvarint, varint:                       the varint encoding
+	be, le:                                the byte order, default be
+	len=N, len=Field:                      the size of string or []byte, a constant or the value of a previous field
+	rest:                                  string or []byte take all the remaining bytes
+	magic=KR, magic=0xdabb:                the expected bytes, fail with ErrMagic on mismatch
+	-:                                     ignore the field
+
+for example, the header of go http2 uprobe:
+
+	type header struct {
+		Fd       uint32 `bin:"le"`
+		StreamID uint32 `bin:"le"`
+		KeyLen   uint32 `bin:"le"`
+		ValLen   uint32 `bin:"le"`
+		Key      string `bin:"len=KeyLen"`
+		Val      string `bin:"len=ValLen"`
+	}
+
+the integer encoding must fit the field type, such as u32 for uint8 and u64 for int64 are rejected, the varint value
+overflow the field fail on decoding. the options of a nested struct field are rejected, tag its own fields instead.
+
+only bool, integer, string, []byte, [N]byte and nested struct fields are supported, the []byte fields alias the
+payload. the parsed tags are cached per type, so the reflection cost is paid once.
+*/
+func Unmarshal(payload []byte, v interface{}) error {
+	return NewReader(payload).Unmarshal(v)
+}
+
+// Unmarshal decode from the current offset, see Unmarshal
+func (r *Reader) Unmarshal(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("bin: unmarshal target must be a non-nil struct pointer")
+	}
+	if err := r.unmarshalStruct(rv.Elem()); err != nil {
+		return err
+	}
+	return r.Err()
+}
+
+type encoding uint8
+
+const (
+	encodingDefault encoding = iota
+	encodingU8
+	encodingU16
+	encodingU32
+	encodingU64
+	encodingI8
+	encodingI16
+	encodingI32
+	encodingI64
+	encodingUvarint
+	encodingVarint
+)
+
+type fieldPlan struct {
+	index    int
+	name     string
+	encoding encoding
+	le       bool
+	magic    []byte
+	rest     bool
+	fixedLen int
+	lenField int // index of the field plan hold the length, -1 indicate absent
+	skip     bool
+	nested   bool
+}
+
+var (
+	planLock sync.Mutex
+	plans    = map[reflect.Type][]fieldPlan{}
+)
+
+func getPlan(t reflect.Type) ([]fieldPlan, error) {
+	planLock.Lock()
+	defer planLock.Unlock()
+	if p, ok := plans[t]; ok {
+		return p, nil
+	}
+	p, err := buildPlan(t)
+	if err != nil {
+		return nil, err
+	}
+	plans[t] = p
+	return p, nil
+}
+
+func buildPlan(t reflect.Type) ([]fieldPlan, error) {
+	var fields []fieldPlan
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("bin")
+		p := fieldPlan{index: i, name: f.Name, fixedLen: -1, lenField: -1}
+		if tag == "-" {
+			continue
+		}
+		var encodingName string
+		for _, opt := range strings.Split(tag, ",") {
+			key, val, _ := strings.Cut(strings.TrimSpace(opt), "=")
+			switch key {
+			case "u8", "u16", "u32", "u64", "i8", "i16", "i32", "i64", "uvarint", "varint":
+				encodingName = key
+			}
+			switch key {
+			case "":
+			case "u8":
+				p.encoding = encodingU8
+			case "u16":
+				p.encoding = encodingU16
+			case "u32":
+				p.encoding = encodingU32
+			case "u64":
+				p.encoding = encodingU64
+			case "i8":
+				p.encoding = encodingI8
+			case "i16":
+				p.encoding = encodingI16
+			case "i32":
+				p.encoding = encodingI32
+			case "i64":
+				p.encoding = encodingI64
+			case "uvarint":
+				p.encoding = encodingUvarint
+			case "varint":
+				p.encoding = encodingVarint
+			case "be":
+				p.le = false
+			case "le":
+				p.le = true
+			case "rest":
+				p.rest = true
+			case "magic":
+				magic, err := parseMagic(val)
+				if err != nil {
+					return nil, fmt.Errorf("bin: field %s: %v", f.Name, err)
+				}
+				p.magic = magic
+			case "len":
+				if n, err := strconv.Atoi(val); err == nil {
+					p.fixedLen = n
+					break
+				}
+				for j := range fields {
+					if fields[j].name == val {
+						p.lenField = j
+					}
+				}
+				if p.lenField < 0 {
+					return nil, fmt.Errorf("bin: field %s: length field %s not found before it", f.Name, val)
+				}
+			default:
+				return nil, fmt.Errorf("bin: field %s: unknown option %s", f.Name, key)
+			}
+		}
+
+		switch kind := f.Type.Kind(); kind {
+		case reflect.Bool, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint,
+			reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+			if p.encoding == encodingDefault {
+				p.encoding = inferEncoding(kind)
+			} else if kind != reflect.Bool && !p.encoding.fit(f.Type) {
+				// the varint is checked on decoding, because its size is unknown until read
+				return nil, fmt.Errorf("bin: field %s: %s overflow %s", f.Name, encodingName, f.Type)
+			}
+			if p.magic != nil {
+				return nil, fmt.Errorf("bin: field %s: magic is only supported by string and bytes", f.Name)
+			}
+			if p.fixedLen >= 0 || p.lenField >= 0 || p.rest {
+				return nil, fmt.Errorf("bin: field %s: length is only supported by string and bytes", f.Name)
+			}
+		case reflect.String, reflect.Slice:
+			if kind == reflect.Slice && f.Type.Elem().Kind() != reflect.Uint8 {
+				return nil, fmt.Errorf("bin: field %s: only []byte slice is supported", f.Name)
+			}
+			if p.magic != nil {
+				p.fixedLen = len(p.magic)
+			}
+			if p.fixedLen < 0 && p.lenField < 0 && !p.rest {
+				return nil, fmt.Errorf("bin: field %s: length is required", f.Name)
+			}
+		case reflect.Array:
+			if f.Type.Elem().Kind() != reflect.Uint8 {
+				return nil, fmt.Errorf("bin: field %s: only byte array is supported", f.Name)
+			}
+			if p.magic != nil && len(p.magic) != f.Type.Len() {
+				return nil, fmt.Errorf("bin: field %s: magic size mismatch", f.Name)
+			}
+			p.fixedLen = f.Type.Len()
+		case reflect.Struct:
+			if f.PkgPath != "" {
+				return nil, fmt.Errorf("bin: field %s: nested struct must be exported", f.Name)
+			}
+			if strings.TrimSpace(tag) != "" {
+				// apply to the fields of the nested struct by their own tags
+				return nil, fmt.Errorf("bin: field %s: option is not supported by nested struct", f.Name)
+			}
+			p.nested = true
+		default:
+			return nil, fmt.Errorf("bin: field %s: unsupported type %s", f.Name, f.Type)
+		}
+		if f.PkgPath != "" {
+			// unexported field such as the padding `_`, still consume the bytes
+			p.skip = true
+		}
+		fields = append(fields, p)
+	}
+	return fields, nil
+}
+
+func parseMagic(s string) ([]byte, error) {
+	if strings.HasPrefix(s, "0x") {
+		return hex.DecodeString(s[2:])
+	}
+	if s == "" {
+		return nil, errors.New("empty magic")
+	}
+	return []byte(s), nil
+}
+
+func inferEncoding(kind reflect.Kind) encoding {
+	switch kind {
+	case reflect.Bool, reflect.Uint8:
+		return encodingU8
+	case reflect.Uint16:
+		return encodingU16
+	case reflect.Uint32:
+		return encodingU32
+	case reflect.Uint64, reflect.Uint:
+		return encodingU64
+	case reflect.Int8:
+		return encodingI8
+	case reflect.Int16:
+		return encodingI16
+	case reflect.Int32:
+		return encodingI32
+	default:
+		return encodingI64
+	}
+}
+
+// report whether the fixed size encoding fit the integer type, the varint always fit
+func (e encoding) fit(t reflect.Type) bool {
+	var bits int
+	switch e {
+	case encodingU8, encodingI8:
+		bits = 8
+	case encodingU16, encodingI16:
+		bits = 16
+	case encodingU32, encodingI32:
+		bits = 32
+	case encodingU64, encodingI64:
+		bits = 64
+	default:
+		return true
+	}
+	signed := e >= encodingI8 && e <= encodingI64
+	switch t.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		// the unsigned value need one more bit for the sign
+		return bits < t.Bits() || signed && bits == t.Bits()
+	}
+	// the negative value of the signed encoding is checked on decoding
+	return bits <= t.Bits()
+}
+
+func (r *Reader) readInt(p *fieldPlan) (uint64, bool) {
+	switch p.encoding {
+	case encodingU8, encodingI8:
+		v := r.U8()
+		if p.encoding == encodingI8 {
+			return uint64(int8(v)), true
+		}
+		return uint64(v), false
+	case encodingU16, encodingI16:
+		var v uint16
+		if p.le {
+			v = r.U16LE()
+		} else {
+			v = r.U16BE()
+		}
+		if p.encoding == encodingI16 {
+			return uint64(int16(v)), true
+		}
+		return uint64(v), false
+	case encodingU32, encodingI32:
+		var v uint32
+		if p.le {
+			v = r.U32LE()
+		} else {
+			v = r.U32BE()
+		}
+		if p.encoding == encodingI32 {
+			return uint64(int32(v)), true
+		}
+		return uint64(v), false
+	case encodingU64, encodingI64:
+		if p.le {
+			return r.U64LE(), p.encoding == encodingI64
+		}
+		return r.U64BE(), p.encoding == encodingI64
+	case encodingUvarint:
+		return r.Uvarint(), false
+	case encodingVarint:
+		return uint64(r.Varint()), true
+	}
+	return 0, false
+}
+
+func (r *Reader) unmarshalStruct(v reflect.Value) error {
+	plan, err := getPlan(v.Type())
+	if err != nil {
+		return err
+	}
+	// the decoded integer of each field, use for the length reference
+	values := make([]uint64, len(plan))
+	for i := range plan {
+		p := &plan[i]
+		fv := v.Field(p.index)
+		if p.nested {
+			if err := r.unmarshalStruct(fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch fv.Kind() {
+		case reflect.String, reflect.Slice, reflect.Array:
+			var b []byte
+			switch {
+			case p.rest:
+				b = r.Bytes(r.Len())
+			case p.lenField >= 0:
+				n := values[p.lenField]
+				if n > uint64(r.Len()) {
+					r.Fail(ErrShortBuffer)
+					return r.Err()
+				}
+				b = r.Bytes(int(n))
+			default:
+				b = r.Bytes(p.fixedLen)
+			}
+			if r.Err() != nil {
+				return r.Err()
+			}
+			if p.magic != nil && !bytes.Equal(b, p.magic) {
+				r.Fail(ErrMagic)
+				return r.Err()
+			}
+			if p.skip {
+				continue
+			}
+			switch fv.Kind() {
+			case reflect.String:
+				fv.SetString(string(b))
+			case reflect.Slice:
+				fv.SetBytes(b)
+			default:
+				for j := 0; j < len(b); j++ {
+					fv.Index(j).SetUint(uint64(b[j]))
+				}
+			}
+		default:
+			n, signed := r.readInt(p)
+			if r.Err() != nil {
+				return r.Err()
+			}
+			values[i] = n
+			if p.skip {
+				continue
+			}
+			switch fv.Kind() {
+			case reflect.Bool:
+				fv.SetBool(n != 0)
+			case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+				if !signed && int64(n) < 0 || fv.OverflowInt(int64(n)) {
+					r.Fail(fmt.Errorf("bin: field %s: value overflow", p.name))
+					return r.Err()
+				}
+				fv.SetInt(int64(n))
+			default:
+				if signed && int64(n) < 0 {
+					r.Fail(fmt.Errorf("bin: field %s: negative value for unsigned field", p.name))
+					return r.Err()
+				}
+				if fv.OverflowUint(n) {
+					r.Fail(fmt.Errorf("bin: field %s: value overflow", p.name))
+					return r.Err()
+				}
+				fv.SetUint(n)
+			}
+		}
+	}
+	return nil
+}
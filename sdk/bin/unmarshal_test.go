@@ -0,0 +1,203 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bin
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+type http2Header struct {
+	Fd       uint32 `bin:"le"`
+	StreamID uint32 `bin:"le"`
+	KeyLen   uint32 `bin:"le"`
+	ValLen   uint32 `bin:"le"`
+	Key      string `bin:"len=KeyLen"`
+	Val      string `bin:"len=ValLen"`
+}
+
+type krpcHeader struct {
+	Magic   string `bin:"magic=KR"`
+	HeadLen uint16
+	_       [2]byte
+	Flags   struct {
+		Oneway bool
+		Type   int8
+	}
+	Seq     uint64 `bin:"uvarint"`
+	Delta   int32  `bin:"varint"`
+	Code    int16  `bin:"i16,le"`
+	Trace   [4]byte
+	Ignored string `bin:"-"`
+	Body    []byte `bin:"rest"`
+}
+
+func http2Payload(key, val string) []byte {
+	var b []byte
+	b = binary.LittleEndian.AppendUint32(b, 3)
+	b = binary.LittleEndian.AppendUint32(b, 5)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(key)))
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(val)))
+	b = append(b, key...)
+	return append(b, val...)
+}
+
+func TestUnmarshal(t *testing.T) {
+	var h http2Header
+	if err := Unmarshal(http2Payload(":path", "/api"), &h); err != nil {
+		t.Fatal(err)
+	}
+	if h.Fd != 3 || h.StreamID != 5 || h.Key != ":path" || h.Val != "/api" {
+		t.Errorf("unexpected header %+v", h)
+	}
+
+	payload := []byte{'K', 'R', 0x00, 0x08, 0xee, 0xee, 0x01, 0xff, 0xac, 0x02, 0x03, 0xfe, 0xff, 't', 'r', 'a', 'c', 'b', 'o', 'd', 'y'}
+	var k krpcHeader
+	if err := Unmarshal(payload, &k); err != nil {
+		t.Fatal(err)
+	}
+	if k.Magic != "KR" || k.HeadLen != 8 || !k.Flags.Oneway || k.Flags.Type != -1 || k.Seq != 300 || k.Delta != -2 ||
+		k.Code != -2 || string(k.Trace[:]) != "trac" || string(k.Body) != "body" {
+		t.Errorf("unexpected header %+v", k)
+	}
+}
+
+func TestUnmarshalMalformed(t *testing.T) {
+	full := http2Payload(":path", "/api")
+	for i := 0; i < len(full); i++ {
+		var h http2Header
+		if err := Unmarshal(full[:i], &h); err != ErrShortBuffer {
+			t.Errorf("truncated at %d: got error %v", i, err)
+		}
+	}
+
+	// the length field larger than the payload
+	huge := http2Payload(":path", "/api")
+	binary.LittleEndian.PutUint32(huge[8:], 0xffffffff)
+	var h http2Header
+	if err := Unmarshal(huge, &h); err != ErrShortBuffer {
+		t.Errorf("huge length: got error %v", err)
+	}
+
+	var k krpcHeader
+	if err := Unmarshal([]byte("KX\x00\x08"), &k); err != ErrMagic {
+		t.Errorf("bad magic: got error %v", err)
+	}
+}
+
+func TestUnmarshalBadPlan(t *testing.T) {
+	cases := []struct {
+		name string
+		v    interface{}
+	}{
+		{"not pointer", http2Header{}},
+		{"nil pointer", (*http2Header)(nil)},
+		{"unknown option", &struct {
+			A uint8 `bin:"u7"`
+		}{}},
+		{"length absent", &struct {
+			A string
+		}{}},
+		{"length field after", &struct {
+			A string `bin:"len=N"`
+			N uint8
+		}{}},
+		{"magic on integer", &struct {
+			A uint16 `bin:"magic=KR"`
+		}{}},
+		{"magic size mismatch", &struct {
+			A [3]byte `bin:"magic=KR"`
+		}{}},
+		{"slice of int", &struct {
+			A []int `bin:"rest"`
+		}{}},
+		{"unsupported type", &struct {
+			A float32
+		}{}},
+		{"u32 on uint8", &struct {
+			A uint8 `bin:"u32"`
+		}{}},
+		{"u64 on int64", &struct {
+			A int64 `bin:"u64"`
+		}{}},
+		{"u8 on int8", &struct {
+			A int8 `bin:"u8"`
+		}{}},
+		{"i16 on int8", &struct {
+			A int8 `bin:"i16"`
+		}{}},
+		{"length on integer", &struct {
+			A uint16 `bin:"len=2"`
+		}{}},
+		{"option on nested struct", &struct {
+			A struct {
+				B uint16
+			} `bin:"le"`
+		}{}},
+	}
+	for _, c := range cases {
+		if err := Unmarshal([]byte("KR\x00\x00\x00\x00"), c.v); err == nil {
+			t.Errorf("%s: expect fail", c.name)
+		}
+	}
+
+	decodes := []struct {
+		name    string
+		v       interface{}
+		payload []byte
+	}{
+		{"negative value for unsigned field", &struct {
+			A uint8 `bin:"i8"`
+		}{}, []byte{0xff}},
+		{"uvarint overflow int64", &struct {
+			A int64 `bin:"uvarint"`
+		}{}, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}},
+		{"varint overflow int8", &struct {
+			A int8 `bin:"varint"`
+		}{}, []byte{0x80, 0x02}},
+		{"uvarint overflow uint16", &struct {
+			A uint16 `bin:"uvarint"`
+		}{}, []byte{0x80, 0x80, 0x04}},
+	}
+	for _, c := range decodes {
+		r := NewReader(c.payload)
+		err := r.Unmarshal(c.v)
+		if err == nil {
+			t.Errorf("%s: expect fail", c.name)
+			continue
+		}
+		// the error is sticky
+		if r.Err() != err || r.U8() != 0 || r.Err() != err {
+			t.Errorf("%s: the error %v is not sticky", c.name, err)
+		}
+	}
+
+	// the narrower encoding and the signed encoding of the same size fit
+	var fit struct {
+		A int16  `bin:"u8"`
+		B uint64 `bin:"u32,le"`
+		C int32  `bin:"i32"`
+		D bool   `bin:"u16"`
+		E int8   `bin:"varint"`
+	}
+	if err := Unmarshal([]byte{0xff, 1, 0, 0, 0, 0xff, 0xff, 0xff, 0xfe, 0, 1, 0x03}, &fit); err != nil {
+		t.Fatal(err)
+	}
+	if fit.A != 255 || fit.B != 1 || fit.C != -2 || !fit.D || fit.E != -2 {
+		t.Errorf("unexpected %+v", fit)
+	}
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Package body decode the http body by Content-Encoding and the charset of Content-Type, the supported encodings are
+gzip, deflate, br and zstd, and several encodings such as "gzip, br" are decoded in reverse order.
+
+the body in a payload is often truncated by the tcp segmentation, so the decoded prefix is returned with Partial
+instead of an error. the output never exceed MaxSize to avoid the decompression bomb exhausting the memory of agent:
+
+	m, _ := http1.ParseResponse(payload)
+	result, err := body.DecodeMessage(m)
+	if err == nil {
+		jsonpath.Get(result.Data, "$.status")
+	}
+
+the zstd decoder github.com/klauspost/compress require go 1.22, so the plugin must be built by TinyGo 0.31 or newer.
+*/
+package body
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andybalholm/brotli"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/http1"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	DEFAULT_MAX_SIZE = 64 << 10
+	// the max window of zstd, the larger frame is rejected to limit the memory
+	ZSTD_MAX_WINDOW = 8 << 20
+)
+
+var ErrUnsupported = errors.New("body: unsupported content encoding")
+
+type Decoder struct {
+	// the max size of the decoded output, the output exceed is truncated. 0 indicate DEFAULT_MAX_SIZE
+	MaxSize int
+	// convert the text in the charset of Content-Type to utf8
+	Charset bool
+}
+
+var DefaultDecoder = &Decoder{MaxSize: DEFAULT_MAX_SIZE, Charset: true}
+
+type Result struct {
+	Data []byte
+	// the output exceed MaxSize and is truncated
+	Truncated bool
+	// the input end unexpectedly or is corrupted after some output, Data is the decoded prefix
+	Partial bool
+	// the charset in Content-Type in lower case, Data is converted to utf8 if it is supported
+	Charset string
+}
+
+// Decode by DefaultDecoder
+func Decode(contentEncoding, contentType string, data []byte) (*Result, error) {
+	return DefaultDecoder.Decode(contentEncoding, contentType, data)
+}
+
+// DecodeMessage by DefaultDecoder
+func DecodeMessage(m *http1.Message) (*Result, error) {
+	return DefaultDecoder.DecodeMessage(m)
+}
+
+// DecodeMessage decode the body of the message parsed by http1, the chunked body is decoded first
+func (d *Decoder) DecodeMessage(m *http1.Message) (*Result, error) {
+	data := m.Body
+	if m.Chunked {
+		// the error of chunked is treated as truncation, the data decoded are kept
+		data, _ = m.DecodeBody(nil)
+	}
+	return d.Decode(m.HeaderString("Content-Encoding"), m.HeaderString("Content-Type"), data)
+}
+
+func (d *Decoder) Decode(contentEncoding, contentType string, data []byte) (*Result, error) {
+	maxSize := d.MaxSize
+	if maxSize <= 0 {
+		maxSize = DEFAULT_MAX_SIZE
+	}
+	result := &Result{}
+
+	var (
+		r       io.Reader = bytes.NewReader(data)
+		closers []io.Closer
+	)
+	defer func() {
+		for _, c := range closers {
+			c.Close()
+		}
+	}()
+	encodings := strings.Split(contentEncoding, ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		reader, err := newReader(strings.ToLower(strings.TrimSpace(encodings[i])), r)
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := reader.(io.Closer); ok {
+			closers = append(closers, c)
+		}
+		r = reader
+	}
+
+	// read one more byte to know whether the output exceed
+	out, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if len(out) > maxSize {
+		out = out[:maxSize]
+		result.Truncated = true
+	}
+	if err != nil {
+		if len(out) == 0 {
+			return nil, err
+		}
+		result.Partial = true
+	}
+	result.Data = out
+
+	if d.Charset {
+		result.Charset = charsetOf(contentType)
+		if decode := lookupCharset(result.Charset); decode != nil {
+			result.Data = decode(result.Data)
+			// the utf8 may be longer than the charset, such as latin1
+			if len(result.Data) > maxSize {
+				result.Data = truncateUTF8(result.Data, maxSize)
+				result.Truncated = true
+			}
+		}
+	}
+	return result, nil
+}
+
+// truncate to n bytes at most without splitting the rune
+func truncateUTF8(b []byte, n int) []byte {
+	for n > 0 && !utf8.RuneStart(b[n]) {
+		n--
+	}
+	return b[:n]
+}
+
+func newReader(encoding string, r io.Reader) (io.Reader, error) {
+	switch encoding {
+	case "", "identity":
+		return r, nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		return newDeflateReader(r)
+	case "br":
+		// the brotli stream truncated at the boundary of meta-block end without error, so Partial is not set
+		return brotli.NewReader(r), nil
+	case "zstd":
+		z, err := zstd.NewReader(r,
+			zstd.WithDecoderConcurrency(1),
+			zstd.WithDecoderLowmem(true),
+			zstd.WithDecoderMaxWindow(ZSTD_MAX_WINDOW),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return z.IOReadCloser(), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupported, encoding)
+}
+
+// the deflate of http is zlib format, but some servers send the raw deflate without the zlib header
+func newDeflateReader(r io.Reader) (io.Reader, error) {
+	var header [2]byte
+	n, _ := io.ReadFull(r, header[:])
+	r = io.MultiReader(bytes.NewReader(header[:n]), r)
+	if n == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(r)
+	}
+	return flate.NewReader(r), nil
+}
@@ -0,0 +1,223 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package body
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/http1"
+	"github.com/klauspost/compress/zstd"
+)
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+		err error
+	)
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	case "flate":
+		w, err = flate.NewWriter(&buf, flate.DefaultCompression)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	case "zstd":
+		w, err = zstd.NewWriter(&buf)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+func text(n int) []byte {
+	var b bytes.Buffer
+	for i := 0; b.Len() < n; i++ {
+		fmt.Fprintf(&b, "line %d of the body;", i)
+	}
+	return b.Bytes()[:n]
+}
+
+func TestDecode(t *testing.T) {
+	plain := text(4096)
+	gz := compress(t, "gzip", plain)
+	cases := []struct {
+		name      string
+		encoding  string
+		data      []byte
+		maxSize   int
+		want      []byte
+		truncated bool
+		partial   bool
+	}{
+		{"identity", "", plain, 0, plain, false, false},
+		{"identity truncated", "identity", plain, 100, plain[:100], true, false},
+		{"gzip", "GZIP", gz, 0, plain, false, false},
+		{"x-gzip", "x-gzip", gz, 0, plain, false, false},
+		{"zlib", "deflate", compress(t, "zlib", plain), 0, plain, false, false},
+		{"raw deflate", "deflate", compress(t, "flate", plain), 0, plain, false, false},
+		{"brotli", "br", compress(t, "br", plain), 0, plain, false, false},
+		{"zstd", "zstd", compress(t, "zstd", plain), 0, plain, false, false},
+		{"gzip then br", "gzip, br", compress(t, "br", gz), 0, plain, false, false},
+		{"gzip bomb", "gzip", compress(t, "gzip", make([]byte, 8<<20)), 1024, make([]byte, 1024), true, false},
+		{"zstd bomb", "zstd", compress(t, "zstd", make([]byte, 8<<20)), 1024, make([]byte, 1024), true, false},
+		{"exact max size", "gzip", gz, len(plain), plain, false, false},
+	}
+	for _, c := range cases {
+		d := &Decoder{MaxSize: c.maxSize}
+		r, err := d.Decode(c.encoding, "", c.data)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if !bytes.Equal(r.Data, c.want) || r.Truncated != c.truncated || r.Partial != c.partial {
+			t.Errorf("%s: got %d bytes truncated %v partial %v, want %d bytes truncated %v partial %v",
+				c.name, len(r.Data), r.Truncated, r.Partial, len(c.want), c.truncated, c.partial)
+		}
+	}
+}
+
+func TestDecodePartial(t *testing.T) {
+	// zstd output nothing until the whole block is received, so it is not included
+	plain := text(64 << 10)
+	for _, encoding := range []string{"gzip", "zlib", "flate"} {
+		data := compress(t, encoding, plain)
+		contentEncoding := encoding
+		if encoding == "zlib" || encoding == "flate" {
+			contentEncoding = "deflate"
+		}
+		r, err := DefaultDecoder.Decode(contentEncoding, "", data[:len(data)/2])
+		if err != nil {
+			t.Errorf("%s: %v", encoding, err)
+			continue
+		}
+		if !r.Partial || len(r.Data) == 0 || !bytes.HasPrefix(plain, r.Data) {
+			t.Errorf("%s: got %d bytes partial %v", encoding, len(r.Data), r.Partial)
+		}
+	}
+
+	cases := []struct {
+		name     string
+		encoding string
+		data     []byte
+	}{
+		{"gzip header", "gzip", []byte{0x1f, 0x8b}},
+		{"not gzip", "gzip", []byte("plain text")},
+		{"corrupted zlib", "deflate", []byte{0x78, 0x9c, 0xff, 0xff, 0xff}},
+	}
+	for _, c := range cases {
+		if r, err := DefaultDecoder.Decode(c.encoding, "", c.data); err == nil {
+			t.Errorf("%s: got %q without error", c.name, r.Data)
+		}
+	}
+	if _, err := Decode("compress", "", []byte("x")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("got error %v, want %v", err, ErrUnsupported)
+	}
+}
+
+func TestCharset(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		data        string
+		maxSize     int
+		want        string
+		charset     string
+		truncated   bool
+	}{
+		{"utf8", "text/plain; charset=utf-8", "caf\xc3\xa9", 0, "caf\xc3\xa9", "utf-8", false},
+		{"no charset", "application/json", "caf\xe9", 0, "caf\xe9", "", false},
+		{"latin1 quoted", `text/html; Charset="ISO-8859-1"`, "caf\xe9", 0, "café", "iso-8859-1", false},
+		{"latin1 on rune boundary", "text/plain; charset=latin1", "\xe9\xe9\xe9", 3, "é", "latin1", true},
+		{"latin1 fit", "text/plain; charset=latin1", "a\xe9", 3, "aé", "latin1", false},
+		{"windows-1252", "text/plain;charset=windows-1252", "\x80\x81\x93x\x94", 0, "€\u0081“x”", "windows-1252", false},
+		{"utf-16 le", "text/plain; charset=utf-16", "h\x00i\x00", 0, "hi", "utf-16", false},
+		{"utf-16 bom be", "text/plain; charset=utf-16", "\xfe\xff\x00h\x00i", 0, "hi", "utf-16", false},
+		{"utf-16be truncated", "text/plain; charset=utf-16be", "\x00h\x00i\x00", 0, "hi", "utf-16be", false},
+		{"utf-16 truncated surrogate", "text/plain; charset=utf-16le", "h\x00\x3d\xd8", 0, "h", "utf-16le", false},
+		{"utf-16 surrogate pair", "text/plain; charset=utf-16le", "\x3d\xd8\x00\xde", 0, "😀", "utf-16le", false},
+	}
+	for _, c := range cases {
+		d := &Decoder{MaxSize: c.maxSize, Charset: true}
+		r, err := d.Decode("", c.contentType, []byte(c.data))
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if string(r.Data) != c.want || r.Charset != c.charset || r.Truncated != c.truncated {
+			t.Errorf("%s: got %q charset %q truncated %v, want %q charset %q truncated %v",
+				c.name, r.Data, r.Charset, r.Truncated, c.want, c.charset, c.truncated)
+		}
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	s := []byte("aé😀")
+	for n, want := range []string{"", "a", "a", "aé", "aé", "aé", "aé"} {
+		if got := truncateUTF8(s, n); string(got) != want {
+			t.Errorf("truncate %d: got %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestDecodeMessage(t *testing.T) {
+	plain := text(1000)
+	gz := compress(t, "gzip", plain)
+	var chunked strings.Builder
+	for i := 0; i < len(gz); i += 100 {
+		end := i + 100
+		if end > len(gz) {
+			end = len(gz)
+		}
+		fmt.Fprintf(&chunked, "%x\r\n%s\r\n", end-i, gz[i:end])
+	}
+	chunked.WriteString("0\r\n\r\n")
+
+	header := "HTTP/1.1 200 OK\r\nContent-Encoding: gzip\r\nContent-Type: text/plain; charset=utf-8\r\nTransfer-Encoding: chunked\r\n\r\n"
+	m, err := http1.ParseResponse([]byte(header + chunked.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := DecodeMessage(m)
+	if err != nil || !bytes.Equal(r.Data, plain) || r.Partial || r.Charset != "utf-8" {
+		t.Errorf("got %d bytes partial %v charset %q, error %v", len(r.Data), r.Partial, r.Charset, err)
+	}
+
+	// the payload is cut in the middle of the chunks
+	m, err = http1.ParseResponse([]byte(header + chunked.String()[:len(chunked.String())/2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err = DecodeMessage(m)
+	if err != nil || !r.Partial || len(r.Data) == 0 || !bytes.HasPrefix(plain, r.Data) {
+		t.Errorf("truncated: got %d bytes partial %v, error %v", len(r.Data), r.Partial, err)
+	}
+}
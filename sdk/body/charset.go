@@ -0,0 +1,133 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package body
+
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// CharsetDecoder convert the text to utf8, the truncated character at the end should be dropped
+type CharsetDecoder func(b []byte) []byte
+
+/*
+the built-in charsets are iso-8859-1, windows-1252 and utf-16, the utf8 and ascii need no conversion. the other
+charsets such as gbk can be registered by RegisterCharset with golang.org/x/text:
+
+	body.RegisterCharset("gbk", func(b []byte) []byte {
+		out, _, _ := transform.Bytes(simplifiedchinese.GBK.NewDecoder(), b)
+		return out
+	})
+*/
+var charsets = map[string]CharsetDecoder{
+	"iso-8859-1":   decodeLatin1,
+	"iso8859-1":    decodeLatin1,
+	"latin1":       decodeLatin1,
+	"windows-1252": decodeWindows1252,
+	"cp1252":       decodeWindows1252,
+	"utf-16":       decodeUTF16,
+	"utf-16le":     decodeUTF16,
+	"utf-16be":     decodeUTF16BE,
+}
+
+// RegisterCharset is not concurrent safe, it should be called in init or main
+func RegisterCharset(name string, decode CharsetDecoder) {
+	charsets[strings.ToLower(name)] = decode
+}
+
+func lookupCharset(name string) CharsetDecoder {
+	if name == "" {
+		return nil
+	}
+	return charsets[name]
+}
+
+// the charset parameter of Content-Type such as text/html; charset="UTF-8", in lower case
+func charsetOf(contentType string) string {
+	for _, param := range strings.Split(contentType, ";")[1:] {
+		key, val, ok := strings.Cut(param, "=")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "charset") {
+			return strings.ToLower(strings.Trim(strings.TrimSpace(val), `"`))
+		}
+	}
+	return ""
+}
+
+func decodeLatin1(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for _, c := range b {
+		out = utf8.AppendRune(out, rune(c))
+	}
+	return out
+}
+
+// the differences from iso-8859-1 in 0x80 - 0x9f, 0 indicate undefined which is kept as iso-8859-1
+var windows1252 = [32]rune{
+	0x20ac, 0, 0x201a, 0x0192, 0x201e, 0x2026, 0x2020, 0x2021, 0x02c6, 0x2030, 0x0160, 0x2039, 0x0152, 0, 0x017d, 0,
+	0, 0x2018, 0x2019, 0x201c, 0x201d, 0x2022, 0x2013, 0x2014, 0x02dc, 0x2122, 0x0161, 0x203a, 0x0153, 0, 0x017e, 0x0178,
+}
+
+func decodeWindows1252(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for _, c := range b {
+		r := rune(c)
+		if c >= 0x80 && c < 0xa0 && windows1252[c-0x80] != 0 {
+			r = windows1252[c-0x80]
+		}
+		out = utf8.AppendRune(out, r)
+	}
+	return out
+}
+
+// utf-16 without BOM is little endian as the most implementations
+func decodeUTF16(b []byte) []byte {
+	if len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff {
+		return decodeUTF16Order(b[2:], true)
+	}
+	if len(b) >= 2 && b[0] == 0xff && b[1] == 0xfe {
+		b = b[2:]
+	}
+	return decodeUTF16Order(b, false)
+}
+
+func decodeUTF16BE(b []byte) []byte {
+	if len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff {
+		b = b[2:]
+	}
+	return decodeUTF16Order(b, true)
+}
+
+func decodeUTF16Order(b []byte, bigEndian bool) []byte {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+		} else {
+			units = append(units, uint16(b[i+1])<<8|uint16(b[i]))
+		}
+	}
+	// drop the truncated surrogate pair
+	if n := len(units); n > 0 && utf16.IsSurrogate(rune(units[n-1])) && units[n-1] < 0xdc00 {
+		units = units[:n-1]
+	}
+	out := make([]byte, 0, len(units))
+	for _, r := range utf16.Decode(units) {
+		out = utf8.AppendRune(out, r)
+	}
+	return out
+}
@@ -0,0 +1,351 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dynpb
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var (
+	ErrTooDeep   = errors.New("dynpb: message too deep")
+	ErrWireType  = errors.New("dynpb: unexpected wire type")
+	ErrTruncated = errors.New("dynpb: message truncated")
+)
+
+// the decoded message, the values of each field are kept in the order of the fields in schema
+type object struct {
+	msg    *Message
+	values map[*Field][]interface{}
+}
+
+/*
+the scalar value is one of:
+
+	int64:   int32, int64, sint32, sint64, sfixed32, sfixed64 and enum
+	uint64:  uint32, uint64, fixed32, fixed64
+	float64: float and double
+	bool, string, []byte
+	*object: message
+*/
+func (s *Schema) decode(name string, data []byte) (*object, error) {
+	m := s.Message(name)
+	if m == nil {
+		return nil, fmt.Errorf("%w: %s", ErrMessageNotFound, name)
+	}
+	return s.decodeMessage(m, data, 0)
+}
+
+func (s *Schema) decodeMessage(m *Message, b []byte, depth int) (*object, error) {
+	maxDepth := s.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DEFAULT_MAX_DEPTH
+	}
+	if depth >= maxDepth {
+		return nil, ErrTooDeep
+	}
+	obj := &object{msg: m, values: make(map[*Field][]interface{})}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, ErrTruncated
+		}
+		b = b[n:]
+		f := m.FieldByNumber(int32(num))
+		if f == nil {
+			// skip the unknown field
+			if n = protowire.ConsumeFieldValue(num, typ, b); n < 0 {
+				return nil, ErrTruncated
+			}
+			b = b[n:]
+			continue
+		}
+		values, n, err := s.decodeField(f, typ, b, depth)
+		if err != nil {
+			return nil, err
+		}
+		b = b[n:]
+		if f.Repeated {
+			obj.values[f] = append(obj.values[f], values...)
+		} else {
+			// the last one win for singular field
+			obj.values[f] = values[len(values)-1:]
+		}
+	}
+	return obj, nil
+}
+
+func (s *Schema) decodeField(f *Field, typ protowire.Type, b []byte, depth int) ([]interface{}, int, error) {
+	switch typ {
+	case protowire.VarintType:
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return nil, 0, ErrTruncated
+		}
+		val, ok := varintScalar(f.Type, v)
+		if !ok {
+			return nil, 0, fmt.Errorf("%w: field %s", ErrWireType, f.Name)
+		}
+		return []interface{}{val}, n, nil
+	case protowire.Fixed32Type:
+		v, n := protowire.ConsumeFixed32(b)
+		if n < 0 {
+			return nil, 0, ErrTruncated
+		}
+		val, ok := fixed32Scalar(f.Type, v)
+		if !ok {
+			return nil, 0, fmt.Errorf("%w: field %s", ErrWireType, f.Name)
+		}
+		return []interface{}{val}, n, nil
+	case protowire.Fixed64Type:
+		v, n := protowire.ConsumeFixed64(b)
+		if n < 0 {
+			return nil, 0, ErrTruncated
+		}
+		val, ok := fixed64Scalar(f.Type, v)
+		if !ok {
+			return nil, 0, fmt.Errorf("%w: field %s", ErrWireType, f.Name)
+		}
+		return []interface{}{val}, n, nil
+	case protowire.BytesType:
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return nil, 0, ErrTruncated
+		}
+		switch f.Type {
+		case TypeString:
+			return []interface{}{string(v)}, n, nil
+		case TypeBytes:
+			return []interface{}{v}, n, nil
+		case TypeMessage:
+			obj, err := s.decodeMessage(f.Message, v, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			return []interface{}{obj}, n, nil
+		default:
+			if !f.Repeated {
+				return nil, 0, fmt.Errorf("%w: field %s", ErrWireType, f.Name)
+			}
+			values, err := decodePacked(f, v)
+			return values, n, err
+		}
+	}
+	return nil, 0, fmt.Errorf("%w: field %s", ErrWireType, f.Name)
+}
+
+func decodePacked(f *Field, b []byte) ([]interface{}, error) {
+	var values []interface{}
+	for len(b) > 0 {
+		var (
+			val interface{}
+			ok  bool
+			n   int
+		)
+		switch f.Type {
+		case TypeFixed32, TypeSfixed32, TypeFloat:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(b)
+			val, ok = fixed32Scalar(f.Type, v)
+		case TypeFixed64, TypeSfixed64, TypeDouble:
+			var v uint64
+			v, n = protowire.ConsumeFixed64(b)
+			val, ok = fixed64Scalar(f.Type, v)
+		default:
+			var v uint64
+			v, n = protowire.ConsumeVarint(b)
+			val, ok = varintScalar(f.Type, v)
+		}
+		if n < 0 {
+			return nil, ErrTruncated
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: field %s", ErrWireType, f.Name)
+		}
+		values = append(values, val)
+		b = b[n:]
+	}
+	return values, nil
+}
+
+func varintScalar(t FieldType, v uint64) (interface{}, bool) {
+	switch t {
+	case TypeInt32, TypeEnum:
+		return int64(int32(v)), true
+	case TypeInt64:
+		return int64(v), true
+	case TypeUint32:
+		return uint64(uint32(v)), true
+	case TypeUint64:
+		return v, true
+	case TypeSint32:
+		return int64(int32(protowire.DecodeZigZag(v & math.MaxUint32))), true
+	case TypeSint64:
+		return protowire.DecodeZigZag(v), true
+	case TypeBool:
+		return v != 0, true
+	}
+	return nil, false
+}
+
+func fixed32Scalar(t FieldType, v uint32) (interface{}, bool) {
+	switch t {
+	case TypeFixed32:
+		return uint64(v), true
+	case TypeSfixed32:
+		return int64(int32(v)), true
+	case TypeFloat:
+		return float64(math.Float32frombits(v)), true
+	}
+	return nil, false
+}
+
+func fixed64Scalar(t FieldType, v uint64) (interface{}, bool) {
+	switch t {
+	case TypeFixed64:
+		return v, true
+	case TypeSfixed64:
+		return int64(v), true
+	case TypeDouble:
+		return math.Float64frombits(v), true
+	}
+	return nil, false
+}
+
+// format the scalar value as the field path map value
+func formatScalar(f *Field, v interface{}) string {
+	switch v := v.(type) {
+	case int64:
+		if f.Type == TypeEnum {
+			if name, ok := f.Enum.Values[int32(v)]; ok {
+				return name
+			}
+		}
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		if f.Type == TypeFloat {
+			return strconv.FormatFloat(v, 'g', -1, 32)
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	}
+	return ""
+}
+
+/*
+DecodePaths decode the message by full name into a map from the field path to the value, such as:
+
+	order.id:            42
+	order.items[0].name: apple
+	labels[env]:         prod
+
+the path consists of the field names in proto, the repeated field is indexed by the position and the map field is
+indexed by the key. the enum value is formatted as name and bytes as base64.
+*/
+func (s *Schema) DecodePaths(name string, data []byte) (map[string]string, error) {
+	obj, err := s.decode(name, data)
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[string]string)
+	obj.paths("", paths)
+	return paths, nil
+}
+
+func (o *object) paths(prefix string, paths map[string]string) {
+	for _, f := range o.msg.Fields {
+		values, ok := o.values[f]
+		if !ok {
+			continue
+		}
+		path := f.Name
+		if prefix != "" {
+			path = prefix + "." + f.Name
+		}
+		for i, v := range values {
+			p := path
+			if f.Message != nil && f.Message.MapEntry {
+				key, value := v.(*object).mapEntry()
+				p += "[" + key + "]"
+				if obj, ok := value.(*object); ok {
+					obj.paths(p, paths)
+				} else {
+					paths[p] = formatScalar(f.Message.FieldByNumber(2), value)
+				}
+				continue
+			}
+			if f.Repeated {
+				p += "[" + strconv.Itoa(i) + "]"
+			}
+			if obj, ok := v.(*object); ok {
+				obj.paths(p, paths)
+			} else {
+				paths[p] = formatScalar(f, v)
+			}
+		}
+	}
+}
+
+// return the formatted key and the value of the map entry, the absent value is the zero value
+func (o *object) mapEntry() (string, interface{}) {
+	var key string
+	if f := o.msg.FieldByNumber(1); f != nil {
+		if values := o.values[f]; len(values) > 0 {
+			key = formatScalar(f, values[0])
+		} else {
+			key = formatScalar(f, zeroValue(f))
+		}
+	}
+	f := o.msg.FieldByNumber(2)
+	if f == nil {
+		return key, nil
+	}
+	if values := o.values[f]; len(values) > 0 {
+		return key, values[0]
+	}
+	return key, zeroValue(f)
+}
+
+func zeroValue(f *Field) interface{} {
+	switch f.Type {
+	case TypeUint32, TypeUint64, TypeFixed32, TypeFixed64:
+		return uint64(0)
+	case TypeFloat, TypeDouble:
+		return float64(0)
+	case TypeBool:
+		return false
+	case TypeString:
+		return ""
+	case TypeBytes:
+		return []byte{}
+	case TypeMessage, TypeGroup:
+		return &object{msg: f.Message, values: map[*Field][]interface{}{}}
+	}
+	return int64(0)
+}
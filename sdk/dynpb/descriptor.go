@@ -0,0 +1,394 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Package dynpb decode the protobuf messages at runtime by the schema loaded from a serialized FileDescriptorSet, so the
+plugin need not to be rebuilt when the schema change. the descriptor set is generated by:
+
+	protoc --include_imports --descriptor_set_out=demo.pb demo.proto
+
+and embedded into the plugin or supplied by any other source:
+
+	//go:embed demo.pb
+	var descriptorSet []byte
+
+	schema, err := dynpb.Load(descriptorSet)
+	data, err := schema.DecodeJSON("pb.OrderRequest", payload)
+
+the descriptors are parsed by a small wire decoder into plain structs instead of the protoreflect registry, which is
+too heavy for tinygo.
+*/
+package dynpb
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var ErrMessageNotFound = errors.New("dynpb: message not found")
+
+// correspond FieldDescriptorProto.Type
+type FieldType int32
+
+const (
+	TypeDouble   FieldType = 1
+	TypeFloat    FieldType = 2
+	TypeInt64    FieldType = 3
+	TypeUint64   FieldType = 4
+	TypeInt32    FieldType = 5
+	TypeFixed64  FieldType = 6
+	TypeFixed32  FieldType = 7
+	TypeBool     FieldType = 8
+	TypeString   FieldType = 9
+	TypeGroup    FieldType = 10
+	TypeMessage  FieldType = 11
+	TypeBytes    FieldType = 12
+	TypeUint32   FieldType = 13
+	TypeEnum     FieldType = 14
+	TypeSfixed32 FieldType = 15
+	TypeSfixed64 FieldType = 16
+	TypeSint32   FieldType = 17
+	TypeSint64   FieldType = 18
+)
+
+const labelRepeated = 3
+
+type Field struct {
+	Name     string
+	JSONName string
+	Number   int32
+	Type     FieldType
+	Repeated bool
+	// the full name without the leading dot, for message and enum
+	TypeName string
+	Message  *Message
+	Enum     *Enum
+}
+
+type Message struct {
+	FullName string
+	Fields   []*Field
+	// key is field 1 and value is field 2
+	MapEntry bool
+	byNumber map[int32]*Field
+}
+
+// FieldByNumber return nil if not found
+func (m *Message) FieldByNumber(n int32) *Field {
+	return m.byNumber[n]
+}
+
+type Enum struct {
+	FullName string
+	Values   map[int32]string
+}
+
+// Schema is not safe for concurrent modification, but the plugin is always called in single thread
+type Schema struct {
+	// the max depth of nested messages, default 32
+	MaxDepth int
+	messages map[string]*Message
+	enums    map[string]*Enum
+}
+
+const DEFAULT_MAX_DEPTH = 32
+
+func NewSchema() *Schema {
+	return &Schema{
+		MaxDepth: DEFAULT_MAX_DEPTH,
+		messages: make(map[string]*Message),
+		enums:    make(map[string]*Enum),
+	}
+}
+
+// Load a serialized FileDescriptorSet, the set must contain all the dependencies, see protoc --include_imports
+func Load(descriptorSet []byte) (*Schema, error) {
+	s := NewSchema()
+	if err := s.Add(descriptorSet); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// LoadBase64 is like Load, for the descriptor set in text config
+func LoadBase64(descriptorSet string) (*Schema, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(descriptorSet))
+	if err != nil {
+		return nil, fmt.Errorf("dynpb: invalid base64 descriptor set: %v", err)
+	}
+	return Load(data)
+}
+
+// Add the files of another FileDescriptorSet, the message with the same full name is replaced
+func (s *Schema) Add(descriptorSet []byte) error {
+	err := walk(descriptorSet, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		if num == 1 && typ == protowire.BytesType {
+			return s.addFile(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("dynpb: invalid descriptor set: %v", err)
+	}
+	return s.resolve()
+}
+
+// Message lookup the message by full name, such as foo.bar.OrderRequest
+func (s *Schema) Message(fullName string) *Message {
+	return s.messages[strings.TrimPrefix(fullName, ".")]
+}
+
+/*
+the fields used in descriptor.proto:
+
+	FileDescriptorProto:      package 2, message_type 4, enum_type 5
+	DescriptorProto:          name 1, field 2, nested_type 3, enum_type 4, options 7
+	MessageOptions:           map_entry 7
+	FieldDescriptorProto:     name 1, number 3, label 4, type 5, type_name 6, json_name 10
+	EnumDescriptorProto:      name 1, value 2
+	EnumValueDescriptorProto: name 1, number 2
+*/
+func (s *Schema) addFile(b []byte) error {
+	var (
+		pkg      string
+		messages [][]byte
+		enums    [][]byte
+	)
+	err := walk(b, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch num {
+		case 2:
+			pkg = string(v)
+		case 4:
+			messages = append(messages, v)
+		case 5:
+			enums = append(enums, v)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, m := range messages {
+		if err := s.addMessage(pkg, m); err != nil {
+			return err
+		}
+	}
+	for _, e := range enums {
+		if err := s.addEnum(pkg, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func joinName(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+func (s *Schema) addMessage(scope string, b []byte) error {
+	m := &Message{byNumber: make(map[int32]*Field)}
+	var (
+		name   string
+		nested [][]byte
+		enums  [][]byte
+	)
+	err := walk(b, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch num {
+		case 1:
+			name = string(v)
+		case 2:
+			f, err := parseField(v)
+			if err != nil {
+				return err
+			}
+			m.Fields = append(m.Fields, f)
+			m.byNumber[f.Number] = f
+		case 3:
+			nested = append(nested, v)
+		case 4:
+			enums = append(enums, v)
+		case 7:
+			return walk(v, func(num protowire.Number, typ protowire.Type, v []byte) error {
+				if num == 7 {
+					m.MapEntry = varintValue(v) != 0
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.FullName = joinName(scope, name)
+	s.messages[m.FullName] = m
+	for _, n := range nested {
+		if err := s.addMessage(m.FullName, n); err != nil {
+			return err
+		}
+	}
+	for _, e := range enums {
+		if err := s.addEnum(m.FullName, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseField(b []byte) (*Field, error) {
+	f := &Field{}
+	err := walk(b, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch num {
+		case 1:
+			f.Name = string(v)
+		case 3:
+			f.Number = int32(varintValue(v))
+		case 4:
+			f.Repeated = varintValue(v) == labelRepeated
+		case 5:
+			f.Type = FieldType(varintValue(v))
+		case 6:
+			f.TypeName = strings.TrimPrefix(string(v), ".")
+		case 10:
+			f.JSONName = string(v)
+		}
+		return nil
+	})
+	if f.JSONName == "" {
+		f.JSONName = jsonName(f.Name)
+	}
+	return f, err
+}
+
+// the json name generated by protoc, such as business_id to businessId
+func jsonName(name string) string {
+	var b strings.Builder
+	upper := false
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c == '_' {
+			upper = true
+			continue
+		}
+		if upper && 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		upper = false
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func (s *Schema) addEnum(scope string, b []byte) error {
+	e := &Enum{Values: make(map[int32]string)}
+	var name string
+	err := walk(b, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch num {
+		case 1:
+			name = string(v)
+		case 2:
+			var (
+				valueName string
+				number    int32
+			)
+			err := walk(v, func(num protowire.Number, typ protowire.Type, v []byte) error {
+				switch num {
+				case 1:
+					valueName = string(v)
+				case 2:
+					number = int32(varintValue(v))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			// the first value win for alias
+			if _, ok := e.Values[number]; !ok {
+				e.Values[number] = valueName
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	e.FullName = joinName(scope, name)
+	s.enums[e.FullName] = e
+	return nil
+}
+
+func (s *Schema) resolve() error {
+	for _, m := range s.messages {
+		for _, f := range m.Fields {
+			switch f.Type {
+			case TypeMessage, TypeGroup:
+				if f.Message = s.messages[f.TypeName]; f.Message == nil {
+					return fmt.Errorf("dynpb: message %s of field %s.%s not found", f.TypeName, m.FullName, f.Name)
+				}
+			case TypeEnum:
+				if f.Enum = s.enums[f.TypeName]; f.Enum == nil {
+					return fmt.Errorf("dynpb: enum %s of field %s.%s not found", f.TypeName, m.FullName, f.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// walk the fields of a message, the value of the varint field is passed as the raw varint bytes
+func walk(b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		var v []byte
+		switch typ {
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n >= 0 {
+				v = b[:n]
+			}
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		if err := fn(num, typ, v); err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+func varintValue(v []byte) uint64 {
+	x, n := protowire.ConsumeVarint(v)
+	if n < 0 {
+		return 0
+	}
+	return x
+}
@@ -0,0 +1,250 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dynpb
+
+import (
+	"encoding/base64"
+	"errors"
+	"math"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func field(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type, repeated bool, typeName string) *descriptorpb.FieldDescriptorProto {
+	label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	if repeated {
+		label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	}
+	f := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(num), Type: typ.Enum(), Label: label.Enum()}
+	if typeName != "" {
+		f.TypeName = proto.String(typeName)
+	}
+	return f
+}
+
+/*
+the descriptor set of:
+
+	package demo;
+	enum Status { UNKNOWN = 0; OK = 1; }
+	message Item { string name = 1; int32 count = 2; }
+	message Order {
+	  int64 order_id = 1;
+	  repeated Item items = 2;
+	  map<string, string> labels = 3;
+	  Status status = 4;
+	  repeated sint32 deltas = 5;
+	  bytes blob = 6;
+	  double price = 7;
+	  Order parent = 8;
+	  fixed32 flags = 9;
+	}
+*/
+func testDescriptorSet(t *testing.T) []byte {
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("demo.proto"),
+		Package: proto.String("demo"),
+		Syntax:  proto.String("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Status"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("UNKNOWN"), Number: proto.Int32(0)},
+				{Name: proto.String("OK"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Item"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, false, ""),
+					field("count", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, false, ""),
+				},
+			},
+			{
+				Name: proto.String("Order"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("order_id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64, false, ""),
+					field("items", 2, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, true, ".demo.Item"),
+					field("labels", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, true, ".demo.Order.LabelsEntry"),
+					field("status", 4, descriptorpb.FieldDescriptorProto_TYPE_ENUM, false, ".demo.Status"),
+					field("deltas", 5, descriptorpb.FieldDescriptorProto_TYPE_SINT32, true, ""),
+					field("blob", 6, descriptorpb.FieldDescriptorProto_TYPE_BYTES, false, ""),
+					field("price", 7, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, false, ""),
+					field("parent", 8, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, false, ".demo.Order"),
+					field("flags", 9, descriptorpb.FieldDescriptorProto_TYPE_FIXED32, false, ""),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{{
+					Name: proto.String("LabelsEntry"),
+					Field: []*descriptorpb.FieldDescriptorProto{
+						field("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, false, ""),
+						field("value", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, false, ""),
+					},
+					Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+				}},
+			},
+		},
+	}
+	set, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return set
+}
+
+func appendBytesField(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendVarintField(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func item(name string, count int64) []byte {
+	b := appendBytesField(nil, 1, []byte(name))
+	return appendVarintField(b, 2, uint64(count))
+}
+
+func testOrder() []byte {
+	var b []byte
+	b = appendVarintField(b, 1, 42)
+	b = appendBytesField(b, 2, item("apple", 3))
+	b = appendBytesField(b, 2, item("pear", -1))
+	b = appendBytesField(b, 3, appendBytesField(appendBytesField(nil, 1, []byte("env")), 2, []byte("prod")))
+	b = appendVarintField(b, 4, 1)
+	var packed []byte
+	packed = protowire.AppendVarint(packed, protowire.EncodeZigZag(-2))
+	packed = protowire.AppendVarint(packed, protowire.EncodeZigZag(5))
+	b = appendBytesField(b, 5, packed)
+	// the unpacked element is appended to the packed ones
+	b = appendVarintField(b, 5, protowire.EncodeZigZag(7))
+	b = appendBytesField(b, 6, []byte{0xff, 0x00})
+	b = protowire.AppendTag(b, 7, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, math.Float64bits(1.5))
+	b = appendBytesField(b, 8, appendVarintField(nil, 1, 41))
+	b = protowire.AppendTag(b, 9, protowire.Fixed32Type)
+	b = protowire.AppendFixed32(b, 7)
+	// the unknown field is dropped
+	b = appendBytesField(b, 100, []byte("unknown"))
+	return b
+}
+
+func TestDecodeJSON(t *testing.T) {
+	s, err := Load(testDescriptorSet(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := s.DecodeJSON("demo.Order", testOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"orderId":"42","items":[{"name":"apple","count":3},{"name":"pear","count":-1}],"labels":{"env":"prod"},` +
+		`"status":"OK","deltas":[-2,5,7],"blob":"/wA=","price":1.5,"parent":{"orderId":"41"},"flags":7}`
+	if string(data) != want {
+		t.Errorf("got %s\nwant %s", data, want)
+	}
+}
+
+func TestDecodePaths(t *testing.T) {
+	s, err := Load(testDescriptorSet(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, err := s.DecodePaths(".demo.Order", testOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"order_id":        "42",
+		"items[0].name":   "apple",
+		"items[0].count":  "3",
+		"items[1].name":   "pear",
+		"items[1].count":  "-1",
+		"labels[env]":     "prod",
+		"status":          "OK",
+		"deltas[0]":       "-2",
+		"deltas[1]":       "5",
+		"deltas[2]":       "7",
+		"blob":            "/wA=",
+		"price":           "1.5",
+		"parent.order_id": "41",
+		"flags":           "7",
+	}
+	if len(paths) != len(want) {
+		t.Errorf("got %d paths %v, want %d", len(paths), paths, len(want))
+	}
+	for k, v := range want {
+		if paths[k] != v {
+			t.Errorf("path %s got %q, want %q", k, paths[k], v)
+		}
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	s, err := Load(testDescriptorSet(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := testOrder()
+	for i := 1; i < len(order); i++ {
+		// the truncation at the field boundary is a valid message
+		if _, err := s.DecodeJSON("demo.Order", order[:i]); err != nil && !errors.Is(err, ErrTruncated) && !errors.Is(err, ErrWireType) {
+			t.Errorf("truncated at %d: unexpected error %v", i, err)
+		}
+	}
+
+	deep := appendVarintField(nil, 1, 1)
+	for i := 0; i < DEFAULT_MAX_DEPTH; i++ {
+		deep = appendBytesField(nil, 8, deep)
+	}
+	cases := []struct {
+		name string
+		msg  string
+		data []byte
+		err  error
+	}{
+		{"unknown message", "demo.Nope", nil, ErrMessageNotFound},
+		{"truncated tag", "demo.Order", []byte{0x80}, ErrTruncated},
+		{"truncated length", "demo.Order", []byte{0x12, 0x05, 'a'}, ErrTruncated},
+		{"wire type mismatch", "demo.Order", appendBytesField(nil, 1, []byte("x")), ErrWireType},
+		{"fixed for varint", "demo.Order", protowire.AppendFixed32(protowire.AppendTag(nil, 4, protowire.Fixed32Type), 1), ErrWireType},
+		{"too deep", "demo.Order", deep, ErrTooDeep},
+	}
+	for _, c := range cases {
+		if _, err := s.DecodeJSON(c.msg, c.data); !errors.Is(err, c.err) {
+			t.Errorf("%s: got error %v, want %v", c.name, err, c.err)
+		}
+	}
+}
+
+func TestLoadBase64(t *testing.T) {
+	set := testDescriptorSet(t)
+	s, err := LoadBase64(" " + base64.StdEncoding.EncodeToString(set) + "\n")
+	if err != nil || s.Message("demo.Order.LabelsEntry") == nil || !s.Message("demo.Order.LabelsEntry").MapEntry {
+		t.Errorf("load base64 fail: %v", err)
+	}
+	if _, err := LoadBase64("not base64"); err == nil {
+		t.Error("invalid base64: expect fail")
+	}
+	if _, err := Load([]byte{0x0a, 0x05, 0x01}); err == nil {
+		t.Error("truncated descriptor set: expect fail")
+	}
+}
@@ -0,0 +1,165 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dynpb
+
+import (
+	"encoding/base64"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+/*
+DecodeJSON decode the message by full name into json in the form of protojson: the keys are the json names, the 64 bit
+integers are quoted, the enums are names and the bytes are base64. the absent fields are omitted and the unknown fields
+are dropped.
+*/
+func (s *Schema) DecodeJSON(name string, data []byte) ([]byte, error) {
+	obj, err := s.decode(name, data)
+	if err != nil {
+		return nil, err
+	}
+	return obj.appendJSON(nil), nil
+}
+
+func (o *object) appendJSON(b []byte) []byte {
+	b = append(b, '{')
+	first := true
+	for _, f := range o.msg.Fields {
+		values, ok := o.values[f]
+		if !ok {
+			continue
+		}
+		if !first {
+			b = append(b, ',')
+		}
+		first = false
+		b = appendString(b, f.JSONName)
+		b = append(b, ':')
+		switch {
+		case f.Message != nil && f.Message.MapEntry:
+			b = append(b, '{')
+			for i, v := range values {
+				if i > 0 {
+					b = append(b, ',')
+				}
+				key, value := v.(*object).mapEntry()
+				b = appendString(b, key)
+				b = append(b, ':')
+				b = appendValue(b, f.Message.FieldByNumber(2), value)
+			}
+			b = append(b, '}')
+		case f.Repeated:
+			b = append(b, '[')
+			for i, v := range values {
+				if i > 0 {
+					b = append(b, ',')
+				}
+				b = appendValue(b, f, v)
+			}
+			b = append(b, ']')
+		default:
+			b = appendValue(b, f, values[0])
+		}
+	}
+	return append(b, '}')
+}
+
+func appendValue(b []byte, f *Field, v interface{}) []byte {
+	switch v := v.(type) {
+	case *object:
+		return v.appendJSON(b)
+	case int64:
+		switch f.Type {
+		case TypeEnum:
+			if name, ok := f.Enum.Values[int32(v)]; ok {
+				return appendString(b, name)
+			}
+			return strconv.AppendInt(b, v, 10)
+		case TypeInt64, TypeSint64, TypeSfixed64:
+			b = append(b, '"')
+			b = strconv.AppendInt(b, v, 10)
+			return append(b, '"')
+		}
+		return strconv.AppendInt(b, v, 10)
+	case uint64:
+		if f.Type == TypeUint64 || f.Type == TypeFixed64 {
+			b = append(b, '"')
+			b = strconv.AppendUint(b, v, 10)
+			return append(b, '"')
+		}
+		return strconv.AppendUint(b, v, 10)
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return append(b, `"NaN"`...)
+		case math.IsInf(v, 1):
+			return append(b, `"Infinity"`...)
+		case math.IsInf(v, -1):
+			return append(b, `"-Infinity"`...)
+		}
+		if f.Type == TypeFloat {
+			return strconv.AppendFloat(b, v, 'g', -1, 32)
+		}
+		return strconv.AppendFloat(b, v, 'g', -1, 64)
+	case bool:
+		return strconv.AppendBool(b, v)
+	case string:
+		return appendString(b, v)
+	case []byte:
+		b = append(b, '"')
+		b = append(b, base64.StdEncoding.EncodeToString(v)...)
+		return append(b, '"')
+	}
+	return append(b, "null"...)
+}
+
+const hex = "0123456789abcdef"
+
+// append the json string, the invalid utf8 is replaced by U+FFFD
+func appendString(b []byte, s string) []byte {
+	b = append(b, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				b = append(b, '\\', c)
+			case c == '\n':
+				b = append(b, '\\', 'n')
+			case c == '\r':
+				b = append(b, '\\', 'r')
+			case c == '\t':
+				b = append(b, '\\', 't')
+			case c < 0x20:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			default:
+				b = append(b, c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, `�`...)
+		} else {
+			b = append(b, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(b, '"')
+}
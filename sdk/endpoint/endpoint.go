@@ -0,0 +1,270 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Package endpoint normalize the raw path into a low cardinality endpoint, such as:
+
+	/user/12345/orders/678                          -> /user/{id}/orders/{id}
+	/file/3f2504e0-4f89-11d3-9a0c-0305e82c3301      -> /file/{uuid}
+	/blob/9e107d9d372bb6826bd81d3542a419d6?size=10  -> /blob/{hash}
+
+the routes registered by AddRoute are matched first, the route with more literal segments is preferred:
+
+	n := endpoint.New()
+	n.AddRoute("/user/{id}/orders/{oid}")
+	n.Normalize("/user/12345/orders/678") // /user/{id}/orders/{oid}
+
+the Normalizer implement OnHttpReq and OnHttpResp, so it can be the handler to rewrite the endpoint of both
+directions, or Fill the info in the other handlers.
+*/
+package endpoint
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+)
+
+// Pattern replace the matched segment by Placeholder
+type Pattern struct {
+	Placeholder string
+	Match       func(segment string) bool
+}
+
+// the built-in patterns in order
+var (
+	NumberPattern = Pattern{Placeholder: "{id}", Match: isNumber}
+	UUIDPattern   = Pattern{Placeholder: "{uuid}", Match: isUUID}
+	// the hex string of 16 chars at least with a digit, such as md5, sha1 and sha256
+	HashPattern = Pattern{Placeholder: "{hash}", Match: isHash}
+)
+
+// RegexpPattern match the whole segment by expr, the expr is compiled once
+func RegexpPattern(placeholder, expr string) (Pattern, error) {
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return Pattern{}, err
+	}
+	return Pattern{Placeholder: placeholder, Match: re.MatchString}, nil
+}
+
+type route struct {
+	template string
+	segments []string
+	// the last segment is *, which match the rest segments
+	wildcard bool
+	literals int
+}
+
+type Normalizer struct {
+	routes   []*route
+	patterns []Pattern
+}
+
+// New return the Normalizer with the built-in patterns
+func New() *Normalizer {
+	return &Normalizer{patterns: []Pattern{NumberPattern, UUIDPattern, HashPattern}}
+}
+
+var Default = New()
+
+// Normalize by Default
+func Normalize(path string) string {
+	return Default.Normalize(path)
+}
+
+// AddPattern append the pattern after the existing ones
+func (n *Normalizer) AddPattern(p Pattern) {
+	n.patterns = append(n.patterns, p)
+}
+
+/*
+AddRoute add the route template, the segment in braces such as {id} match any segment and the last segment * match
+the rest segments:
+
+	/user/{id}/orders/{oid}
+	/static/*
+*/
+func (n *Normalizer) AddRoute(template string) {
+	r := &route{template: template, segments: split(template)}
+	if len(r.segments) > 0 && r.segments[len(r.segments)-1] == "*" {
+		r.segments = r.segments[:len(r.segments)-1]
+		r.wildcard = true
+	}
+	for _, s := range r.segments {
+		if !isParam(s) {
+			r.literals++
+		}
+	}
+	n.routes = append(n.routes, r)
+}
+
+// Normalize the path without query and the scheme, host of the absolute form
+func (n *Normalizer) Normalize(path string) string {
+	path = stripPath(path)
+	segments := split(path)
+	if r := n.route(segments); r != nil {
+		return r.template
+	}
+
+	var b strings.Builder
+	b.Grow(len(path))
+	for _, seg := range segments {
+		b.WriteByte('/')
+		b.WriteString(n.replace(seg))
+	}
+	if b.Len() == 0 || (len(segments) > 0 && strings.HasSuffix(path, "/")) {
+		b.WriteByte('/')
+	}
+	return b.String()
+}
+
+func (n *Normalizer) replace(segment string) string {
+	for i := range n.patterns {
+		if n.patterns[i].Match(segment) {
+			return n.patterns[i].Placeholder
+		}
+	}
+	return segment
+}
+
+func (n *Normalizer) route(segments []string) *route {
+	var best *route
+	for _, r := range n.routes {
+		if r.match(segments) && (best == nil || r.literals > best.literals) {
+			best = r
+		}
+	}
+	return best
+}
+
+func (r *route) match(segments []string) bool {
+	if len(segments) < len(r.segments) || (!r.wildcard && len(segments) != len(r.segments)) {
+		return false
+	}
+	for i, s := range r.segments {
+		if !isParam(s) && s != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Fill normalize the endpoint of the request and response in info
+func (n *Normalizer) Fill(info *sdk.L7ProtocolInfo) {
+	if info.Req != nil && info.Req.Endpoint != "" {
+		info.Req.Endpoint = n.Normalize(info.Req.Endpoint)
+	}
+	if info.Resp != nil && info.Resp.Endpoint != "" {
+		info.Resp.Endpoint = n.Normalize(info.Resp.Endpoint)
+	}
+}
+
+// OnHttpReq leave the request to the agent if the path is already normalized
+func (n *Normalizer) OnHttpReq(ctx *sdk.HttpReqCtx) sdk.Action {
+	endpoint := n.Normalize(ctx.Path)
+	if endpoint == ctx.Path {
+		return sdk.ActionNext()
+	}
+	return sdk.HttpReqActionAbortWithResult(&sdk.Request{Endpoint: endpoint}, nil, nil)
+}
+
+// OnHttpResp leave the response without endpoint to the agent
+func (n *Normalizer) OnHttpResp(ctx *sdk.HttpRespCtx) sdk.Action {
+	if ctx.Endpoint == "" {
+		return sdk.ActionNext()
+	}
+	return sdk.HttpRespActionAbortWithResult(&sdk.Response{Endpoint: n.Normalize(ctx.Endpoint)}, nil, nil)
+}
+
+// remove the query, fragment and the scheme, host of http://host/path
+func stripPath(path string) string {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	if i := strings.Index(path, "://"); i >= 0 && !strings.Contains(path[:i], "/") {
+		path = path[i+3:]
+		if j := strings.IndexByte(path, '/'); j >= 0 {
+			path = path[j:]
+		} else {
+			path = "/"
+		}
+	}
+	return path
+}
+
+// split the path into the non-empty segments
+func split(path string) []string {
+	var segments []string
+	for _, s := range strings.Split(path, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+func isParam(s string) bool {
+	return len(s) > 2 && s[0] == '{' && s[len(s)-1] == '}'
+}
+
+func isNumber(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+func isHexDigit(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// 8-4-4-4-12
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if !isHexDigit(s[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isHash(s string) bool {
+	if len(s) < 16 {
+		return false
+	}
+	digit := false
+	for i := 0; i < len(s); i++ {
+		if !isHexDigit(s[i]) {
+			return false
+		}
+		digit = digit || (s[i] >= '0' && s[i] <= '9')
+	}
+	return digit
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package endpoint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"/user/12345/orders/678", "/user/{id}/orders/{id}"},
+		{"/file/3f2504e0-4f89-11d3-9a0c-0305e82c3301", "/file/{uuid}"},
+		{"/file/3f2504e0-4f89-11d3-9a0c-0305e82c330", "/file/3f2504e0-4f89-11d3-9a0c-0305e82c330"},
+		{"/file/3f2504e0x4f89-11d3-9a0c-0305e82c3301", "/file/3f2504e0x4f89-11d3-9a0c-0305e82c3301"},
+		{"/blob/9e107d9d372bb6826bd81d3542a419d6?size=10", "/blob/{hash}"},
+		{"/blob/DA39A3EE5E6B4B0D3255BFEF95601890AFD80709", "/blob/{hash}"},
+		{"/word/deadbeefdeadbeefcafe", "/word/deadbeefdeadbeefcafe"},
+		{"/short/9e107d9d", "/short/9e107d9d"},
+		{"/v1/users/", "/v1/users/"},
+		{"/a//12#frag", "/a/{id}"},
+		{"", "/"},
+		{"/", "/"},
+		{"?x=1", "/"},
+		{"http://example.com/api/1?x", "/api/{id}"},
+		{"https://example.com", "/"},
+		{"/redirect/http://example.com", "/redirect/http:/example.com"},
+	}
+	for _, c := range cases {
+		if got := Normalize(c.path); got != c.want {
+			t.Errorf("%q: got %q, want %q", c.path, got, c.want)
+		}
+	}
+}
+
+func TestRoute(t *testing.T) {
+	n := New()
+	n.AddRoute("/user/{id}/orders/{oid}")
+	n.AddRoute("/user/{id}/orders/latest")
+	n.AddRoute("/user/{id}/{action}/{x}")
+	n.AddRoute("/static/*")
+	n.AddRoute("/{any}/*")
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"/user/1/orders/2", "/user/{id}/orders/{oid}"},
+		{"/user/1/orders/latest", "/user/{id}/orders/latest"},
+		{"/user/1/cart/2", "/user/{id}/{action}/{x}"},
+		{"/user/1/orders", "/{any}/*"},
+		{"/static/js/app.js", "/static/*"},
+		{"/static", "/static/*"},
+		{"/", "/"},
+		{"/x", "/{any}/*"},
+	}
+	for _, c := range cases {
+		if got := n.Normalize(c.path); got != c.want {
+			t.Errorf("%q: got %q, want %q", c.path, got, c.want)
+		}
+	}
+
+	// the first route is preferred if the literals are equal
+	n = New()
+	n.AddRoute("/{a}/b")
+	n.AddRoute("/a/{b}")
+	if got := n.Normalize("/a/b"); got != "/{a}/b" {
+		t.Errorf("got %q, want /{a}/b", got)
+	}
+}
+
+func TestPattern(t *testing.T) {
+	n := New()
+	p, err := RegexpPattern("{sku}", "SKU-[0-9]+")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.AddPattern(p)
+	if got := n.Normalize("/item/SKU-42/SKU-42x/7"); got != "/item/{sku}/SKU-42x/{id}" {
+		t.Errorf("got %q", got)
+	}
+	if _, err := RegexpPattern("{bad}", "("); err == nil {
+		t.Error("bad regexp: no error")
+	}
+}
+
+func TestFill(t *testing.T) {
+	info := &sdk.L7ProtocolInfo{
+		Req:  &sdk.Request{Endpoint: "/user/1?x=2"},
+		Resp: &sdk.Response{},
+	}
+	New().Fill(info)
+	if info.Req.Endpoint != "/user/{id}" || info.Resp.Endpoint != "" {
+		t.Errorf("got %q and %q", info.Req.Endpoint, info.Resp.Endpoint)
+	}
+	// nothing to fill
+	New().Fill(&sdk.L7ProtocolInfo{})
+}
+
+func TestHooks(t *testing.T) {
+	n := New()
+	reqs := []struct {
+		path string
+		want sdk.Action
+	}{
+		{"/user/list", sdk.ActionNext()},
+		{"/user/1", sdk.HttpReqActionAbortWithResult(&sdk.Request{Endpoint: "/user/{id}"}, nil, nil)},
+		{"/user?x=1", sdk.HttpReqActionAbortWithResult(&sdk.Request{Endpoint: "/user"}, nil, nil)},
+	}
+	for _, c := range reqs {
+		if got := n.OnHttpReq(&sdk.HttpReqCtx{Path: c.path}); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("request %s: got %+v, want %+v", c.path, got, c.want)
+		}
+	}
+
+	resps := []struct {
+		endpoint string
+		want     sdk.Action
+	}{
+		{"", sdk.ActionNext()},
+		{"/user/1", sdk.HttpRespActionAbortWithResult(&sdk.Response{Endpoint: "/user/{id}"}, nil, nil)},
+	}
+	for _, c := range resps {
+		if got := n.OnHttpResp(&sdk.HttpRespCtx{Endpoint: c.endpoint}); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("response %q: got %+v, want %+v", c.endpoint, got, c.want)
+		}
+	}
+}
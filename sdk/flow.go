@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+import "net/netip"
+
+// FlowKey is comparable and can be used as map key, the zero value of the address indicate unknown.
+type FlowKey struct {
+	Src netip.AddrPort
+	Dst netip.AddrPort
+	L4  L4Protocol
+}
+
+func (p *ParseCtx) Src() netip.AddrPort {
+	addr := p.srcAddr
+	if !addr.IsValid() {
+		// the ctx is not created by the sdk
+		addr = toAddr(p.SrcIP.IP)
+	}
+	return netip.AddrPortFrom(addr, p.SrcPort)
+}
+
+func (p *ParseCtx) Dst() netip.AddrPort {
+	addr := p.dstAddr
+	if !addr.IsValid() {
+		addr = toAddr(p.DstIP.IP)
+	}
+	return netip.AddrPortFrom(addr, p.DstPort)
+}
+
+func toAddr(ip []byte) netip.Addr {
+	addr, _ := netip.AddrFromSlice(ip)
+	return addr.Unmap()
+}
+
+// the key of the packet, the request and response of the same flow have the reversed key
+func (p *ParseCtx) FlowKey() FlowKey {
+	return FlowKey{
+		Src: p.Src(),
+		Dst: p.Dst(),
+		L4:  p.L4,
+	}
+}
+
+// the direction independent key, the request and response of the same flow have the same key
+func (p *ParseCtx) CanonicalFlowKey() FlowKey {
+	return p.FlowKey().Canonical()
+}
+
+func (k FlowKey) Reverse() FlowKey {
+	return FlowKey{
+		Src: k.Dst,
+		Dst: k.Src,
+		L4:  k.L4,
+	}
+}
+
+// order the endpoints so that the smaller one is Src
+func (k FlowKey) Canonical() FlowKey {
+	if c := k.Src.Addr().Compare(k.Dst.Addr()); c > 0 || (c == 0 && k.Src.Port() > k.Dst.Port()) {
+		return k.Reverse()
+	}
+	return k
+}
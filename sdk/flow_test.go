@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestFlowAddr(t *testing.T) {
+	cases := []struct {
+		name   string
+		layout parseCtxLayout
+		src    string
+		dst    string
+	}{
+		{"v4", parseCtxLayout{l4: uint8(TCP)}, "10.0.0.1:12345", "10.0.0.2:80"},
+		{"v6", parseCtxLayout{ipv6: true, l4: uint8(TCP)}, "[fe80::1]:12345", "[fe80::2]:80"},
+		{"v4-mapped", parseCtxLayout{ipv6: true, l4: uint8(TCP),
+			src: net.ParseIP("::ffff:10.0.0.1"), dst: net.ParseIP("::ffff:10.0.0.2")}, "10.0.0.1:12345", "10.0.0.2:80"},
+	}
+	for _, c := range cases {
+		ctx := deserializeParseCtx(c.layout.serialize())
+		if ctx == nil {
+			t.Fatalf("%s: deserialize fail", c.name)
+		}
+		if ctx.Src().String() != c.src || ctx.Dst().String() != c.dst {
+			t.Errorf("%s: got %s -> %s, want %s -> %s", c.name, ctx.Src(), ctx.Dst(), c.src, c.dst)
+		}
+		// the ctx not created by the sdk has the same key
+		manual := &ParseCtx{SrcIP: ctx.SrcIP, DstIP: ctx.DstIP, SrcPort: ctx.SrcPort, DstPort: ctx.DstPort, L4: ctx.L4}
+		if manual.FlowKey() != ctx.FlowKey() {
+			t.Errorf("%s: got key %+v, want %+v", c.name, manual.FlowKey(), ctx.FlowKey())
+		}
+	}
+
+	if src := (&ParseCtx{SrcPort: 80}).Src(); src.Addr().IsValid() || src.Port() != 80 {
+		t.Errorf("the unknown address got %s", src)
+	}
+}
+
+func TestCanonicalFlowKey(t *testing.T) {
+	key := func(src, dst string) FlowKey {
+		return FlowKey{Src: netip.MustParseAddrPort(src), Dst: netip.MustParseAddrPort(dst), L4: TCP}
+	}
+	cases := []struct {
+		name string
+		key  FlowKey
+		want FlowKey
+	}{
+		{"ordered", key("10.0.0.1:1000", "10.0.0.2:80"), key("10.0.0.1:1000", "10.0.0.2:80")},
+		{"reversed", key("10.0.0.2:80", "10.0.0.1:1000"), key("10.0.0.1:1000", "10.0.0.2:80")},
+		{"same address by port", key("10.0.0.1:8080", "10.0.0.1:80"), key("10.0.0.1:80", "10.0.0.1:8080")},
+		{"v4 before v6", key("[::1]:80", "127.0.0.1:1000"), key("127.0.0.1:1000", "[::1]:80")},
+		{"v6", key("[fe80::2]:80", "[fe80::1]:1000"), key("[fe80::1]:1000", "[fe80::2]:80")},
+	}
+	for _, c := range cases {
+		if got := c.key.Canonical(); got != c.want {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, c.want)
+		}
+		if got := c.key.Reverse().Canonical(); got != c.want {
+			t.Errorf("%s: the reversed got %+v, want %+v", c.name, got, c.want)
+		}
+	}
+
+	// the request and response of the same flow
+	req := &ParseCtx{SrcIP: net.IPAddr{IP: net.ParseIP("10.0.0.2")}, DstIP: net.IPAddr{IP: net.ParseIP("10.0.0.1")},
+		SrcPort: 1000, DstPort: 80, L4: TCP}
+	resp := &ParseCtx{SrcIP: req.DstIP, DstIP: req.SrcIP, SrcPort: 80, DstPort: 1000, L4: TCP}
+	if req.FlowKey() != resp.FlowKey().Reverse() || req.CanonicalFlowKey() != resp.CanonicalFlowKey() {
+		t.Errorf("got keys %+v and %+v", req.CanonicalFlowKey(), resp.CanonicalFlowKey())
+	}
+	udp := *resp
+	udp.L4 = UDP
+	if udp.CanonicalFlowKey() == resp.CanonicalFlowKey() {
+		t.Error("the l4 protocol is not in the key")
+	}
+}
@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Package framing split a payload into complete frames, it works with both a single payload and the data reassembled
+by sdk.StreamBuffer:
+
+	data, err := streams.Append(ctx)
+	if err != nil {
+		return sdk.ActionAbortWithErr(err)
+	}
+	frames, leftover, err := framing.Split(framer, data)
+	if err != nil {
+		return sdk.ActionAbortWithErr(err)
+	}
+	streams.Consume(ctx, len(data)-len(leftover))
+
+the frames alias the input data, copy them if they need to outlive the data.
+*/
+package framing
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrBadMagic      = errors.New("frame magic mismatch")
+	ErrFrameTooLarge = errors.New("frame too large")
+	ErrBadLength     = errors.New("frame length invalid")
+)
+
+type Framer interface {
+	// Next return the first complete frame of data and the size consumed, n is 0 when the data is not enough for a frame
+	Next(data []byte) (frame []byte, n int, err error)
+}
+
+// Split data into complete frames, the leftover is the incomplete frame at the end of data
+func Split(f Framer, data []byte) (frames [][]byte, leftover []byte, err error) {
+	for len(data) > 0 {
+		frame, n, err := f.Next(data)
+		if err != nil {
+			return frames, data, err
+		}
+		if n == 0 {
+			break
+		}
+		frames = append(frames, frame)
+		data = data[n:]
+	}
+	return frames, data, nil
+}
+
+/*
+LengthFieldFramer split the frames with a fixed size header which contain the length field, such as:
+
+	krpc:  'KR' magic (2 bytes) + head len (2 bytes) + packet len (4 bytes, be)
+	dubbo: 0xdabb magic (2 bytes) + flag, status, request id (10 bytes) + body len (4 bytes, be)
+
+the frame size is HeaderSize + length + LengthAdjust, or length + LengthAdjust when LengthIncludesHeader.
+*/
+type LengthFieldFramer struct {
+	// optional, expected at the start of the frame
+	Magic        []byte
+	HeaderSize   int
+	LengthOffset int
+	// 1, 2, 4 or 8
+	LengthSize int
+	// nil indicate big endian
+	ByteOrder            binary.ByteOrder
+	LengthAdjust         int
+	LengthIncludesHeader bool
+	// 0 indicate unlimited
+	MaxFrameSize int
+}
+
+func (f *LengthFieldFramer) byteOrder() binary.ByteOrder {
+	if f.ByteOrder == nil {
+		return binary.BigEndian
+	}
+	return f.ByteOrder
+}
+
+func (f *LengthFieldFramer) Next(data []byte) ([]byte, int, error) {
+	if len(f.Magic) > 0 {
+		n := len(f.Magic)
+		if n > len(data) {
+			n = len(data)
+		}
+		if !bytes.Equal(data[:n], f.Magic[:n]) {
+			return nil, 0, ErrBadMagic
+		}
+	}
+	if len(data) < f.HeaderSize || len(data) < f.LengthOffset+f.LengthSize {
+		return nil, 0, nil
+	}
+
+	var length uint64
+	field := data[f.LengthOffset : f.LengthOffset+f.LengthSize]
+	switch f.LengthSize {
+	case 1:
+		length = uint64(field[0])
+	case 2:
+		length = uint64(f.byteOrder().Uint16(field))
+	case 4:
+		length = uint64(f.byteOrder().Uint32(field))
+	case 8:
+		length = f.byteOrder().Uint64(field)
+	default:
+		return nil, 0, fmt.Errorf("unsupported length size %d", f.LengthSize)
+	}
+	// avoid overflow of the int on 32 bit platform such as wasm
+	if length > 1<<31-1 {
+		return nil, 0, ErrFrameTooLarge
+	}
+
+	size := int(length) + f.LengthAdjust
+	if !f.LengthIncludesHeader {
+		size += f.HeaderSize
+	}
+	if size < f.HeaderSize || size <= 0 {
+		return nil, 0, ErrBadLength
+	}
+	if f.MaxFrameSize > 0 && size > f.MaxFrameSize {
+		return nil, 0, ErrFrameTooLarge
+	}
+	if size > len(data) {
+		return nil, 0, nil
+	}
+	return data[:size], size, nil
+}
+
+// VarintFramer split the frames prefixed with an unsigned varint length, the frame excludes the prefix
+type VarintFramer struct {
+	// 0 indicate unlimited
+	MaxFrameSize int
+}
+
+func (f *VarintFramer) Next(data []byte) ([]byte, int, error) {
+	length, n := binary.Uvarint(data)
+	if n == 0 {
+		// not enough data for the varint
+		return nil, 0, nil
+	}
+	if n < 0 || length > 1<<31-1 {
+		return nil, 0, ErrBadLength
+	}
+	if f.MaxFrameSize > 0 && int(length) > f.MaxFrameSize {
+		return nil, 0, ErrFrameTooLarge
+	}
+	size := n + int(length)
+	if size > len(data) {
+		return nil, 0, nil
+	}
+	return data[n:size], size, nil
+}
+
+// DelimiterFramer split the frames terminated by the delimiter, such as CRLF of text protocols
+type DelimiterFramer struct {
+	Delimiter []byte
+	// whether the returned frame contain the delimiter
+	IncludeDelimiter bool
+	// 0 indicate unlimited, the frame without delimiter larger than it is an error
+	MaxFrameSize int
+}
+
+func NewLineFramer(maxFrameSize int) *DelimiterFramer {
+	return &DelimiterFramer{
+		Delimiter:    []byte("\r\n"),
+		MaxFrameSize: maxFrameSize,
+	}
+}
+
+func (f *DelimiterFramer) Next(data []byte) ([]byte, int, error) {
+	if len(f.Delimiter) == 0 {
+		return nil, 0, errors.New("empty delimiter")
+	}
+	i := bytes.Index(data, f.Delimiter)
+	if i < 0 {
+		if f.MaxFrameSize > 0 && len(data) > f.MaxFrameSize {
+			return nil, 0, ErrFrameTooLarge
+		}
+		return nil, 0, nil
+	}
+	if f.MaxFrameSize > 0 && i > f.MaxFrameSize {
+		return nil, 0, ErrFrameTooLarge
+	}
+	n := i + len(f.Delimiter)
+	if f.IncludeDelimiter {
+		return data[:n], n, nil
+	}
+	return data[:i], n, nil
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package framing
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	// 'KR' + head len + packet len, the packet len include the header
+	krpc := &LengthFieldFramer{Magic: []byte("KR"), HeaderSize: 8, LengthOffset: 4, LengthSize: 4, LengthIncludesHeader: true, MaxFrameSize: 64}
+	// magic + 10 bytes + body len
+	dubbo := &LengthFieldFramer{Magic: []byte{0xda, 0xbb}, HeaderSize: 16, LengthOffset: 12, LengthSize: 4}
+	little := &LengthFieldFramer{HeaderSize: 2, LengthSize: 2, ByteOrder: binary.LittleEndian}
+	adjust := &LengthFieldFramer{HeaderSize: 1, LengthSize: 1, LengthAdjust: -1}
+	dubboHeader := func(bodyLen int) string {
+		h := make([]byte, 16)
+		h[0], h[1] = 0xda, 0xbb
+		binary.BigEndian.PutUint32(h[12:], uint32(bodyLen))
+		return string(h)
+	}
+
+	cases := []struct {
+		name     string
+		framer   Framer
+		data     string
+		frames   []string
+		leftover string
+		err      error
+	}{
+		{"krpc frames", krpc, "KR\x00\x00\x00\x00\x00\x0aabKR\x00\x00\x00\x00\x00\x08", []string{"KR\x00\x00\x00\x00\x00\x0aab", "KR\x00\x00\x00\x00\x00\x08"}, "", nil},
+		{"krpc partial header", krpc, "KR\x00\x00\x00", nil, "KR\x00\x00\x00", nil},
+		{"krpc partial magic", krpc, "K", nil, "K", nil},
+		{"krpc partial body", krpc, "KR\x00\x00\x00\x00\x00\x0aa", nil, "KR\x00\x00\x00\x00\x00\x0aa", nil},
+		{"krpc bad magic", krpc, "KX\x00\x00\x00\x00\x00\x08", nil, "KX\x00\x00\x00\x00\x00\x08", ErrBadMagic},
+		{"krpc bad magic after frame", krpc, "KR\x00\x00\x00\x00\x00\x08XX", []string{"KR\x00\x00\x00\x00\x00\x08"}, "XX", ErrBadMagic},
+		{"krpc length less than header", krpc, "KR\x00\x00\x00\x00\x00\x04", nil, "KR\x00\x00\x00\x00\x00\x04", ErrBadLength},
+		{"krpc too large", krpc, "KR\x00\x00\x00\x00\x01\x00", nil, "KR\x00\x00\x00\x00\x01\x00", ErrFrameTooLarge},
+		{"krpc length overflow", krpc, "KR\x00\x00\xff\xff\xff\xff", nil, "KR\x00\x00\xff\xff\xff\xff", ErrFrameTooLarge},
+		{"dubbo", dubbo, dubboHeader(3) + "abc" + dubboHeader(1), []string{dubboHeader(3) + "abc"}, dubboHeader(1), nil},
+		{"little endian", little, "\x03\x00abc\x01\x00", []string{"\x03\x00abc"}, "\x01\x00", nil},
+		{"length adjust", adjust, "\x03ab\x00", []string{"\x03ab"}, "\x00", ErrBadLength},
+		{"varint", &VarintFramer{}, "\x03abc\x00\x02a", []string{"abc", ""}, "\x02a", nil},
+		{"varint long", &VarintFramer{}, "\x80\x01" + strings.Repeat("x", 128), []string{strings.Repeat("x", 128)}, "", nil},
+		{"varint partial prefix", &VarintFramer{}, "\x80", nil, "\x80", nil},
+		{"varint overflow", &VarintFramer{}, "\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01", nil, "\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01", ErrBadLength},
+		{"varint too large", &VarintFramer{MaxFrameSize: 2}, "\x03abc", nil, "\x03abc", ErrFrameTooLarge},
+		{"lines", NewLineFramer(0), "PING\r\n+OK\r\n$3", []string{"PING", "+OK"}, "$3", nil},
+		{"lines include delimiter", &DelimiterFramer{Delimiter: []byte("\n"), IncludeDelimiter: true}, "a\nb\n", []string{"a\n", "b\n"}, "", nil},
+		{"line too large", NewLineFramer(4), "PING PONG", nil, "PING PONG", ErrFrameTooLarge},
+		{"terminated line too large", NewLineFramer(4), "PING PONG\r\n", nil, "PING PONG\r\n", ErrFrameTooLarge},
+		{"empty", NewLineFramer(0), "", nil, "", nil},
+	}
+	for _, c := range cases {
+		frames, leftover, err := Split(c.framer, []byte(c.data))
+		if err != c.err {
+			t.Errorf("%s: got error %v, want %v", c.name, err, c.err)
+		}
+		if len(frames) != len(c.frames) {
+			t.Errorf("%s: got %d frames %q, want %q", c.name, len(frames), frames, c.frames)
+		} else {
+			for i := range frames {
+				if string(frames[i]) != c.frames[i] {
+					t.Errorf("%s: frame %d got %q, want %q", c.name, i, frames[i], c.frames[i])
+				}
+			}
+		}
+		if string(leftover) != c.leftover {
+			t.Errorf("%s: leftover got %q, want %q", c.name, leftover, c.leftover)
+		}
+	}
+}
+
+func TestLengthFieldFramerLengthSize(t *testing.T) {
+	for _, size := range []int{1, 2, 4, 8} {
+		f := &LengthFieldFramer{HeaderSize: size, LengthSize: size}
+		data := make([]byte, size+2)
+		data[size-1] = 2
+		frame, n, err := f.Next(data)
+		if err != nil || n != size+2 || len(frame) != size+2 {
+			t.Errorf("length size %d: got frame %q n %d err %v", size, frame, n, err)
+		}
+	}
+	if _, _, err := (&LengthFieldFramer{HeaderSize: 3, LengthSize: 3}).Next([]byte{0, 0, 1, 0}); err == nil {
+		t.Error("length size 3: expect fail")
+	}
+}
@@ -0,0 +1,248 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+import "github.com/deepflowio/deepflow-wasm-go-sdk/sdk/pb"
+
+// correspond HOOK_POINT_HTTP_REQ
+type HttpReqHandler interface {
+	OnHttpReq(*HttpReqCtx) Action
+}
+
+// correspond HOOK_POINT_HTTP_RESP
+type HttpRespHandler interface {
+	OnHttpResp(*HttpRespCtx) Action
+}
+
+// correspond HOOK_POINT_PAYLOAD_PARSE, the agent always calls both methods so they can not be implemented separately.
+type PayloadParser interface {
+	// protoNum return 0 indicate fail
+	OnCheckPayload(*ParseCtx) (protoNum uint8, protoStr string, direction uint8)
+	OnParsePayload(*ParseCtx) Action
+}
+
+// correspond HOOK_POINT_CUSTOM_MESSAGE
+type CustomMessageHandler interface {
+	OnCustomMessage(*CustomMessageCtx) Action
+}
+
+// correspond HOOK_POINT_CUSTOM_MESSAGE, called with the decoded message when the nats protocol is subscribed.
+type NatsMessageHandler interface {
+	OnNatsMessage(*pb.NatsMessage) Action
+}
+
+// correspond HOOK_POINT_CUSTOM_MESSAGE with hook point SessionFilter, return false to drop the session
+type SessionFilterHandler interface {
+	OnSessionFilter(*CustomMessageCtx) (keep bool)
+}
+
+// correspond HOOK_POINT_CUSTOM_MESSAGE with hook point Sampling
+type SamplingHandler interface {
+	OnSampling(*CustomMessageCtx) SamplingResult
+}
+
+// explicit override of the hook bitmap derived from the implemented handlers.
+type HookDeclarer interface {
+	HookIn() []HookBitmap
+}
+
+type CustomMessageHookDeclarer interface {
+	CustomMessageHookIn() uint64
+}
+
+/*
+SetHandler register a plugin which implements any subset of HttpReqHandler, HttpRespHandler, PayloadParser,
+CustomMessageHandler, NatsMessageHandler, SessionFilterHandler and SamplingHandler, the hook bitmap is derived
+from the implemented handlers so the plugin never gets called on a hook it ignores.
+
+if the handler also implements HookDeclarer, the declared bitmap take precedence and a warning is logged when it
+disagrees with the implemented handlers. the handler must not embed DefaultParser, otherwise all handlers are
+treated as implemented.
+*/
+func SetHandler(h interface{}) {
+	p := &handlerParser{h: h}
+	p.hooks = p.derivedHooks()
+	if d, ok := h.(HookDeclarer); ok {
+		declared := mergeHookBitmap(d.HookIn())
+		checkHookBitmap(declared, p.hooks)
+		p.hooks = declared
+	}
+	if p.hooks == mergeHookBitmap(nil) {
+		Warn("handler %T implements no hook, it will never be called", h)
+	}
+	if p.hooks.contains(HOOK_POINT_CUSTOM_MESSAGE) {
+		_, isDeclarer := h.(CustomMessageHookDeclarer)
+		_, isSubscriber := h.(CustomMessageSubscriber)
+		if !isDeclarer && !isSubscriber {
+			Warn("handler %T hook in custom message but not implement CustomMessageHookIn or CustomMessageSubscriptions, no message will be received", h)
+		}
+	}
+	vmParser = p
+}
+
+func mergeHookBitmap(b []HookBitmap) HookBitmap {
+	hookBit := HookBitmap{0, 0}
+	for _, v := range b {
+		hookBit[0] |= v[0]
+		hookBit[1] |= v[1]
+	}
+	return hookBit
+}
+
+func (b HookBitmap) contains(hook HookBitmap) bool {
+	return b[0]&hook[0] == hook[0] && b[1]&hook[1] == hook[1]
+}
+
+var hookPoints = []struct {
+	hook HookBitmap
+	name string
+}{
+	{HOOK_POINT_HTTP_REQ, "HOOK_POINT_HTTP_REQ"},
+	{HOOK_POINT_HTTP_RESP, "HOOK_POINT_HTTP_RESP"},
+	{HOOK_POINT_CUSTOM_MESSAGE, "HOOK_POINT_CUSTOM_MESSAGE"},
+	{HOOK_POINT_PAYLOAD_PARSE, "HOOK_POINT_PAYLOAD_PARSE"},
+}
+
+func checkHookBitmap(declared, implemented HookBitmap) {
+	for _, p := range hookPoints {
+		switch d, i := declared.contains(p.hook), implemented.contains(p.hook); {
+		case d && !i:
+			Warn("%s is declared in HookIn but the handler is not implemented", p.name)
+		case !d && i:
+			Warn("handler of %s is implemented but not declared in HookIn, it will never be called", p.name)
+		}
+	}
+}
+
+// handlerParser adapt the handler registered by SetHandler to Parser, Parser.OnNatsMessage is never called
+type handlerParser struct {
+	DefaultParser
+	h     interface{}
+	hooks HookBitmap
+}
+
+func (p *handlerParser) derivedHooks() HookBitmap {
+	var hooks []HookBitmap
+	if _, ok := p.h.(HttpReqHandler); ok {
+		hooks = append(hooks, HOOK_POINT_HTTP_REQ)
+	}
+	if _, ok := p.h.(HttpRespHandler); ok {
+		hooks = append(hooks, HOOK_POINT_HTTP_RESP)
+	}
+	if _, ok := p.h.(PayloadParser); ok {
+		hooks = append(hooks, HOOK_POINT_PAYLOAD_PARSE)
+	}
+	_, isCustom := p.h.(CustomMessageHandler)
+	_, isNats := p.h.(NatsMessageHandler)
+	_, isFilter := p.h.(SessionFilterHandler)
+	_, isSampling := p.h.(SamplingHandler)
+	if isCustom || isNats || isFilter || isSampling {
+		hooks = append(hooks, HOOK_POINT_CUSTOM_MESSAGE)
+	}
+	return mergeHookBitmap(hooks)
+}
+
+func (p *handlerParser) HookIn() []HookBitmap {
+	return []HookBitmap{p.hooks}
+}
+
+func (p *handlerParser) CustomMessageHookIn() uint64 {
+	if d, ok := p.h.(CustomMessageHookDeclarer); ok {
+		return d.CustomMessageHookIn()
+	}
+	return 0
+}
+
+func (p *handlerParser) CustomMessageSubscriptions() []CustomMessageSubscription {
+	if s, ok := p.h.(CustomMessageSubscriber); ok {
+		return s.CustomMessageSubscriptions()
+	}
+	return nil
+}
+
+func (p *handlerParser) OnHttpReq(ctx *HttpReqCtx) Action {
+	if h, ok := p.h.(HttpReqHandler); ok {
+		return h.OnHttpReq(ctx)
+	}
+	return ActionNext()
+}
+
+func (p *handlerParser) OnHttpResp(ctx *HttpRespCtx) Action {
+	if h, ok := p.h.(HttpRespHandler); ok {
+		return h.OnHttpResp(ctx)
+	}
+	return ActionNext()
+}
+
+func (p *handlerParser) OnCustomMessage(ctx *CustomMessageCtx) Action {
+	if h, ok := p.h.(CustomMessageHandler); ok {
+		return h.OnCustomMessage(ctx)
+	}
+	if ctx.CheckParseProtocol(PROTOCOL_NATS, true) {
+		h, ok := p.h.(NatsMessageHandler)
+		if !ok {
+			return ActionNext()
+		}
+		message := &pb.NatsMessage{}
+		if err := message.UnmarshalVT(ctx.Payload); err != nil {
+			return ActionAbortWithErr(err)
+		}
+		return h.OnNatsMessage(message)
+	}
+	return ActionNext()
+}
+
+func (p *handlerParser) OnCheckPayload(ctx *ParseCtx) (uint8, string, uint8) {
+	if h, ok := p.h.(PayloadParser); ok {
+		return h.OnCheckPayload(ctx)
+	}
+	return 0, "", 0
+}
+
+func (p *handlerParser) OnParsePayload(ctx *ParseCtx) Action {
+	if h, ok := p.h.(PayloadParser); ok {
+		return h.OnParsePayload(ctx)
+	}
+	return ActionNext()
+}
+
+// implements report whether the handler implement the custom message hook point, see customHookImplementer
+func (p *handlerParser) implements(hookPoint uint16) bool {
+	switch hookPoint {
+	case SessionFilter:
+		_, ok := p.h.(SessionFilterHandler)
+		return ok
+	case Sampling:
+		_, ok := p.h.(SamplingHandler)
+		return ok
+	}
+	return true
+}
+
+func (p *handlerParser) OnSessionFilter(ctx *CustomMessageCtx) bool {
+	if h, ok := p.h.(SessionFilterHandler); ok {
+		return h.OnSessionFilter(ctx)
+	}
+	return true
+}
+
+func (p *handlerParser) OnSampling(ctx *CustomMessageCtx) SamplingResult {
+	if h, ok := p.h.(SamplingHandler); ok {
+		return h.OnSampling(ctx)
+	}
+	return SamplingResult{Decision: SamplingDefault}
+}
@@ -0,0 +1,181 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/pb"
+)
+
+// captureLogs collect the logs until the test end
+func captureLogs(t *testing.T) *[]string {
+	logs := &[]string{}
+	testLog = func(s string, level uint8) {
+		*logs = append(*logs, s)
+	}
+	t.Cleanup(func() { testLog = nil })
+	return logs
+}
+
+// restoreParser restore vmParser replaced by SetHandler after the test
+func restoreParser(t *testing.T) {
+	saved := vmParser
+	t.Cleanup(func() { vmParser = saved })
+}
+
+type reqHandler struct{}
+
+func (reqHandler) OnHttpReq(*HttpReqCtx) Action { return ActionAbort() }
+
+type respHandler struct{}
+
+func (respHandler) OnHttpResp(*HttpRespCtx) Action { return ActionAbort() }
+
+type payloadHandler struct{}
+
+func (payloadHandler) OnCheckPayload(*ParseCtx) (uint8, string, uint8) { return 1, "demo", 0 }
+func (payloadHandler) OnParsePayload(*ParseCtx) Action                 { return ActionAbort() }
+
+type customHandler struct{}
+
+func (customHandler) OnCustomMessage(*CustomMessageCtx) Action { return ActionAbort() }
+func (customHandler) CustomMessageHookIn() uint64              { return CUSTOM_MESSAGE_HOOK_ALL }
+
+type natsHandler struct{}
+
+func (natsHandler) OnNatsMessage(*pb.NatsMessage) Action { return ActionAbort() }
+
+type filterHandler struct{}
+
+func (filterHandler) OnSessionFilter(*CustomMessageCtx) bool { return false }
+
+type samplingHandler struct{}
+
+func (samplingHandler) OnSampling(*CustomMessageCtx) SamplingResult {
+	return SamplingResult{Decision: SamplingDrop}
+}
+
+type httpHandler struct {
+	reqHandler
+	respHandler
+}
+
+// declare the request only, but implement both request and response
+type declaredHandler struct {
+	respHandler
+	declared []HookBitmap
+}
+
+func (h declaredHandler) HookIn() []HookBitmap { return h.declared }
+
+func TestDerivedHooks(t *testing.T) {
+	cases := []struct {
+		name    string
+		handler interface{}
+		want    HookBitmap
+	}{
+		{"none", struct{}{}, HookBitmap{0, 0}},
+		{"http request", reqHandler{}, HOOK_POINT_HTTP_REQ},
+		{"http response", respHandler{}, HOOK_POINT_HTTP_RESP},
+		{"http", httpHandler{}, mergeHookBitmap([]HookBitmap{HOOK_POINT_HTTP_REQ, HOOK_POINT_HTTP_RESP})},
+		{"payload", payloadHandler{}, HOOK_POINT_PAYLOAD_PARSE},
+		{"custom message", customHandler{}, HOOK_POINT_CUSTOM_MESSAGE},
+		{"nats", natsHandler{}, HOOK_POINT_CUSTOM_MESSAGE},
+		{"session filter", filterHandler{}, HOOK_POINT_CUSTOM_MESSAGE},
+		{"sampling", samplingHandler{}, HOOK_POINT_CUSTOM_MESSAGE},
+		{"default parser", DefaultParser{}, mergeHookBitmap([]HookBitmap{
+			HOOK_POINT_HTTP_REQ, HOOK_POINT_HTTP_RESP, HOOK_POINT_PAYLOAD_PARSE, HOOK_POINT_CUSTOM_MESSAGE,
+		})},
+	}
+	for _, c := range cases {
+		p := &handlerParser{h: c.handler}
+		if got := p.derivedHooks(); got != c.want {
+			t.Errorf("%s: got %x, want %x", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSetHandler(t *testing.T) {
+	restoreParser(t)
+	cases := []struct {
+		name    string
+		handler interface{}
+		hooks   HookBitmap
+		warns   []string
+	}{
+		{"derived", httpHandler{}, mergeHookBitmap([]HookBitmap{HOOK_POINT_HTTP_REQ, HOOK_POINT_HTTP_RESP}), nil},
+		{"declared mismatch", declaredHandler{declared: []HookBitmap{HOOK_POINT_HTTP_REQ}}, HOOK_POINT_HTTP_REQ, []string{
+			"HOOK_POINT_HTTP_REQ is declared in HookIn but the handler is not implemented",
+			"handler of HOOK_POINT_HTTP_RESP is implemented but not declared in HookIn",
+		}},
+		{"declared match", declaredHandler{declared: []HookBitmap{HOOK_POINT_HTTP_RESP}}, HOOK_POINT_HTTP_RESP, nil},
+		{"no hook", struct{}{}, HookBitmap{0, 0}, []string{"implements no hook"}},
+		{"custom message without subscription", natsHandler{}, HOOK_POINT_CUSTOM_MESSAGE, []string{
+			"not implement CustomMessageHookIn or CustomMessageSubscriptions",
+		}},
+		{"custom message with hook in", customHandler{}, HOOK_POINT_CUSTOM_MESSAGE, nil},
+	}
+	for _, c := range cases {
+		logs := captureLogs(t)
+		SetHandler(c.handler)
+		if got := mergeHookBitmap(vmParser.HookIn()); got != c.hooks {
+			t.Errorf("%s: got hooks %x, want %x", c.name, got, c.hooks)
+		}
+		if len(*logs) != len(c.warns) {
+			t.Errorf("%s: got warnings %q, want %q", c.name, *logs, c.warns)
+			continue
+		}
+		for i, w := range c.warns {
+			if !strings.Contains((*logs)[i], w) {
+				t.Errorf("%s: got warning %q, want %q", c.name, (*logs)[i], w)
+			}
+		}
+	}
+}
+
+func TestHandlerParserDispatch(t *testing.T) {
+	isAbort := func(a Action) bool { return a.abort() }
+
+	p := &handlerParser{h: httpHandler{}}
+	if !isAbort(p.OnHttpReq(&HttpReqCtx{})) || !isAbort(p.OnHttpResp(&HttpRespCtx{})) {
+		t.Error("the http handler is not called")
+	}
+	if isAbort(p.OnParsePayload(&ParseCtx{})) || isAbort(p.OnCustomMessage(&CustomMessageCtx{})) {
+		t.Error("the unimplemented handler should return ActionNext")
+	}
+	if proto, _, _ := p.OnCheckPayload(&ParseCtx{}); proto != 0 {
+		t.Errorf("the unimplemented check payload got protocol %d", proto)
+	}
+
+	p = &handlerParser{h: payloadHandler{}}
+	if proto, name, _ := p.OnCheckPayload(&ParseCtx{}); proto != 1 || name != "demo" || !isAbort(p.OnParsePayload(&ParseCtx{})) {
+		t.Error("the payload parser is not called")
+	}
+	if isAbort(p.OnHttpReq(&HttpReqCtx{})) || isAbort(p.OnHttpResp(&HttpRespCtx{})) {
+		t.Error("the unimplemented http handler should return ActionNext")
+	}
+	if p.CustomMessageHookIn() != 0 || p.CustomMessageSubscriptions() != nil {
+		t.Error("the handler without custom message declare nothing")
+	}
+
+	p = &handlerParser{h: customHandler{}}
+	if !isAbort(p.OnCustomMessage(&CustomMessageCtx{})) || p.CustomMessageHookIn() != CUSTOM_MESSAGE_HOOK_ALL {
+		t.Error("the custom message handler is not called")
+	}
+}
@@ -19,6 +19,7 @@ package sdk
 import (
 	"errors"
 	"net"
+	"net/netip"
 )
 
 const (
@@ -36,6 +37,8 @@ const (
 	EbpfTypeTlsUprobe         EbpfType = 1
 	EbpfTypeGoHttp2Uprobe     EbpfType = 2
 	EbpfTypeGoHttp2UprobeDATA EbpfType = 5
+	EbpfTypeIOEvent           EbpfType = 6
+	EbpfTypeOtherUprobe       EbpfType = 7
 	EbpfTypeUnixSocket        EbpfType = 8
 	EbpfTypeNone              EbpfType = 255
 )
@@ -53,6 +56,27 @@ type Direction uint8
 type EbpfType uint8
 type RespStatus uint8
 
+// the agent may send the protocol newer than the sdk, it is preserved as raw number
+func (p L4Protocol) IsKnown() bool {
+	return p == UDP || p == TCP
+}
+
+// the agent may add new ebpf source newer than the sdk, it is preserved as raw number
+func (t EbpfType) IsKnown() bool {
+	switch t {
+	case EbpfTypeTracePoint,
+		EbpfTypeTlsUprobe,
+		EbpfTypeGoHttp2Uprobe,
+		EbpfTypeGoHttp2UprobeDATA,
+		EbpfTypeIOEvent,
+		EbpfTypeOtherUprobe,
+		EbpfTypeUnixSocket,
+		EbpfTypeNone:
+		return true
+	}
+	return false
+}
+
 type HttpReqCtx struct {
 	BaseCtx   ParseCtx
 	Path      string
@@ -88,6 +112,55 @@ func (ctx *CustomMessageCtx) CheckParseProtocol(protocol uint16, isRequest bool)
 	return false
 }
 
+// CheckHook report whether the message is delivered for the hook point of the protocol in the given direction
+func (ctx *CustomMessageCtx) CheckHook(hookPoint uint16, protocol uint16, isRequest bool) bool {
+	return ctx.HookPoint == hookPoint &&
+		uint16(ctx.TypeCode) == protocol &&
+		(ctx.BaseCtx.Direction == DirectionRequest) == isRequest
+}
+
+type SamplingDecision uint8
+
+const (
+	// leave the decision to agent
+	SamplingDefault SamplingDecision = 0
+	SamplingKeep    SamplingDecision = 1
+	SamplingDrop    SamplingDecision = 2
+	// keep the session with probability SamplingResult.Rate
+	SamplingRate SamplingDecision = 3
+)
+
+// the rate is expressed in parts per million
+const SAMPLING_RATE_SCALE = 1000000
+
+type SamplingResult struct {
+	Decision SamplingDecision
+	// only valid when Decision is SamplingRate, in [0, SAMPLING_RATE_SCALE]
+	Rate uint32
+}
+
+func SampleKeep() SamplingResult {
+	return SamplingResult{Decision: SamplingKeep}
+}
+
+func SampleDrop() SamplingResult {
+	return SamplingResult{Decision: SamplingDrop}
+}
+
+// ratio in [0, 1], out of range value will be clamped
+func SampleRate(ratio float64) SamplingResult {
+	if ratio <= 0 {
+		return SampleDrop()
+	}
+	if ratio >= 1 {
+		return SampleKeep()
+	}
+	return SamplingResult{
+		Decision: SamplingRate,
+		Rate:     uint32(ratio * SAMPLING_RATE_SCALE),
+	}
+}
+
 type ParseCtx struct {
 	SrcIP     net.IPAddr
 	SrcPort   uint16
@@ -102,7 +175,30 @@ type ParseCtx struct {
 	ProcName string
 	FlowID   uint64
 	BufSize  uint16
-	payload  []byte
+
+	// the following fields are sent by the agent support ABI_VERSION_EXT_CTX, zero indicate absent.
+	// only EbpfType is not EbpfTypeNone will not empty
+	PID         uint32
+	TID         uint32
+	CoroutineID uint64
+	SocketID    uint64
+	// the sequence of data captured by ebpf in the socket, use for ordering the data
+	CapSeq      uint64
+	TcpSeq      uint32
+	TcpAck      uint32
+	ContainerID string
+	// the pod of the container, empty if the process is not in kubernetes
+	PodName string
+
+	// same as SrcIP and DstIP, see Src() and Dst()
+	srcAddr netip.Addr
+	dstAddr netip.Addr
+	payload []byte
+}
+
+// the l7 protocol as agent native protocol, only meaningful when the flow is identified by agent rather than plugin
+func (p *ParseCtx) L7Protocol() L7Protocol {
+	return L7Protocol(p.L7)
 }
 
 func (p *ParseCtx) GetPayload() ([]byte, error) {
@@ -0,0 +1,164 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http1
+
+import (
+	"bytes"
+	"errors"
+)
+
+var ErrInvalidChunk = errors.New("http1: invalid chunk")
+
+// the max size of the chunk size line including the extensions
+const MAX_CHUNK_LINE = 4096
+
+type chunkState uint8
+
+const (
+	chunkSize chunkState = iota
+	chunkData
+	chunkDataEnd
+	chunkTrailer
+	chunkDone
+)
+
+/*
+ChunkedDecoder decode the chunked transfer encoding incrementally, the state is kept between the calls so the body
+split into several payloads can be decoded one by one, such as the streaming response:
+
+	d := &http1.ChunkedDecoder{}
+	body, n, err := d.Decode(nil, payload)
+	// payload[n:] is the truncated chunk size line, which should be prepended to the next payload
+
+the data of a chunk is decoded as soon as read, it does not wait for the whole chunk.
+*/
+type ChunkedDecoder struct {
+	state chunkState
+	// the remaining size of the current chunk
+	remain int64
+	// the number of the chunks read completely, excluding the last chunk
+	Chunks int
+}
+
+func (d *ChunkedDecoder) Reset() {
+	*d = ChunkedDecoder{}
+}
+
+// Done return true after the last chunk and the trailer are read
+func (d *ChunkedDecoder) Done() bool {
+	return d.state == chunkDone
+}
+
+// Decode append the chunk data in src to dst, n is the size consumed of src
+func (d *ChunkedDecoder) Decode(dst, src []byte) ([]byte, int, error) {
+	n, err := d.feed(src, func(b []byte) {
+		dst = append(dst, b...)
+	})
+	return dst, n, err
+}
+
+// Skip is the same as Decode but discard the data, used for finding the end of the body
+func (d *ChunkedDecoder) Skip(src []byte) (int, error) {
+	return d.feed(src, nil)
+}
+
+func (d *ChunkedDecoder) feed(src []byte, emit func([]byte)) (int, error) {
+	n := 0
+	for n < len(src) && d.state != chunkDone {
+		switch d.state {
+		case chunkSize:
+			line, next := readLine(src, n)
+			if next < 0 {
+				if len(line) > MAX_CHUNK_LINE {
+					return n, ErrInvalidChunk
+				}
+				return n, nil
+			}
+			// the chunk extensions are ignored
+			if i := bytes.IndexByte(line, ';'); i >= 0 {
+				line = line[:i]
+			}
+			size, ok := parseHex(bytes.TrimSpace(line))
+			if !ok {
+				return n, ErrInvalidChunk
+			}
+			n = next
+			if size == 0 {
+				d.state = chunkTrailer
+			} else {
+				d.remain, d.state = size, chunkData
+			}
+		case chunkData:
+			size := len(src) - n
+			if int64(size) > d.remain {
+				size = int(d.remain)
+			}
+			if emit != nil {
+				emit(src[n : n+size])
+			}
+			n += size
+			if d.remain -= int64(size); d.remain == 0 {
+				d.state = chunkDataEnd
+				d.Chunks++
+			}
+		case chunkDataEnd:
+			switch {
+			case src[n] == '\n':
+				n++
+			case src[n] == '\r' && n+1 == len(src):
+				return n, nil
+			case src[n] == '\r' && src[n+1] == '\n':
+				n += 2
+			default:
+				return n, ErrInvalidChunk
+			}
+			d.state = chunkSize
+		case chunkTrailer:
+			line, next := readLine(src, n)
+			if next < 0 {
+				return n, nil
+			}
+			n = next
+			if len(line) == 0 {
+				d.state = chunkDone
+			}
+		}
+	}
+	return n, nil
+}
+
+// at most 15 hex digits to avoid overflow
+func parseHex(b []byte) (int64, bool) {
+	if len(b) == 0 || len(b) > 15 {
+		return 0, false
+	}
+	var v int64
+	for _, c := range b {
+		switch {
+		case '0' <= c && c <= '9':
+			c -= '0'
+		case 'a' <= c && c <= 'f':
+			c -= 'a' - 10
+		case 'A' <= c && c <= 'F':
+			c -= 'A' - 10
+		default:
+			return 0, false
+		}
+		v = v<<4 | int64(c)
+	}
+	return v, true
+}
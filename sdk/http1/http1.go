@@ -0,0 +1,359 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Package http1 parse the http/1.x message in a byte slice without copy, as a replacement of http.ReadRequest and
+http.ReadResponse in the hooks. the method, target, status, headers and body are the sub slices of the payload, so they
+must be copied if they need to outlive the payload.
+
+the payload is usually a part of the message due to the tcp segmentation, so the parse tolerate the partial message:
+the headers are parsed as far as read and HeaderComplete, Complete indicate how much is read. several pipelined
+messages in one payload are parsed by ParseAll:
+
+	msgs, err := http1.ParseAll(payload)
+	for _, m := range msgs {
+		sdk.Info("%s %s", m.Method, m.Path())
+	}
+*/
+package http1
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+)
+
+var (
+	ErrInvalid = errors.New("http1: invalid message")
+	// the data end before the start line can be recognized
+	ErrIncomplete = errors.New("http1: incomplete start line")
+)
+
+type Header struct {
+	Name  []byte
+	Value []byte
+}
+
+type Message struct {
+	// the request line: Method Target Proto, Method is empty for the response
+	Method []byte
+	Target []byte
+	// the status line: Proto StatusCode Reason, StatusCode is 0 for the request
+	Proto      []byte
+	StatusCode int
+	Reason     []byte
+
+	Headers []Header
+	// the headers are terminated by an empty line, otherwise the headers are parsed as far as read
+	HeaderComplete bool
+	// -1 if absent
+	ContentLength int64
+	Chunked       bool
+	// the raw body in data as far as read, which is still chunk encoded if Chunked
+	Body []byte
+	// the body is complete by the content length or the last chunk, the response delimited by close is never complete
+	Complete bool
+	// the size of the message in data, which is also the offset of the next pipelined message
+	Size int
+}
+
+func (m *Message) IsRequest() bool {
+	return len(m.Method) > 0
+}
+
+// Header return the value of the first header of name, the name is case-insensitive
+func (m *Message) Header(name string) []byte {
+	for i := range m.Headers {
+		if equalFold(m.Headers[i].Name, name) {
+			return m.Headers[i].Value
+		}
+	}
+	return nil
+}
+
+// HeaderString is the same as Header but return string, it can be used as tracectx.Getter
+func (m *Message) HeaderString(name string) string {
+	return string(m.Header(name))
+}
+
+// Values return the values of all headers of name in order
+func (m *Message) Values(name string) []string {
+	var values []string
+	for i := range m.Headers {
+		if equalFold(m.Headers[i].Name, name) {
+			values = append(values, string(m.Headers[i].Value))
+		}
+	}
+	return values
+}
+
+// Path return the target without query
+func (m *Message) Path() []byte {
+	if i := bytes.IndexByte(m.Target, '?'); i >= 0 {
+		return m.Target[:i]
+	}
+	return m.Target
+}
+
+// Query return the raw query of target without '?', nil if absent
+func (m *Message) Query() []byte {
+	if i := bytes.IndexByte(m.Target, '?'); i >= 0 {
+		return m.Target[i+1:]
+	}
+	return nil
+}
+
+// DecodeBody append the body to dst, the chunked body is decoded as far as read
+func (m *Message) DecodeBody(dst []byte) ([]byte, error) {
+	if !m.Chunked {
+		return append(dst, m.Body...), nil
+	}
+	d := &ChunkedDecoder{}
+	dst, _, err := d.Decode(dst, m.Body)
+	return dst, err
+}
+
+// Parse the request or response according to the start of data
+func Parse(data []byte) (*Message, error) {
+	if bytes.HasPrefix(data, []byte("HTTP/")) {
+		return ParseResponse(data)
+	}
+	return ParseRequest(data)
+}
+
+// ParseAll parse the pipelined messages until the data end or a message is incomplete
+func ParseAll(data []byte) ([]*Message, error) {
+	var msgs []*Message
+	for len(data) > 0 {
+		m, err := Parse(data)
+		if err != nil {
+			return msgs, err
+		}
+		msgs = append(msgs, m)
+		if !m.Complete {
+			break
+		}
+		data = data[m.Size:]
+	}
+	return msgs, nil
+}
+
+func ParseRequest(data []byte) (*Message, error) {
+	m := &Message{ContentLength: -1}
+	line, next := readLine(data, 0)
+	if err := m.parseRequestLine(line, next >= 0); err != nil {
+		return nil, err
+	}
+	if err := m.parseHeaders(data, next); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func ParseResponse(data []byte) (*Message, error) {
+	m := &Message{ContentLength: -1}
+	line, next := readLine(data, 0)
+	if err := m.parseStatusLine(line, next >= 0); err != nil {
+		return nil, err
+	}
+	if err := m.parseHeaders(data, next); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// the target and proto of the truncated request line are parsed as far as read
+func (m *Message) parseRequestLine(line []byte, complete bool) error {
+	sp := bytes.IndexByte(line, ' ')
+	if sp < 0 {
+		if !complete && isToken(line) {
+			return ErrIncomplete
+		}
+		return ErrInvalid
+	}
+	if m.Method = line[:sp]; !isToken(m.Method) {
+		return ErrInvalid
+	}
+	rest := line[sp+1:]
+	if sp = bytes.LastIndexByte(rest, ' '); sp >= 0 && (complete || bytes.HasPrefix(rest[sp+1:], []byte("HTTP/"))) {
+		m.Target, m.Proto = rest[:sp], rest[sp+1:]
+	} else {
+		m.Target = rest
+	}
+	if len(m.Target) == 0 || (complete && !isProto(m.Proto)) {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// HTTP/1.1 200 OK, the reason is optional
+func (m *Message) parseStatusLine(line []byte, complete bool) error {
+	if len(line) < 12 {
+		if !complete && bytes.HasPrefix(line, []byte("HTTP/")) {
+			return ErrIncomplete
+		}
+		return ErrInvalid
+	}
+	if !isProto(line[:8]) || line[8] != ' ' {
+		return ErrInvalid
+	}
+	m.Proto = line[:8]
+	code, err := strconv.Atoi(string(line[9:12]))
+	if err != nil || code < 100 || code > 999 {
+		return ErrInvalid
+	}
+	m.StatusCode = code
+	if len(line) > 12 {
+		if line[12] != ' ' {
+			return ErrInvalid
+		}
+		m.Reason = line[13:]
+	}
+	return nil
+}
+
+func (m *Message) parseHeaders(data []byte, pos int) error {
+	if pos < 0 {
+		m.Size = len(data)
+		return nil
+	}
+	for {
+		line, next := readLine(data, pos)
+		if next < 0 {
+			// the truncated header line is dropped
+			m.Size = len(data)
+			return nil
+		}
+		pos = next
+		if len(line) == 0 {
+			m.HeaderComplete = true
+			break
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			// the obsolete line folding is ignored
+			continue
+		}
+		colon := bytes.IndexByte(line, ':')
+		if colon <= 0 || !isToken(line[:colon]) {
+			return ErrInvalid
+		}
+		m.Headers = append(m.Headers, Header{Name: line[:colon], Value: bytes.Trim(line[colon+1:], " \t")})
+	}
+	return m.parseBody(data, pos)
+}
+
+func (m *Message) parseBody(data []byte, start int) error {
+	for _, h := range m.Headers {
+		switch {
+		case equalFold(h.Name, "Transfer-Encoding"):
+			// chunked must be the last coding
+			coding := h.Value
+			if i := bytes.LastIndexByte(coding, ','); i >= 0 {
+				coding = coding[i+1:]
+			}
+			m.Chunked = equalFold(bytes.TrimSpace(coding), "chunked")
+		case equalFold(h.Name, "Content-Length") && m.ContentLength < 0:
+			n, err := strconv.ParseInt(string(h.Value), 10, 64)
+			if err != nil || n < 0 {
+				return ErrInvalid
+			}
+			m.ContentLength = n
+		}
+	}
+
+	rest := data[start:]
+	switch {
+	case !m.IsRequest() && (m.StatusCode < 200 || m.StatusCode == 204 || m.StatusCode == 304):
+		m.Complete, m.Size = true, start
+	case m.Chunked:
+		d := &ChunkedDecoder{}
+		n, err := d.Skip(rest)
+		if err != nil {
+			return err
+		}
+		if d.Done() {
+			m.Body, m.Complete, m.Size = rest[:n], true, start+n
+		} else {
+			m.Body, m.Size = rest, len(data)
+		}
+	case m.ContentLength >= 0:
+		n := len(rest)
+		if int64(n) >= m.ContentLength {
+			n = int(m.ContentLength)
+			m.Complete = true
+		}
+		m.Body, m.Size = rest[:n], start+n
+	case m.IsRequest():
+		m.Complete, m.Size = true, start
+	default:
+		// the response without length is delimited by the close of connection
+		m.Body, m.Size = rest, len(data)
+	}
+	return nil
+}
+
+// readLine return the line without CRLF or LF start from pos, next is -1 if the line is not terminated
+func readLine(data []byte, pos int) (line []byte, next int) {
+	i := bytes.IndexByte(data[pos:], '\n')
+	if i < 0 {
+		return data[pos:], -1
+	}
+	line = data[pos : pos+i]
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	return line, pos + i + 1
+}
+
+// HTTP/1.0 or HTTP/1.1
+func isProto(b []byte) bool {
+	return len(b) == 8 && bytes.HasPrefix(b, []byte("HTTP/1.")) && b[7] >= '0' && b[7] <= '9'
+}
+
+func isToken(b []byte) bool {
+	if len(b) == 0 {
+		return false
+	}
+	for _, c := range b {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case bytes.IndexByte([]byte("!#$%&'*+-.^_`|~"), c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ascii case-insensitive compare without allocation
+func equalFold(b []byte, s string) bool {
+	if len(b) != len(s) {
+		return false
+	}
+	for i := 0; i < len(b); i++ {
+		x, y := b[i], s[i]
+		if 'A' <= x && x <= 'Z' {
+			x += 'a' - 'A'
+		}
+		if 'A' <= y && y <= 'Z' {
+			y += 'a' - 'A'
+		}
+		if x != y {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http1
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     string
+		request  bool
+		target   string
+		status   int
+		headers  int
+		hdrDone  bool
+		body     string
+		complete bool
+		size     int
+		err      error
+	}{
+		{"get", "GET /a?b=1 HTTP/1.1\r\nHost: x\r\n\r\n", true, "/a?b=1", 0, 1, true, "", true, 32, nil},
+		{"post with length", "POST /p HTTP/1.1\r\nContent-Length: 3\r\n\r\nabcGET", true, "/p", 0, 1, true, "abc", true, 42, nil},
+		{"post partial body", "POST /p HTTP/1.1\r\nContent-Length: 5\r\n\r\nab", true, "/p", 0, 1, true, "ab", false, 41, nil},
+		{"bare lf", "GET / HTTP/1.0\nHost: x\n\n", true, "/", 0, 1, true, "", true, 24, nil},
+		{"truncated request line", "GET /very/long/pa", true, "/very/long/pa", 0, 0, false, "", false, 17, nil},
+		{"truncated header", "GET / HTTP/1.1\r\nHost: x\r\nUser-Ag", true, "/", 0, 1, false, "", false, 32, nil},
+		{"truncated method", "GE", false, "", 0, 0, false, "", false, 0, ErrIncomplete},
+		{"response", "HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok", false, "", 200, 1, true, "ok", true, 40, nil},
+		{"response without reason", "HTTP/1.1 404\r\n\r\n", false, "", 404, 0, true, "", false, 16, nil},
+		{"response without length", "HTTP/1.0 200 OK\r\n\r\nstream", false, "", 200, 0, true, "stream", false, 25, nil},
+		{"no content", "HTTP/1.1 204 No Content\r\n\r\nHTTP/1.1", false, "", 204, 0, true, "", true, 27, nil},
+		{"truncated status line", "HTTP/1.1 2", false, "", 0, 0, false, "", false, 0, ErrIncomplete},
+		{"chunked complete", "HTTP/1.1 200 OK\r\nTransfer-Encoding: gzip, chunked\r\n\r\n3\r\nabc\r\n0\r\n\r\nnext", false, "", 200, 1, true, "3\r\nabc\r\n0\r\n\r\n", true, 66, nil},
+		{"chunked partial", "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nab", false, "", 200, 1, true, "5\r\nab", false, 52, nil},
+		{"not http", "\x16\x03\x01\x02\x00", false, "", 0, 0, false, "", false, 0, ErrInvalid},
+		{"bad method", "G(T / HTTP/1.1\r\n\r\n", false, "", 0, 0, false, "", false, 0, ErrInvalid},
+		{"bad proto", "GET / HTTP/2.0\r\n\r\n", false, "", 0, 0, false, "", false, 0, ErrInvalid},
+		{"bad status", "HTTP/1.1 2x0 OK\r\n\r\n", false, "", 0, 0, false, "", false, 0, ErrInvalid},
+		{"bad header", "GET / HTTP/1.1\r\nno colon\r\n\r\n", false, "", 0, 0, false, "", false, 0, ErrInvalid},
+		{"bad content length", "GET / HTTP/1.1\r\nContent-Length: -1\r\n\r\n", false, "", 0, 0, false, "", false, 0, ErrInvalid},
+		{"bad chunk size", "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\nzz\r\n", false, "", 0, 0, false, "", false, 0, ErrInvalidChunk},
+	}
+	for _, c := range cases {
+		m, err := Parse([]byte(c.data))
+		if err != c.err {
+			t.Errorf("%s: got error %v, want %v", c.name, err, c.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if m.IsRequest() != c.request || string(m.Target) != c.target || m.StatusCode != c.status ||
+			len(m.Headers) != c.headers || m.HeaderComplete != c.hdrDone || string(m.Body) != c.body ||
+			m.Complete != c.complete || m.Size != c.size {
+			t.Errorf("%s: unexpected message request %v target %q status %d headers %d/%v body %q complete %v size %d",
+				c.name, m.IsRequest(), m.Target, m.StatusCode, len(m.Headers), m.HeaderComplete, m.Body, m.Complete, m.Size)
+		}
+	}
+}
+
+func TestMessageAccessors(t *testing.T) {
+	m, err := ParseRequest([]byte("GET /a/b?x=1&y=2 HTTP/1.1\r\nX-Forwarded-For: 1.1.1.1\r\nx-forwarded-for: 2.2.2.2\r\nHost:  h  \r\n\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(m.Path()) != "/a/b" || string(m.Query()) != "x=1&y=2" || m.HeaderString("HOST") != "h" || m.Header("Nope") != nil {
+		t.Errorf("unexpected path %q query %q host %q", m.Path(), m.Query(), m.HeaderString("host"))
+	}
+	if v := m.Values("X-Forwarded-For"); len(v) != 2 || v[1] != "2.2.2.2" {
+		t.Errorf("unexpected values %q", v)
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	data := "GET /1 HTTP/1.1\r\n\r\nPOST /2 HTTP/1.1\r\nContent-Length: 2\r\n\r\nokGET /3 HTTP/1.1\r\nHo"
+	msgs, err := ParseAll([]byte(data))
+	if err != nil || len(msgs) != 3 {
+		t.Fatalf("got %d messages, error %v", len(msgs), err)
+	}
+	for i, target := range []string{"/1", "/2", "/3"} {
+		if string(msgs[i].Target) != target {
+			t.Errorf("message %d got target %q, want %q", i, msgs[i].Target, target)
+		}
+	}
+	if msgs[2].HeaderComplete {
+		t.Error("the last message is truncated")
+	}
+	if msgs, err := ParseAll([]byte("GET / HTTP/1.1\r\n\r\n\x00\x01")); err != ErrInvalid || len(msgs) != 1 {
+		t.Errorf("got %d messages, error %v", len(msgs), err)
+	}
+}
+
+const chunkedBody = "4\r\nWiki\r\n6;ext=1\r\npedia \r\nE\r\nin \r\n\r\nchunks.\r\n0\r\nTrailer: x\r\n\r\n"
+
+// decode the chunks split into payloads, the unconsumed part is prepended to the next payload
+func decodeSplit(t *testing.T, payloads []string) (string, bool) {
+	d := &ChunkedDecoder{}
+	var (
+		body     []byte
+		leftover []byte
+	)
+	for _, p := range payloads {
+		src := append(leftover, p...)
+		var (
+			n   int
+			err error
+		)
+		body, n, err = d.Decode(body, src)
+		if err != nil {
+			t.Fatalf("payloads %q: %v", payloads, err)
+		}
+		leftover = append([]byte{}, src[n:]...)
+	}
+	return string(body), d.Done()
+}
+
+func TestChunkedDecoderSplit(t *testing.T) {
+	want := "Wikipedia in \r\n\r\nchunks."
+	for i := 0; i <= len(chunkedBody); i++ {
+		for j := i; j <= len(chunkedBody); j++ {
+			payloads := []string{chunkedBody[:i], chunkedBody[i:j], chunkedBody[j:]}
+			if body, done := decodeSplit(t, payloads); body != want || !done {
+				t.Fatalf("split at %d, %d: got %q done %v", i, j, body, done)
+			}
+		}
+	}
+
+	bytewise := make([]string, len(chunkedBody))
+	for i := range chunkedBody {
+		bytewise[i] = chunkedBody[i : i+1]
+	}
+	if body, done := decodeSplit(t, bytewise); body != want || !done {
+		t.Errorf("byte by byte: got %q done %v", body, done)
+	}
+}
+
+func TestChunkedDecoderMalformed(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+	}{
+		{"bad size", "x\r\n"},
+		{"empty size", "\r\n"},
+		{"size overflow", "1000000000000000\r\n"},
+		{"missing crlf after data", "1\r\nab\r\n"},
+		{"size line too long", "1;" + string(make([]byte, MAX_CHUNK_LINE))},
+	}
+	for _, c := range cases {
+		d := &ChunkedDecoder{}
+		if _, _, err := d.Decode(nil, []byte(c.data)); err != ErrInvalidChunk {
+			t.Errorf("%s: got error %v, want %v", c.name, err, ErrInvalidChunk)
+		}
+	}
+
+	m, err := ParseResponse([]byte("HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n" + chunkedBody[:20]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := m.DecodeBody([]byte("prefix:"))
+	if err != nil || string(body) != "prefix:Wikipe" {
+		t.Errorf("partial chunked body got %q, error %v", body, err)
+	}
+}
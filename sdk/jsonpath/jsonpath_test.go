@@ -0,0 +1,240 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsonpath
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+)
+
+func TestParsePath(t *testing.T) {
+	cases := []struct {
+		path string
+		want string
+		err  bool
+	}{
+		{"$.data.user_id", "$.data.user_id", false},
+		{"data.items[0].name", "$.data.items[0].name", false},
+		{"$['key.with.dot'][2]", "$['key.with.dot'][2]", false},
+		{`$["default=x"]`, "$.default=x", false},
+		{"$", "$", false},
+		{"", "$", false},
+		{"$..a", "", true},
+		{"$.a.", "", true},
+		{"$.a[", "", true},
+		{"$.a[-1]", "", true},
+		{"$.a[x]", "", true},
+		{"$a", "$.a", false},
+	}
+	for _, c := range cases {
+		p, err := ParsePath(c.path)
+		if c.err {
+			if !errors.Is(err, ErrInvalidPath) {
+				t.Errorf("%q: got error %v, want %v", c.path, err, ErrInvalidPath)
+			}
+			continue
+		}
+		if err != nil || p.String() != c.want {
+			t.Errorf("%q: got %q, error %v, want %q", c.path, p.String(), err, c.want)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	data := `{"code": 0, "msg": "a\"bé😀", "data": {"user_id": 12345, "ok": true, "none": null,
+		"items": [{"name": "x", "tags": ["}", "]"]}, {"name": "y"}], "a.b": 1.5, "k\"q": "esc"}}`
+	cases := []struct {
+		name  string
+		data  string
+		path  string
+		kind  Kind
+		value string
+		found bool
+	}{
+		{"number", data, "$.code", KindNumber, "0", true},
+		{"escaped string", data, "$.msg", KindString, "a\"bé😀", true},
+		{"nested", data, "$.data.user_id", KindNumber, "12345", true},
+		{"bool", data, "$.data.ok", KindBool, "true", true},
+		{"null", data, "$.data.none", KindNull, "null", true},
+		{"array element", data, "$.data.items[1].name", KindString, "y", true},
+		{"brackets in string", data, "$.data.items[0].tags[1]", KindString, "]", true},
+		{"object", data, "$.data.items[1]", KindObject, `{"name": "y"}`, true},
+		{"quoted key", data, "$.data['a.b']", KindNumber, "1.5", true},
+		{"escaped key", data, `$.data['k"q']`, KindString, "esc", true},
+		{"missing key", data, "$.data.nope", 0, "", false},
+		{"index out of range", data, "$.data.items[2]", 0, "", false},
+		{"index of object", data, "$.data[0]", 0, "", false},
+		{"key of array", data, "$.data.items.name", 0, "", false},
+		{"invalid path", data, "$.data.", 0, "", false},
+		{"root scalar", "42", "$", KindNumber, "42", true},
+		{"truncated after value", `{"code": 0, "data": {"user_id": 12345, "items": [{"na`, "$.data.user_id", KindNumber, "12345", true},
+		{"truncated in value", `{"code": 0, "data": {"user_id": 123`, "$.data.user_id", 0, "", false},
+		{"truncated in string", `{"msg": "abc`, "$.msg", 0, "", false},
+		{"truncated in object", `{"data": {"a": 1`, "$.data", 0, "", false},
+		{"truncated before key", `{"code": 0, "da`, "$.data", 0, "", false},
+		{"not json", `<html>`, "$.code", 0, "", false},
+		{"missing colon", `{"code" 0}`, "$.code", 0, "", false},
+	}
+	for _, c := range cases {
+		v, found := Get([]byte(c.data), c.path)
+		if found != c.found {
+			t.Errorf("%s: got found %v, want %v", c.name, found, c.found)
+			continue
+		}
+		if found && (v.Kind != c.kind || v.String() != c.value) {
+			t.Errorf("%s: got %d %q, want %d %q", c.name, v.Kind, v.String(), c.kind, c.value)
+		}
+	}
+}
+
+func TestValueConvert(t *testing.T) {
+	cases := []struct {
+		data  string
+		typ   string
+		value string
+		ok    bool
+	}{
+		{`1`, TYPE_INT, "1", true},
+		{`"42"`, TYPE_INT, "42", true},
+		{`3.0`, TYPE_INT, "3", true},
+		{`3.5`, TYPE_INT, "", false},
+		{`true`, TYPE_INT, "", false},
+		{`"1.5"`, TYPE_FLOAT, "1.5", true},
+		{`1e3`, TYPE_FLOAT, "1000", true},
+		{`"x"`, TYPE_FLOAT, "", false},
+		{`false`, TYPE_BOOL, "false", true},
+		{`"true"`, TYPE_BOOL, "true", true},
+		{`1`, TYPE_BOOL, "", false},
+		{`{"a": [1]}`, TYPE_RAW, `{"a": [1]}`, true},
+		{`"a\nb"`, TYPE_STRING, "a\nb", true},
+		{`12`, TYPE_STRING, "12", true},
+		{`null`, TYPE_STRING, "", false},
+	}
+	for _, c := range cases {
+		v, _ := Get([]byte(c.data), "$")
+		value, ok := convert(v, c.typ)
+		if ok != c.ok || (ok && value != c.value) {
+			t.Errorf("%s as %s: got %q %v, want %q %v", c.data, c.typ, value, ok, c.value, c.ok)
+		}
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	text := `
+# comment
+$.data.user_id -> attr user_id int
+$.data.name    -> attr name default=anonymous user
+$['default=x'] -> attr x
+$.code         -> resp_code default=0
+`
+	rules, err := ParseRules(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Rule{
+		{Path: "$.data.user_id", Target: TARGET_ATTR, Key: "user_id", Type: TYPE_INT},
+		{Path: "$.data.name", Target: TARGET_ATTR, Key: "name", Default: "anonymous user"},
+		{Path: "$['default=x']", Target: TARGET_ATTR, Key: "x"},
+		{Path: "$.code", Target: TARGET_RESP_CODE, Default: "0"},
+	}
+	if len(rules) != len(want) {
+		t.Fatalf("got %d rules, want %d", len(rules), len(want))
+	}
+	for i := range want {
+		if rules[i] != want[i] {
+			t.Errorf("rule %d: got %+v, want %+v", i, rules[i], want[i])
+		}
+	}
+
+	for _, text := range []string{
+		"$.a attr",
+		"$.a => attr",
+		"$.a -> attr key extra",
+		"$.a -> result key",
+		"$.a -> exception int float",
+	} {
+		if _, err := ParseRules(text); err == nil {
+			t.Errorf("%q: no error", text)
+		}
+	}
+	for _, text := range []string{
+		"$.a. -> attr",
+		"$.a -> unknown",
+		"$ -> attr",
+		"$[0] -> attr",
+	} {
+		if _, err := Compile(text); err == nil {
+			t.Errorf("%q: no error", text)
+		}
+	}
+	if _, err := NewExtractor([]Rule{{Path: "$.a", Target: TARGET_ATTR, Type: "uint"}}); err == nil {
+		t.Error("unknown type: no error")
+	}
+}
+
+func TestFill(t *testing.T) {
+	e, err := Compile(`
+$.data.user_id  -> attr uid int
+$.data.vip      -> attr vip bool default=false
+$.data.score    -> attr score float default=0
+$.data.name     -> attr name default=anonymous
+$.data.profile  -> attr profile raw
+$.code          -> resp_code
+$.msg           -> exception
+$.status        -> result
+$.biz           -> biz_code
+$.trace.id      -> trace_id
+$.trace.span    -> span_id
+$.missing       -> attr missing
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &sdk.L7ProtocolInfo{}
+	data := `{"code": "503", "msg": "busy", "status": "fail", "biz": 7, "trace": {"id": "t1", "span": "s1"},
+		"data": {"user_id": "12", "vip": "yes", "score": 9.5, "profile": {"a": 1}}}`
+	if n := e.Fill(info, []byte(data)); n != 11 {
+		t.Errorf("got %d rules applied, want 11", n)
+	}
+	kv := map[string]string{}
+	for _, v := range info.Kv {
+		kv[v.Key] = v.Val
+	}
+	want := map[string]string{"uid": "12", "vip": "false", "score": "9.5", "name": "anonymous", "profile": `{"a": 1}`}
+	if len(kv) != len(want) {
+		t.Errorf("got attributes %v, want %v", kv, want)
+	}
+	for k, v := range want {
+		if kv[k] != v {
+			t.Errorf("attribute %s: got %q, want %q", k, kv[k], v)
+		}
+	}
+	if info.Resp == nil || info.Resp.Code == nil || *info.Resp.Code != 503 || info.Resp.Exception != "busy" ||
+		info.Resp.Result != "fail" || info.BizCode != "7" || info.Trace == nil ||
+		info.Trace.TraceID != "t1" || info.Trace.SpanID != "s1" {
+		t.Errorf("unexpected info %+v resp %+v trace %+v", info, info.Resp, info.Trace)
+	}
+
+	// the code which is not int32 is not applied
+	info = &sdk.L7ProtocolInfo{}
+	e, _ = Compile("$.code -> resp_code")
+	if n := e.Fill(info, []byte(`{"code": 99999999999}`)); n != 0 || info.Resp != nil {
+		t.Errorf("got %d rules applied, resp %+v", n, info.Resp)
+	}
+}
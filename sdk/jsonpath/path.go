@@ -0,0 +1,136 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Package jsonpath extract the values from the json body by path, and fill them into L7ProtocolInfo by rules. the json is
+scanned without building the tree and the scan stop at the value found, so the value before the truncation of a
+partial json body can still be found:
+
+	{"code": 0, "data": {"user_id": 12345, "items": [{"na
+
+the path is a subset of JSONPath, such as $.data.user_id, $.data.items[0].name and $['key.with.dot'], the leading $ is
+optional.
+*/
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidPath = errors.New("jsonpath: invalid path")
+
+type Segment struct {
+	Key string
+	// the index of array if IsIndex
+	Index   int
+	IsIndex bool
+}
+
+type Path []Segment
+
+func ParsePath(s string) (Path, error) {
+	var path Path
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "$")
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '.':
+			i++
+			end := i
+			for end < len(s) && s[end] != '.' && s[end] != '[' {
+				end++
+			}
+			if end == i {
+				return nil, fmt.Errorf("%w: empty key in %q", ErrInvalidPath, s)
+			}
+			path = append(path, Segment{Key: s[i:end]})
+			i = end
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unclosed bracket in %q", ErrInvalidPath, s)
+			}
+			inner := s[i+1 : i+end]
+			i += end + 1
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				path = append(path, Segment{Key: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("%w: bad index %q", ErrInvalidPath, inner)
+			}
+			path = append(path, Segment{Index: index, IsIndex: true})
+		default:
+			if i > 0 {
+				return nil, fmt.Errorf("%w: unexpected %q in %q", ErrInvalidPath, s[i], s)
+			}
+			// the path without leading $ and dot such as data.user_id
+			s = "." + s
+		}
+	}
+	return path, nil
+}
+
+func (p Path) String() string {
+	var b strings.Builder
+	b.WriteByte('$')
+	for _, seg := range p {
+		switch {
+		case seg.IsIndex:
+			b.WriteByte('[')
+			b.WriteString(strconv.Itoa(seg.Index))
+			b.WriteByte(']')
+		case strings.ContainsAny(seg.Key, ".[]"):
+			b.WriteString("['")
+			b.WriteString(seg.Key)
+			b.WriteString("']")
+		default:
+			b.WriteByte('.')
+			b.WriteString(seg.Key)
+		}
+	}
+	return b.String()
+}
+
+// Get the value of the path string, the invalid path is not found
+func Get(data []byte, path string) (Value, bool) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return Value{}, false
+	}
+	return Lookup(data, p)
+}
+
+// Lookup the value of path, the value is not found if the json is invalid or truncated before the value end
+func Lookup(data []byte, path Path) (Value, bool) {
+	s := &scanner{data: data}
+	for _, seg := range path {
+		var ok bool
+		if seg.IsIndex {
+			ok = s.enterArray(seg.Index)
+		} else {
+			ok = s.enterObject(seg.Key)
+		}
+		if !ok {
+			return Value{}, false
+		}
+	}
+	return s.value(len(path) == 0)
+}
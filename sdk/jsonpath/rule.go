@@ -0,0 +1,251 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsonpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+)
+
+// the targets of L7ProtocolInfo filled by the rule
+const (
+	TARGET_ATTR      = "attr"
+	TARGET_RESP_CODE = "resp_code"
+	TARGET_EXCEPTION = "exception"
+	TARGET_RESULT    = "result"
+	TARGET_BIZ_CODE  = "biz_code"
+	TARGET_TRACE_ID  = "trace_id"
+	TARGET_SPAN_ID   = "span_id"
+)
+
+// the conversions of the value, the string of json is unescaped and the others keep the json text
+const (
+	TYPE_STRING = "string"
+	TYPE_INT    = "int"
+	TYPE_FLOAT  = "float"
+	TYPE_BOOL   = "bool"
+	// the json text of the value, such as the whole object
+	TYPE_RAW = "raw"
+)
+
+// Rule can be embedded in the plugin configuration by the json tags, or written in text, see ParseRules
+type Rule struct {
+	Path   string `json:"path"`
+	Target string `json:"target"`
+	// the attribute key, the last key of path by default
+	Key string `json:"key,omitempty"`
+	// TYPE_STRING by default
+	Type string `json:"type,omitempty"`
+	// used when the path is not found or the conversion fail, empty indicate skip the rule
+	Default string `json:"default,omitempty"`
+}
+
+type rule struct {
+	Rule
+	path Path
+}
+
+type Extractor struct {
+	rules []rule
+}
+
+func NewExtractor(rules []Rule) (*Extractor, error) {
+	e := &Extractor{}
+	for _, r := range rules {
+		path, err := ParsePath(r.Path)
+		if err != nil {
+			return nil, err
+		}
+		switch r.Target {
+		case TARGET_ATTR:
+			if r.Key == "" {
+				for i := len(path) - 1; i >= 0 && r.Key == ""; i-- {
+					r.Key = path[i].Key
+				}
+				if r.Key == "" {
+					return nil, fmt.Errorf("jsonpath: no attribute key for %s", r.Path)
+				}
+			}
+		case TARGET_RESP_CODE:
+			if r.Type == "" {
+				r.Type = TYPE_INT
+			}
+		case TARGET_EXCEPTION, TARGET_RESULT, TARGET_BIZ_CODE, TARGET_TRACE_ID, TARGET_SPAN_ID:
+		default:
+			return nil, fmt.Errorf("jsonpath: unknown target %q", r.Target)
+		}
+		switch r.Type {
+		case "":
+			r.Type = TYPE_STRING
+		case TYPE_STRING, TYPE_INT, TYPE_FLOAT, TYPE_BOOL, TYPE_RAW:
+		default:
+			return nil, fmt.Errorf("jsonpath: unknown type %q", r.Type)
+		}
+		e.rules = append(e.rules, rule{Rule: r, path: path})
+	}
+	return e, nil
+}
+
+/*
+ParseRules parse the rules in text, one rule per line and the line start with # is comment:
+
+	<path> -> <target> [key] [type] [default=<value>]
+
+such as:
+
+	$.data.user_id -> attr user_id int
+	$.data.name    -> attr name default=anonymous
+	$.code         -> resp_code
+	$.msg          -> exception
+
+the default is the rest of the line after "default=" following the target.
+*/
+func ParseRules(text string) ([]Rule, error) {
+	var rules []Rule
+	for n, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[1] != "->" {
+			return nil, fmt.Errorf("jsonpath: bad rule at line %d: %s", n+1, line)
+		}
+		r := Rule{Path: fields[0], Target: fields[2]}
+		// the path may contain "default=", so search it after the target only
+		rest := strings.TrimSpace(line[len(fields[0]):])
+		rest = strings.TrimSpace(rest[len("->"):])
+		rest = rest[len(fields[2]):]
+		if i := strings.Index(rest, "default="); i >= 0 {
+			r.Default = strings.TrimSpace(rest[i+len("default="):])
+			rest = rest[:i]
+		}
+		for _, f := range strings.Fields(rest) {
+			switch {
+			case isType(f) && r.Type == "":
+				r.Type = f
+			case r.Target == TARGET_ATTR && r.Key == "":
+				r.Key = f
+			default:
+				return nil, fmt.Errorf("jsonpath: unexpected %q at line %d", f, n+1)
+			}
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// Compile the rules in text, see ParseRules for the format
+func Compile(text string) (*Extractor, error) {
+	rules, err := ParseRules(text)
+	if err != nil {
+		return nil, err
+	}
+	return NewExtractor(rules)
+}
+
+func isType(s string) bool {
+	switch s {
+	case TYPE_STRING, TYPE_INT, TYPE_FLOAT, TYPE_BOOL, TYPE_RAW:
+		return true
+	}
+	return false
+}
+
+// Fill the info by the rules, return the number of rules applied
+func (e *Extractor) Fill(info *sdk.L7ProtocolInfo, data []byte) int {
+	applied := 0
+	for i := range e.rules {
+		r := &e.rules[i]
+		v, ok := "", false
+		if value, found := Lookup(data, r.path); found {
+			v, ok = convert(value, r.Type)
+		}
+		if !ok {
+			if r.Default == "" {
+				continue
+			}
+			v = r.Default
+		}
+		if r.apply(info, v) {
+			applied++
+		}
+	}
+	return applied
+}
+
+func convert(v Value, typ string) (string, bool) {
+	switch typ {
+	case TYPE_INT:
+		i, ok := v.Int()
+		return strconv.FormatInt(i, 10), ok
+	case TYPE_FLOAT:
+		f, ok := v.Float()
+		return strconv.FormatFloat(f, 'g', -1, 64), ok
+	case TYPE_BOOL:
+		b, ok := v.Bool()
+		return strconv.FormatBool(b), ok
+	case TYPE_RAW:
+		return string(v.Raw), true
+	}
+	if v.Kind == KindNull {
+		return "", false
+	}
+	return v.String(), true
+}
+
+func (r *rule) apply(info *sdk.L7ProtocolInfo, v string) bool {
+	switch r.Target {
+	case TARGET_ATTR:
+		info.Kv = append(info.Kv, sdk.KeyVal{Key: r.Key, Val: v})
+	case TARGET_RESP_CODE:
+		code, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return false
+		}
+		c := int32(code)
+		resp(info).Code = &c
+	case TARGET_EXCEPTION:
+		resp(info).Exception = v
+	case TARGET_RESULT:
+		resp(info).Result = v
+	case TARGET_BIZ_CODE:
+		info.BizCode = v
+	case TARGET_TRACE_ID:
+		trace(info).TraceID = v
+	case TARGET_SPAN_ID:
+		trace(info).SpanID = v
+	}
+	return true
+}
+
+func resp(info *sdk.L7ProtocolInfo) *sdk.Response {
+	if info.Resp == nil {
+		info.Resp = &sdk.Response{}
+	}
+	return info.Resp
+}
+
+func trace(info *sdk.L7ProtocolInfo) *sdk.Trace {
+	if info.Trace == nil {
+		info.Trace = &sdk.Trace{}
+	}
+	return info.Trace
+}
@@ -0,0 +1,318 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsonpath
+
+import (
+	"bytes"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type Kind uint8
+
+const (
+	KindNull Kind = iota
+	KindString
+	KindNumber
+	KindBool
+	KindObject
+	KindArray
+)
+
+type Value struct {
+	Kind Kind
+	// the raw json text of the value, which is a sub slice of data
+	Raw []byte
+}
+
+// String return the unescaped string for KindString, and the raw json text for the others
+func (v Value) String() string {
+	if v.Kind == KindString {
+		return unescape(v.Raw[1 : len(v.Raw)-1])
+	}
+	return string(v.Raw)
+}
+
+// Int accept the integer number, the float number without fraction and the string of them
+func (v Value) Int() (int64, bool) {
+	if v.Kind != KindNumber && v.Kind != KindString {
+		return 0, false
+	}
+	text := v.String()
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return i, true
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil || f != float64(int64(f)) {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+func (v Value) Float() (float64, bool) {
+	if v.Kind != KindNumber && v.Kind != KindString {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(v.String(), 64)
+	return f, err == nil
+}
+
+func (v Value) Bool() (bool, bool) {
+	if v.Kind != KindBool && v.Kind != KindString {
+		return false, false
+	}
+	b, err := strconv.ParseBool(v.String())
+	return b, err == nil
+}
+
+type scanner struct {
+	data []byte
+	pos  int
+}
+
+func (s *scanner) skipSpace() {
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\r', '\n':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+// consume c after the spaces
+func (s *scanner) consume(c byte) bool {
+	s.skipSpace()
+	if s.pos < len(s.data) && s.data[s.pos] == c {
+		s.pos++
+		return true
+	}
+	return false
+}
+
+// enterObject move to the value of key in the object
+func (s *scanner) enterObject(key string) bool {
+	if !s.consume('{') {
+		return false
+	}
+	for {
+		s.skipSpace()
+		start := s.pos
+		if !s.skipString() {
+			return false
+		}
+		raw := s.data[start+1 : s.pos-1]
+		if !s.consume(':') {
+			return false
+		}
+		if keyEqual(raw, key) {
+			return true
+		}
+		if !s.skipValue() {
+			return false
+		}
+		if !s.consume(',') {
+			return false
+		}
+	}
+}
+
+// enterArray move to the element of index in the array
+func (s *scanner) enterArray(index int) bool {
+	if !s.consume('[') {
+		return false
+	}
+	for i := 0; ; i++ {
+		s.skipSpace()
+		if s.pos >= len(s.data) || s.data[s.pos] == ']' {
+			return false
+		}
+		if i == index {
+			return true
+		}
+		if !s.skipValue() || !s.consume(',') {
+			return false
+		}
+	}
+}
+
+// value read the value at pos, the scalar end with the data is complete only if it is the root
+func (s *scanner) value(root bool) (Value, bool) {
+	s.skipSpace()
+	if s.pos >= len(s.data) {
+		return Value{}, false
+	}
+	start := s.pos
+	var kind Kind
+	switch c := s.data[s.pos]; {
+	case c == '"':
+		kind = KindString
+	case c == '{':
+		kind = KindObject
+	case c == '[':
+		kind = KindArray
+	case c == 't' || c == 'f':
+		kind = KindBool
+	case c == 'n':
+		kind = KindNull
+	default:
+		kind = KindNumber
+	}
+	if !s.skipValue() {
+		return Value{}, false
+	}
+	if (kind == KindNumber || kind == KindBool || kind == KindNull) && s.pos >= len(s.data) && !root {
+		// the number may be truncated
+		return Value{}, false
+	}
+	return Value{Kind: kind, Raw: s.data[start:s.pos]}, true
+}
+
+// skipValue return false if the value is invalid or truncated
+func (s *scanner) skipValue() bool {
+	s.skipSpace()
+	if s.pos >= len(s.data) {
+		return false
+	}
+	switch c := s.data[s.pos]; c {
+	case '"':
+		return s.skipString()
+	case '{', '[':
+		return s.skipContainer()
+	default:
+		start := s.pos
+		for s.pos < len(s.data) && !isDelimiter(s.data[s.pos]) {
+			s.pos++
+		}
+		return s.pos > start
+	}
+}
+
+func (s *scanner) skipString() bool {
+	if s.pos >= len(s.data) || s.data[s.pos] != '"' {
+		return false
+	}
+	for i := s.pos + 1; i < len(s.data); i++ {
+		switch s.data[i] {
+		case '\\':
+			i++
+		case '"':
+			s.pos = i + 1
+			return true
+		}
+	}
+	return false
+}
+
+// skip the object or array by counting the brackets outside the strings
+func (s *scanner) skipContainer() bool {
+	depth := 0
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case '"':
+			if !s.skipString() {
+				return false
+			}
+			continue
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth--; depth == 0 {
+				s.pos++
+				return true
+			}
+		}
+		s.pos++
+	}
+	return false
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case ',', '}', ']', ' ', '\t', '\r', '\n':
+		return true
+	}
+	return false
+}
+
+func keyEqual(raw []byte, key string) bool {
+	if bytes.IndexByte(raw, '\\') < 0 {
+		return string(raw) == key
+	}
+	return unescape(raw) == key
+}
+
+// unescape the content of json string, the invalid escape is kept as is
+func unescape(raw []byte) string {
+	if bytes.IndexByte(raw, '\\') < 0 {
+		return string(raw)
+	}
+	b := make([]byte, 0, len(raw))
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		if c != '\\' || i+1 >= len(raw) {
+			b = append(b, c)
+			continue
+		}
+		i++
+		switch raw[i] {
+		case 'n':
+			b = append(b, '\n')
+		case 'r':
+			b = append(b, '\r')
+		case 't':
+			b = append(b, '\t')
+		case 'b':
+			b = append(b, '\b')
+		case 'f':
+			b = append(b, '\f')
+		case 'u':
+			r, n := decodeUnicode(raw[i+1:])
+			if n == 0 {
+				b = append(b, '\\', 'u')
+				continue
+			}
+			b = utf8.AppendRune(b, r)
+			i += n
+		default:
+			// \" \\ \/
+			b = append(b, raw[i])
+		}
+	}
+	return string(b)
+}
+
+// decode XXXX or XXXX\uXXXX of the surrogate pair after \u, n is the size consumed
+func decodeUnicode(b []byte) (rune, int) {
+	if len(b) < 4 {
+		return 0, 0
+	}
+	r1, err := strconv.ParseUint(string(b[:4]), 16, 16)
+	if err != nil {
+		return 0, 0
+	}
+	if utf16.IsSurrogate(rune(r1)) && len(b) >= 10 && b[4] == '\\' && b[5] == 'u' {
+		if r2, err := strconv.ParseUint(string(b[6:10]), 16, 16); err == nil {
+			if r := utf16.DecodeRune(rune(r1), rune(r2)); r != utf8.RuneError {
+				return r, 10
+			}
+		}
+	}
+	return rune(r1), 4
+}
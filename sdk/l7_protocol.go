@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+import "strconv"
+
+type L7Protocol uint8
+
+// correspond agent enum L7Protocol, the number of the protocol returned by Parser.OnCheckPayload is plugin defined.
+const (
+	L7ProtocolUnknown L7Protocol = 0
+
+	// HTTP
+	L7ProtocolHttp1 L7Protocol = 20
+	L7ProtocolHttp2 L7Protocol = 21
+
+	// RPC
+	L7ProtocolDubbo   L7Protocol = 40
+	L7ProtocolGrpc    L7Protocol = 41
+	L7ProtocolSofaRPC L7Protocol = 43
+	L7ProtocolFastCGI L7Protocol = 44
+	L7ProtocolBrpc    L7Protocol = 45
+	L7ProtocolTars    L7Protocol = 46
+	L7ProtocolSomeIp  L7Protocol = 47
+
+	// SQL
+	L7ProtocolMySQL      L7Protocol = 60
+	L7ProtocolPostgreSQL L7Protocol = 61
+	L7ProtocolOracle     L7Protocol = 62
+
+	// NoSQL
+	L7ProtocolRedis     L7Protocol = 80
+	L7ProtocolMongoDB   L7Protocol = 81
+	L7ProtocolMemcached L7Protocol = 82
+
+	// MQ
+	L7ProtocolKafka    L7Protocol = 100
+	L7ProtocolMQTT     L7Protocol = 101
+	L7ProtocolAMQP     L7Protocol = 102
+	L7ProtocolOpenWire L7Protocol = 103
+	L7ProtocolNATS     L7Protocol = 104
+	L7ProtocolPulsar   L7Protocol = 105
+	L7ProtocolZMTP     L7Protocol = 106
+	L7ProtocolRocketMQ L7Protocol = 107
+
+	// INFRA
+	L7ProtocolDNS  L7Protocol = 120
+	L7ProtocolTLS  L7Protocol = 121
+	L7ProtocolPing L7Protocol = 122
+
+	L7ProtocolCustom L7Protocol = 127
+)
+
+var l7ProtocolNames = map[L7Protocol]string{
+	L7ProtocolUnknown:    "Unknown",
+	L7ProtocolHttp1:      "HTTP",
+	L7ProtocolHttp2:      "HTTP2",
+	L7ProtocolDubbo:      "Dubbo",
+	L7ProtocolGrpc:       "gRPC",
+	L7ProtocolSofaRPC:    "SofaRPC",
+	L7ProtocolFastCGI:    "FastCGI",
+	L7ProtocolBrpc:       "bRPC",
+	L7ProtocolTars:       "Tars",
+	L7ProtocolSomeIp:     "SOME/IP",
+	L7ProtocolMySQL:      "MySQL",
+	L7ProtocolPostgreSQL: "PostgreSQL",
+	L7ProtocolOracle:     "Oracle",
+	L7ProtocolRedis:      "Redis",
+	L7ProtocolMongoDB:    "MongoDB",
+	L7ProtocolMemcached:  "Memcached",
+	L7ProtocolKafka:      "Kafka",
+	L7ProtocolMQTT:       "MQTT",
+	L7ProtocolAMQP:       "AMQP",
+	L7ProtocolOpenWire:   "OpenWire",
+	L7ProtocolNATS:       "NATS",
+	L7ProtocolPulsar:     "Pulsar",
+	L7ProtocolZMTP:       "ZMTP",
+	L7ProtocolRocketMQ:   "RocketMQ",
+	L7ProtocolDNS:        "DNS",
+	L7ProtocolTLS:        "TLS",
+	L7ProtocolPing:       "Ping",
+	L7ProtocolCustom:     "Custom",
+}
+
+func (p L7Protocol) String() string {
+	if s, ok := l7ProtocolNames[p]; ok {
+		return s
+	}
+	return "L7Protocol(" + strconv.Itoa(int(p)) + ")"
+}
+
+func (p L7Protocol) IsHttp() bool {
+	return p == L7ProtocolHttp1 || p == L7ProtocolHttp2
+}
+
+func (p L7Protocol) IsRpc() bool {
+	switch p {
+	case L7ProtocolDubbo, L7ProtocolGrpc, L7ProtocolSofaRPC, L7ProtocolFastCGI,
+		L7ProtocolBrpc, L7ProtocolTars, L7ProtocolSomeIp:
+		return true
+	}
+	return false
+}
+
+func (p L7Protocol) IsSQL() bool {
+	switch p {
+	case L7ProtocolMySQL, L7ProtocolPostgreSQL, L7ProtocolOracle:
+		return true
+	}
+	return false
+}
+
+// include both sql and nosql
+func (p L7Protocol) IsDB() bool {
+	switch p {
+	case L7ProtocolRedis, L7ProtocolMongoDB, L7ProtocolMemcached:
+		return true
+	}
+	return p.IsSQL()
+}
+
+func (p L7Protocol) IsMQ() bool {
+	switch p {
+	case L7ProtocolKafka, L7ProtocolMQTT, L7ProtocolAMQP, L7ProtocolOpenWire,
+		L7ProtocolNATS, L7ProtocolPulsar, L7ProtocolZMTP, L7ProtocolRocketMQ:
+		return true
+	}
+	return false
+}
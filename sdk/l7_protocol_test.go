@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+import "testing"
+
+func TestL7Protocol(t *testing.T) {
+	cases := []struct {
+		protocol L7Protocol
+		name     string
+		http     bool
+		rpc      bool
+		sql      bool
+		db       bool
+		mq       bool
+	}{
+		{L7ProtocolUnknown, "Unknown", false, false, false, false, false},
+		{L7ProtocolHttp1, "HTTP", true, false, false, false, false},
+		{L7ProtocolHttp2, "HTTP2", true, false, false, false, false},
+		{L7ProtocolDubbo, "Dubbo", false, true, false, false, false},
+		{L7ProtocolGrpc, "gRPC", false, true, false, false, false},
+		{L7ProtocolSofaRPC, "SofaRPC", false, true, false, false, false},
+		{L7ProtocolFastCGI, "FastCGI", false, true, false, false, false},
+		{L7ProtocolBrpc, "bRPC", false, true, false, false, false},
+		{L7ProtocolTars, "Tars", false, true, false, false, false},
+		{L7ProtocolSomeIp, "SOME/IP", false, true, false, false, false},
+		{L7ProtocolMySQL, "MySQL", false, false, true, true, false},
+		{L7ProtocolPostgreSQL, "PostgreSQL", false, false, true, true, false},
+		{L7ProtocolOracle, "Oracle", false, false, true, true, false},
+		{L7ProtocolRedis, "Redis", false, false, false, true, false},
+		{L7ProtocolMongoDB, "MongoDB", false, false, false, true, false},
+		{L7ProtocolMemcached, "Memcached", false, false, false, true, false},
+		{L7ProtocolKafka, "Kafka", false, false, false, false, true},
+		{L7ProtocolMQTT, "MQTT", false, false, false, false, true},
+		{L7ProtocolAMQP, "AMQP", false, false, false, false, true},
+		{L7ProtocolOpenWire, "OpenWire", false, false, false, false, true},
+		{L7ProtocolNATS, "NATS", false, false, false, false, true},
+		{L7ProtocolPulsar, "Pulsar", false, false, false, false, true},
+		{L7ProtocolZMTP, "ZMTP", false, false, false, false, true},
+		{L7ProtocolRocketMQ, "RocketMQ", false, false, false, false, true},
+		{L7ProtocolDNS, "DNS", false, false, false, false, false},
+		{L7ProtocolTLS, "TLS", false, false, false, false, false},
+		{L7ProtocolPing, "Ping", false, false, false, false, false},
+		{L7ProtocolCustom, "Custom", false, false, false, false, false},
+		{42, "L7Protocol(42)", false, false, false, false, false},
+		{255, "L7Protocol(255)", false, false, false, false, false},
+	}
+	for _, c := range cases {
+		p := c.protocol
+		if p.String() != c.name || p.IsHttp() != c.http || p.IsRpc() != c.rpc || p.IsSQL() != c.sql ||
+			p.IsDB() != c.db || p.IsMQ() != c.mq {
+			t.Errorf("%d: got %q http %v rpc %v sql %v db %v mq %v", p, p.String(), p.IsHttp(), p.IsRpc(), p.IsSQL(),
+				p.IsDB(), p.IsMQ())
+		}
+	}
+	// every named protocol is covered
+	if named := len(l7ProtocolNames); named != len(cases)-2 {
+		t.Errorf("got %d named protocols, %d are tested", named, len(cases)-2)
+	}
+}
@@ -24,6 +24,24 @@ var (
 	vmParser Parser
 )
 
+/*
+the abi version the sdk supported, the agent only send the data the plugin can understand:
+
+	1: initial version
+	2: ParseCtx carry the extend ebpf context, such as pid, tid, socket id and tcp seq
+	3: the result of the SessionFilter and Sampling hook points is written by host_read_str_result, the agent
+	   older than this version never call the plugin with these hook points
+
+no new host function is imported by the newer version, so the plugin can be instantiated by any agent.
+*/
+const (
+	ABI_VERSION_INITIAL       uint32 = 1
+	ABI_VERSION_EXT_CTX       uint32 = 2
+	ABI_VERSION_CUSTOM_RESULT uint32 = 3
+
+	ABI_VERSION = ABI_VERSION_CUSTOM_RESULT
+)
+
 func SetParser(p Parser) {
 	vmParser = p
 }
@@ -52,11 +70,13 @@ var (
 	HOOK_POINT_PAYLOAD_PARSE HookBitmap = [2]uint64{0, 1}
 )
 
-var (
-	PROTOCOL_DUBBO uint16 = 40
-	PROTOCOL_NATS  uint16 = 104
-	PROTOCOL_ZMTP  uint16 = 106
+const (
+	PROTOCOL_DUBBO = uint16(L7ProtocolDubbo)
+	PROTOCOL_NATS  = uint16(L7ProtocolNATS)
+	PROTOCOL_ZMTP  = uint16(L7ProtocolZMTP)
+)
 
+var (
 	REQUEST  uint8 = 1
 	RESPONSE uint8 = 2
 )
@@ -64,7 +84,11 @@ var (
 var CUSTOM_MESSAGE_HOOK_ALL uint64 = 0xff << 48
 
 func CustomMessageHookProtocol(protocol uint16, isRequest bool) uint64 {
-	hookPoint := ProtocolParse
+	return CustomMessageHook(ProtocolParse, protocol, isRequest)
+}
+
+// hookPoint is one of ProtocolParse, SessionFilter and Sampling
+func CustomMessageHook(hookPoint uint16, protocol uint16, isRequest bool) uint64 {
 	var typeCode uint64
 	if isRequest {
 		typeCode = uint64(protocol)
@@ -0,0 +1,213 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.5
+// 	protoc        v3.21.6
+// source: deepflow/options.proto
+
+package deepflow
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// the columns of L7ProtocolInfo filled by the field
+type Columns struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Trace.TraceID
+	TraceId *bool `protobuf:"varint,1,opt,name=trace_id,json=traceId" json:"trace_id,omitempty"`
+	// Trace.SpanID
+	SpanId *bool `protobuf:"varint,2,opt,name=span_id,json=spanId" json:"span_id,omitempty"`
+	// append to Kv with the name, empty name indicate use the field name
+	Attr *string `protobuf:"bytes,3,opt,name=attr" json:"attr,omitempty"`
+	// L7ProtocolInfo.BizCode
+	BizCode *bool `protobuf:"varint,4,opt,name=biz_code,json=bizCode" json:"biz_code,omitempty"`
+	// Response.Code, the field must be an integer or a string of integer
+	RespCode *bool `protobuf:"varint,5,opt,name=resp_code,json=respCode" json:"resp_code,omitempty"`
+	// Response.Exception
+	Exception     *bool `protobuf:"varint,6,opt,name=exception" json:"exception,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Columns) Reset() {
+	*x = Columns{}
+	mi := &file_deepflow_options_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Columns) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Columns) ProtoMessage() {}
+
+func (x *Columns) ProtoReflect() protoreflect.Message {
+	mi := &file_deepflow_options_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Columns.ProtoReflect.Descriptor instead.
+func (*Columns) Descriptor() ([]byte, []int) {
+	return file_deepflow_options_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Columns) GetTraceId() bool {
+	if x != nil && x.TraceId != nil {
+		return *x.TraceId
+	}
+	return false
+}
+
+func (x *Columns) GetSpanId() bool {
+	if x != nil && x.SpanId != nil {
+		return *x.SpanId
+	}
+	return false
+}
+
+func (x *Columns) GetAttr() string {
+	if x != nil && x.Attr != nil {
+		return *x.Attr
+	}
+	return ""
+}
+
+func (x *Columns) GetBizCode() bool {
+	if x != nil && x.BizCode != nil {
+		return *x.BizCode
+	}
+	return false
+}
+
+func (x *Columns) GetRespCode() bool {
+	if x != nil && x.RespCode != nil {
+		return *x.RespCode
+	}
+	return false
+}
+
+func (x *Columns) GetException() bool {
+	if x != nil && x.Exception != nil {
+		return *x.Exception
+	}
+	return false
+}
+
+var file_deepflow_options_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*Columns)(nil),
+		Field:         51001,
+		Name:          "deepflow.columns",
+		Tag:           "bytes,51001,opt,name=columns",
+		Filename:      "deepflow/options.proto",
+	},
+}
+
+// Extension fields to descriptorpb.FieldOptions.
+var (
+	// optional deepflow.Columns columns = 51001;
+	E_Columns = &file_deepflow_options_proto_extTypes[0]
+)
+
+var File_deepflow_options_proto protoreflect.FileDescriptor
+
+var file_deepflow_options_proto_rawDesc = string([]byte{
+	0x0a, 0x16, 0x64, 0x65, 0x65, 0x70, 0x66, 0x6c, 0x6f, 0x77, 0x2f, 0x6f, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x64, 0x65, 0x65, 0x70, 0x66, 0x6c,
+	0x6f, 0x77, 0x1a, 0x20, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x22, 0xa7, 0x01, 0x0a, 0x07, 0x43, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x73,
+	0x12, 0x19, 0x0a, 0x08, 0x74, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x07, 0x74, 0x72, 0x61, 0x63, 0x65, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x73,
+	0x70, 0x61, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x73, 0x70,
+	0x61, 0x6e, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x74, 0x74, 0x72, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x61, 0x74, 0x74, 0x72, 0x12, 0x19, 0x0a, 0x08, 0x62, 0x69, 0x7a, 0x5f,
+	0x63, 0x6f, 0x64, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x62, 0x69, 0x7a, 0x43,
+	0x6f, 0x64, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x72, 0x65, 0x73, 0x70, 0x5f, 0x63, 0x6f, 0x64, 0x65,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x72, 0x65, 0x73, 0x70, 0x43, 0x6f, 0x64, 0x65,
+	0x12, 0x1c, 0x0a, 0x09, 0x65, 0x78, 0x63, 0x65, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x09, 0x65, 0x78, 0x63, 0x65, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x3a, 0x4c,
+	0x0a, 0x07, 0x63, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x73, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c,
+	0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xb9, 0x8e, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x11, 0x2e, 0x64, 0x65, 0x65, 0x70, 0x66, 0x6c, 0x6f, 0x77, 0x2e, 0x43, 0x6f, 0x6c, 0x75,
+	0x6d, 0x6e, 0x73, 0x52, 0x07, 0x63, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x73, 0x42, 0x3f, 0x5a, 0x3d,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x64, 0x65, 0x65, 0x70, 0x66,
+	0x6c, 0x6f, 0x77, 0x69, 0x6f, 0x2f, 0x64, 0x65, 0x65, 0x70, 0x66, 0x6c, 0x6f, 0x77, 0x2d, 0x77,
+	0x61, 0x73, 0x6d, 0x2d, 0x67, 0x6f, 0x2d, 0x73, 0x64, 0x6b, 0x2f, 0x73, 0x64, 0x6b, 0x2f, 0x70,
+	0x62, 0x72, 0x70, 0x63, 0x2f, 0x64, 0x65, 0x65, 0x70, 0x66, 0x6c, 0x6f, 0x77, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x32,
+})
+
+var (
+	file_deepflow_options_proto_rawDescOnce sync.Once
+	file_deepflow_options_proto_rawDescData []byte
+)
+
+func file_deepflow_options_proto_rawDescGZIP() []byte {
+	file_deepflow_options_proto_rawDescOnce.Do(func() {
+		file_deepflow_options_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_deepflow_options_proto_rawDesc), len(file_deepflow_options_proto_rawDesc)))
+	})
+	return file_deepflow_options_proto_rawDescData
+}
+
+var file_deepflow_options_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_deepflow_options_proto_goTypes = []any{
+	(*Columns)(nil),                   // 0: deepflow.Columns
+	(*descriptorpb.FieldOptions)(nil), // 1: google.protobuf.FieldOptions
+}
+var file_deepflow_options_proto_depIdxs = []int32{
+	1, // 0: deepflow.columns:extendee -> google.protobuf.FieldOptions
+	0, // 1: deepflow.columns:type_name -> deepflow.Columns
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	1, // [1:2] is the sub-list for extension type_name
+	0, // [0:1] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_deepflow_options_proto_init() }
+func file_deepflow_options_proto_init() {
+	if File_deepflow_options_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_deepflow_options_proto_rawDesc), len(file_deepflow_options_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 1,
+			NumServices:   0,
+		},
+		GoTypes:           file_deepflow_options_proto_goTypes,
+		DependencyIndexes: file_deepflow_options_proto_depIdxs,
+		MessageInfos:      file_deepflow_options_proto_msgTypes,
+		ExtensionInfos:    file_deepflow_options_proto_extTypes,
+	}.Build()
+	File_deepflow_options_proto = out.File
+	file_deepflow_options_proto_goTypes = nil
+	file_deepflow_options_proto_depIdxs = nil
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pbrpc
+
+import (
+	"strconv"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+)
+
+// L7ProtocolInfoFiller is generated by protoc-gen-deepflow for the messages with the fields annotated by deepflow/options.proto
+type L7ProtocolInfoFiller interface {
+	FillL7ProtocolInfo(info *sdk.L7ProtocolInfo)
+}
+
+// Fill the info by the message if it implements L7ProtocolInfoFiller
+func Fill(info *sdk.L7ProtocolInfo, msg interface{}) {
+	if f, ok := msg.(L7ProtocolInfoFiller); ok {
+		f.FillL7ProtocolInfo(info)
+	}
+}
+
+// the helpers below are called by the generated FillL7ProtocolInfo, the empty value is ignored
+
+func SetTraceID(info *sdk.L7ProtocolInfo, v string) {
+	if v == "" {
+		return
+	}
+	if info.Trace == nil {
+		info.Trace = &sdk.Trace{}
+	}
+	info.Trace.TraceID = v
+}
+
+func SetSpanID(info *sdk.L7ProtocolInfo, v string) {
+	if v == "" {
+		return
+	}
+	if info.Trace == nil {
+		info.Trace = &sdk.Trace{}
+	}
+	info.Trace.SpanID = v
+}
+
+func AddAttr(info *sdk.L7ProtocolInfo, key, v string) {
+	if v == "" {
+		return
+	}
+	info.Kv = append(info.Kv, sdk.KeyVal{Key: key, Val: v})
+}
+
+func SetBizCode(info *sdk.L7ProtocolInfo, v string) {
+	if v == "" {
+		return
+	}
+	info.BizCode = v
+}
+
+func SetRespCode(info *sdk.L7ProtocolInfo, code int32) {
+	if info.Resp == nil {
+		info.Resp = &sdk.Response{}
+	}
+	info.Resp.Code = &code
+}
+
+// SetRespCodeString set the code parsed from v, v which is not an integer is ignored
+func SetRespCodeString(info *sdk.L7ProtocolInfo, v string) {
+	code, err := strconv.ParseInt(v, 10, 32)
+	if err != nil {
+		return
+	}
+	SetRespCode(info, int32(code))
+}
+
+func SetException(info *sdk.L7ProtocolInfo, v string) {
+	if v == "" {
+		return
+	}
+	if info.Resp == nil {
+		info.Resp = &sdk.Response{}
+	}
+	info.Resp.Exception = v
+}
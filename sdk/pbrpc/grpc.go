@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pbrpc
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/framing"
+)
+
+const GRPC_MESSAGE_HEADER_SIZE = 5
+
+var ErrGrpcCompressed = errors.New("pbrpc: compressed grpc message is not supported")
+
+/*
+the grpc messages in the http2 data frames, a streaming method may carry multi messages in one data frame:
+
+	compressed flag (1 byte)
+	message len (4 bytes, be)
+	message (message len bytes)
+*/
+var grpcFramer = &framing.LengthFieldFramer{
+	HeaderSize:   GRPC_MESSAGE_HEADER_SIZE,
+	LengthOffset: 1,
+	LengthSize:   4,
+}
+
+// SplitGrpcMessages return the complete grpc messages without the header, and the incomplete leftover
+func SplitGrpcMessages(data []byte) (messages [][]byte, leftover []byte, err error) {
+	frames, leftover, err := framing.Split(grpcFramer, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, f := range frames {
+		if f[0] != 0 {
+			return nil, nil, ErrGrpcCompressed
+		}
+		messages = append(messages, f[GRPC_MESSAGE_HEADER_SIZE:])
+	}
+	return messages, leftover, nil
+}
+
+// DecodeGrpc decode all the complete messages in the http2 data of the grpc path, the incomplete leftover is ignored
+func (r *Registry) DecodeGrpc(path string, isRequest bool, data []byte) (*Method, []Message, error) {
+	m, err := r.LookupGrpcPath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	frames, _, err := SplitGrpcMessages(data)
+	if err != nil {
+		return m, nil, err
+	}
+	messages := make([]Message, 0, len(frames))
+	for _, f := range frames {
+		msg, err := m.Decode(isRequest, f)
+		if err != nil {
+			return m, nil, err
+		}
+		messages = append(messages, msg)
+	}
+	return m, messages, nil
+}
+
+// DecodeGrpcJSON is like DecodeGrpc, return a json object for unary side and a json array for streaming side
+func (r *Registry) DecodeGrpcJSON(path string, isRequest bool, data []byte) ([]byte, error) {
+	m, messages, err := r.DecodeGrpc(path, isRequest, data)
+	if err != nil {
+		return nil, err
+	}
+	streaming := m.ServerStreaming
+	if isRequest {
+		streaming = m.ClientStreaming
+	}
+	if !streaming && len(messages) == 1 {
+		return json.Marshal(messages[0])
+	}
+	return json.Marshal(messages)
+}
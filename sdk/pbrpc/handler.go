@@ -0,0 +1,236 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pbrpc
+
+import (
+	"encoding/json"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+	sdkpb "github.com/deepflowio/deepflow-wasm-go-sdk/sdk/pb"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/pbwire"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const DEFAULT_NATS_MAX_PENDING = 4096
+
+/*
+NatsHandler decode the nrpc messages over nats, register it by sdk.SetHandler. the request is published to the
+subject Service.Method with a reply subject, and the response is published to the reply subject, so the handler
+remember the method of the pending requests by the reply subject.
+*/
+type NatsHandler struct {
+	Registry *Registry
+	// the max number of requests waiting for the response, the oldest one is dropped when exceed
+	MaxPending int
+	pending    map[string]*Method
+	// the reply subjects in the order of the requests
+	order []string
+}
+
+// nil registry indicate DefaultRegistry
+func NewNatsHandler(r *Registry) *NatsHandler {
+	if r == nil {
+		r = DefaultRegistry
+	}
+	return &NatsHandler{
+		Registry:   r,
+		MaxPending: DEFAULT_NATS_MAX_PENDING,
+		pending:    make(map[string]*Method),
+	}
+}
+
+func (h *NatsHandler) CustomMessageSubscriptions() []sdk.CustomMessageSubscription {
+	return []sdk.CustomMessageSubscription{
+		{Protocol: sdk.PROTOCOL_NATS, Directions: sdk.MessageRequest, HookPoint: sdk.ProtocolParse},
+	}
+}
+
+func (h *NatsHandler) addPending(replyTo string, m *Method) {
+	if _, ok := h.pending[replyTo]; !ok {
+		for len(h.order) > 0 && len(h.order) >= h.MaxPending {
+			delete(h.pending, h.order[0])
+			h.order = h.order[1:]
+		}
+		h.order = append(h.order, replyTo)
+	}
+	h.pending[replyTo] = m
+}
+
+func (h *NatsHandler) removePending(replyTo string) {
+	delete(h.pending, replyTo)
+	for i, s := range h.order {
+		if s == replyTo {
+			h.order = append(h.order[:i], h.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func (h *NatsHandler) OnNatsMessage(message *sdkpb.NatsMessage) sdk.Action {
+	var (
+		m         *Method
+		callID    string
+		isRequest = len(message.ReplyTo) > 0
+	)
+	if isRequest {
+		var err error
+		if m, err = h.Registry.LookupSubject(message.Subject); err != nil {
+			return sdk.ActionNext()
+		}
+		callID = message.ReplyTo
+		h.addPending(callID, m)
+	} else {
+		var ok bool
+		if m, ok = h.pending[message.Subject]; !ok {
+			return sdk.ActionNext()
+		}
+		callID = message.Subject
+		h.removePending(callID)
+	}
+
+	msg, err := m.Decode(isRequest, message.Payload)
+	if err != nil {
+		return sdk.ActionAbortWithErr(err)
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return sdk.ActionAbortWithErr(err)
+	}
+	info := &sdk.L7ProtocolInfo{
+		Req: &sdk.Request{
+			ReqType:  m.Name,
+			Resource: m.Service,
+			Endpoint: m.GrpcPath(),
+		},
+		Resp: &sdk.Response{},
+		Kv: []sdk.KeyVal{
+			{Key: "json_payload", Val: string(data)},
+			{Key: "call_id", Val: callID},
+			{Key: "rpc_type", Val: m.RpcType()},
+		},
+		L7ProtocolStr: "nRPC",
+	}
+	Fill(info, msg)
+	return sdk.ParseActionAbortWithL7Info([]*sdk.L7ProtocolInfo{info})
+}
+
+// Wrapper is a message with a oneof of the request and response messages, generated for the zmtp transport
+type Wrapper struct {
+	// the full name with the proto package
+	Name string
+	New  func() Message
+	// the field numbers of the oneof
+	Fields []int32
+}
+
+/*
+Match report whether the payload is the wrapper. the unmarshal accept the unknown fields, so the payload of the
+other message is decoded as an empty wrapper without error, Match require one of the oneof fields is set as a
+message and no other field is present. the wrapper without Fields match any payload.
+*/
+func (w *Wrapper) Match(payload []byte) bool {
+	if len(w.Fields) == 0 {
+		return true
+	}
+	set := false
+	for len(payload) > 0 {
+		num, typ, n := protowire.ConsumeTag(payload)
+		if n < 0 {
+			return false
+		}
+		payload = payload[n:]
+		if typ != protowire.BytesType || !w.isField(int32(num)) {
+			return false
+		}
+		if n = protowire.ConsumeFieldValue(num, typ, payload); n < 0 {
+			return false
+		}
+		payload = payload[n:]
+		set = true
+	}
+	return set
+}
+
+func (w *Wrapper) isField(num int32) bool {
+	for _, f := range w.Fields {
+		if f == num {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ZmtpHandler decode the payload of zmtp messages as the wrapper message, register it by sdk.SetHandler. the payload
+which is not the wrapper is decoded by pbwire without schema, nil wrapper indicate always decode without schema.
+*/
+type ZmtpHandler struct {
+	Wrapper       *Wrapper
+	L7ProtocolStr string
+}
+
+func NewZmtpHandler(w *Wrapper) *ZmtpHandler {
+	return &ZmtpHandler{
+		Wrapper:       w,
+		L7ProtocolStr: "Protobuf",
+	}
+}
+
+func (h *ZmtpHandler) CustomMessageSubscriptions() []sdk.CustomMessageSubscription {
+	return []sdk.CustomMessageSubscription{
+		{Protocol: sdk.PROTOCOL_ZMTP, Directions: sdk.MessageRequest, HookPoint: sdk.ProtocolParse},
+	}
+}
+
+func (h *ZmtpHandler) OnCustomMessage(ctx *sdk.CustomMessageCtx) sdk.Action {
+	if !ctx.CheckParseProtocol(sdk.PROTOCOL_ZMTP, true) {
+		return sdk.ActionNext()
+	}
+	var zmtpMsg sdkpb.ZmtpMessage
+	if err := zmtpMsg.UnmarshalVT(ctx.Payload); err != nil {
+		return sdk.ActionAbortWithErr(err)
+	}
+	var (
+		msg  Message
+		data []byte
+		err  error
+	)
+	isWrapper := h.Wrapper != nil && h.Wrapper.Match(zmtpMsg.Payload)
+	if isWrapper {
+		msg = h.Wrapper.New()
+		if err = msg.UnmarshalVT(zmtpMsg.Payload); err == nil {
+			data, err = json.Marshal(msg)
+		}
+	}
+	if !isWrapper || err != nil {
+		// the payload is not the wrapper, decode it without schema instead of dropping
+		msg = nil
+		if data, err = pbwire.DecodeJSON(zmtpMsg.Payload); err != nil {
+			return sdk.ActionAbortWithErr(err)
+		}
+	}
+	info := &sdk.L7ProtocolInfo{
+		Req:  &sdk.Request{},
+		Resp: &sdk.Response{},
+		Kv: []sdk.KeyVal{
+			{Key: "json_payload", Val: string(data)},
+		},
+		L7ProtocolStr: h.L7ProtocolStr,
+	}
+	Fill(info, msg)
+	return sdk.ParseActionAbortWithL7Info([]*sdk.L7ProtocolInfo{info})
+}
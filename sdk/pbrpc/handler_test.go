@@ -0,0 +1,56 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pbrpc
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestWrapperMatch(t *testing.T) {
+	message := func(num protowire.Number, typ protowire.Type, v []byte) []byte {
+		b := protowire.AppendTag(nil, num, typ)
+		if typ == protowire.BytesType {
+			return protowire.AppendBytes(b, v)
+		}
+		return protowire.AppendVarint(b, 1)
+	}
+	request := message(1, protowire.BytesType, []byte("\x0a\x02hi"))
+	w := &Wrapper{Name: "demo.Wrapper", Fields: []int32{1, 2}}
+	cases := []struct {
+		name    string
+		wrapper *Wrapper
+		payload []byte
+		want    bool
+	}{
+		{"request", w, request, true},
+		{"response", w, message(2, protowire.BytesType, nil), true},
+		{"both", w, append(append([]byte{}, request...), message(2, protowire.BytesType, nil)...), true},
+		{"empty", w, nil, false},
+		{"other field", w, message(3, protowire.BytesType, []byte("x")), false},
+		{"varint field", w, message(1, protowire.VarintType, nil), false},
+		{"truncated", w, request[:len(request)-1], false},
+		{"extra field", w, append(append([]byte{}, request...), message(4, protowire.VarintType, nil)...), false},
+		{"without fields", &Wrapper{}, []byte("anything"), true},
+	}
+	for _, c := range cases {
+		if got := c.wrapper.Match(c.payload); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
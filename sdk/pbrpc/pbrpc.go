@@ -0,0 +1,200 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Package pbrpc is the runtime of the code generated by protoc-gen-deepflow. the generated code register every service
+of the proto files into DefaultRegistry in init, then the plugin lookup the method by the grpc path, the nats subject
+or the service and method name, and decode the payload into the generated message:
+
+	//go:generate protoc --go_out=./pb --go-vtproto_out=./pb --go-vtproto_opt=features=unmarshal --deepflow_out=./pb ./demo.proto
+
+	sdk.SetHandler(pbrpc.NewNatsHandler(nil))
+*/
+package pbrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrMethodNotFound = errors.New("pbrpc: method not found")
+	ErrAmbiguous      = errors.New("pbrpc: ambiguous method, use the full service name")
+)
+
+// Message is implemented by the messages generated by protoc-gen-go-vtproto with the unmarshal feature
+type Message interface {
+	UnmarshalVT([]byte) error
+}
+
+type Method struct {
+	Name string
+	// filled by Service.init, the full name of the service such as foo.bar.Greeter
+	Service         string
+	ClientStreaming bool
+	ServerStreaming bool
+	NewRequest      func() Message
+	NewResponse     func() Message
+}
+
+// GrpcPath return the grpc path of the method, such as /foo.bar.Greeter/SayHello
+func (m *Method) GrpcPath() string {
+	return "/" + m.Service + "/" + m.Name
+}
+
+// RpcType return one of unary, client_stream, server_stream and bidi_stream
+func (m *Method) RpcType() string {
+	switch {
+	case m.ClientStreaming && m.ServerStreaming:
+		return "bidi_stream"
+	case m.ClientStreaming:
+		return "client_stream"
+	case m.ServerStreaming:
+		return "server_stream"
+	default:
+		return "unary"
+	}
+}
+
+// Decode unmarshal one request or response message of the method
+func (m *Method) Decode(isRequest bool, data []byte) (Message, error) {
+	var msg Message
+	if isRequest {
+		msg = m.NewRequest()
+	} else {
+		msg = m.NewResponse()
+	}
+	if err := msg.UnmarshalVT(data); err != nil {
+		return nil, fmt.Errorf("pbrpc: decode %s of %s fail: %v", direction(isRequest), m.GrpcPath(), err)
+	}
+	return msg, nil
+}
+
+// DecodeJSON decode the message and marshal it into json
+func (m *Method) DecodeJSON(isRequest bool, data []byte) ([]byte, error) {
+	msg, err := m.Decode(isRequest, data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(msg)
+}
+
+func direction(isRequest bool) string {
+	if isRequest {
+		return "request"
+	}
+	return "response"
+}
+
+type Service struct {
+	// the full name with the proto package, such as foo.bar.Greeter
+	Name    string
+	Methods []*Method
+}
+
+// ShortName return the service name without the proto package
+func (s *Service) ShortName() string {
+	if i := strings.LastIndexByte(s.Name, '.'); i >= 0 {
+		return s.Name[i+1:]
+	}
+	return s.Name
+}
+
+func (s *Service) init() {
+	for _, m := range s.Methods {
+		m.Service = s.Name
+	}
+}
+
+/*
+Registry index the methods by the full service name and the short service name, the short name is only usable when
+it is unique in the registry, because the services of different proto packages may have the same name.
+*/
+type Registry struct {
+	methods map[string]*Method
+	// the methods indexed by short service name, nil value indicate ambiguous
+	shortMethods map[string]*Method
+}
+
+func NewRegistry(services ...*Service) *Registry {
+	r := &Registry{
+		methods:      make(map[string]*Method),
+		shortMethods: make(map[string]*Method),
+	}
+	for _, s := range services {
+		r.Register(s)
+	}
+	return r
+}
+
+var DefaultRegistry = NewRegistry()
+
+// Register the service into DefaultRegistry, called by the generated code
+func Register(s *Service) {
+	DefaultRegistry.Register(s)
+}
+
+func (r *Registry) Register(s *Service) {
+	s.init()
+	short := s.ShortName()
+	for _, m := range s.Methods {
+		r.methods[s.Name+"/"+m.Name] = m
+		if short == s.Name {
+			continue
+		}
+		key := short + "/" + m.Name
+		if exist, ok := r.shortMethods[key]; ok && (exist == nil || exist.Service != m.Service) {
+			r.shortMethods[key] = nil
+		} else {
+			r.shortMethods[key] = m
+		}
+	}
+}
+
+// Lookup the method by service and method name, the service name can be either the full name or the short name
+func (r *Registry) Lookup(service, method string) (*Method, error) {
+	key := service + "/" + method
+	if m, ok := r.methods[key]; ok {
+		return m, nil
+	}
+	if m, ok := r.shortMethods[key]; ok {
+		if m == nil {
+			return nil, fmt.Errorf("%w: %s", ErrAmbiguous, key)
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrMethodNotFound, key)
+}
+
+// LookupGrpcPath lookup the method by grpc path such as /foo.bar.Greeter/SayHello
+func (r *Registry) LookupGrpcPath(path string) (*Method, error) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid grpc path %s", ErrMethodNotFound, path)
+	}
+	return r.Lookup(service, method)
+}
+
+// LookupSubject lookup the method by nats subject in the form of nrpc, such as Greeter.SayHello or foo.bar.Greeter.SayHello
+func (r *Registry) LookupSubject(subject string) (*Method, error) {
+	i := strings.LastIndexByte(subject, '.')
+	if i <= 0 {
+		return nil, fmt.Errorf("%w: invalid subject %s", ErrMethodNotFound, subject)
+	}
+	return r.Lookup(subject[:i], subject[i+1:])
+}
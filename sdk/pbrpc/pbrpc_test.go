@@ -0,0 +1,195 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pbrpc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+)
+
+type fakeMessage struct {
+	Data string `json:"data"`
+}
+
+func (m *fakeMessage) UnmarshalVT(b []byte) error {
+	if strings.HasPrefix(string(b), "bad") {
+		return errors.New("bad message")
+	}
+	m.Data = string(b)
+	return nil
+}
+
+func newFakeMessage() Message {
+	return &fakeMessage{}
+}
+
+func newService(name string, methods ...*Method) *Service {
+	for _, m := range methods {
+		m.NewRequest, m.NewResponse = newFakeMessage, newFakeMessage
+	}
+	return &Service{Name: name, Methods: methods}
+}
+
+func testRegistry() *Registry {
+	return NewRegistry(
+		newService("foo.Greeter", &Method{Name: "SayHello"}, &Method{Name: "Chat", ClientStreaming: true, ServerStreaming: true}),
+		newService("bar.Greeter", &Method{Name: "SayHello"}),
+		newService("foo.Echo", &Method{Name: "Echo", ServerStreaming: true}),
+		newService("Plain", &Method{Name: "Ping"}),
+	)
+}
+
+func TestRegistryLookup(t *testing.T) {
+	r := testRegistry()
+	cases := []struct {
+		name   string
+		lookup func() (*Method, error)
+		path   string
+		err    error
+	}{
+		{"full name", func() (*Method, error) { return r.Lookup("foo.Greeter", "SayHello") }, "/foo.Greeter/SayHello", nil},
+		{"ambiguous short name", func() (*Method, error) { return r.Lookup("Greeter", "SayHello") }, "", ErrAmbiguous},
+		{"unique short name", func() (*Method, error) { return r.Lookup("Greeter", "Chat") }, "/foo.Greeter/Chat", nil},
+		{"service without package", func() (*Method, error) { return r.Lookup("Plain", "Ping") }, "/Plain/Ping", nil},
+		{"unknown method", func() (*Method, error) { return r.Lookup("foo.Greeter", "Nope") }, "", ErrMethodNotFound},
+		{"grpc path", func() (*Method, error) { return r.LookupGrpcPath("/bar.Greeter/SayHello") }, "/bar.Greeter/SayHello", nil},
+		{"bad grpc path", func() (*Method, error) { return r.LookupGrpcPath("/bar.Greeter") }, "", ErrMethodNotFound},
+		{"subject", func() (*Method, error) { return r.LookupSubject("Echo.Echo") }, "/foo.Echo/Echo", nil},
+		{"full subject", func() (*Method, error) { return r.LookupSubject("foo.Greeter.Chat") }, "/foo.Greeter/Chat", nil},
+		{"bad subject", func() (*Method, error) { return r.LookupSubject("Echo") }, "", ErrMethodNotFound},
+	}
+	for _, c := range cases {
+		m, err := c.lookup()
+		if !errors.Is(err, c.err) {
+			t.Errorf("%s: got error %v, want %v", c.name, err, c.err)
+			continue
+		}
+		if err == nil && m.GrpcPath() != c.path {
+			t.Errorf("%s: got %s, want %s", c.name, m.GrpcPath(), c.path)
+		}
+	}
+}
+
+func grpcMessage(compressed bool, msg string) string {
+	flag := "\x00"
+	if compressed {
+		flag = "\x01"
+	}
+	n := len(msg)
+	return flag + string([]byte{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}) + msg
+}
+
+func TestSplitGrpcMessages(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     string
+		messages []string
+		leftover string
+		err      error
+	}{
+		{"one", grpcMessage(false, "abc"), []string{"abc"}, "", nil},
+		{"multi", grpcMessage(false, "a") + grpcMessage(false, "") + grpcMessage(false, "bc"), []string{"a", "", "bc"}, "", nil},
+		{"partial header", grpcMessage(false, "a") + "\x00\x00", []string{"a"}, "\x00\x00", nil},
+		{"partial message", grpcMessage(false, "abc")[:7], nil, grpcMessage(false, "abc")[:7], nil},
+		{"compressed", grpcMessage(true, "abc"), nil, "", ErrGrpcCompressed},
+	}
+	for _, c := range cases {
+		messages, leftover, err := SplitGrpcMessages([]byte(c.data))
+		if err != c.err {
+			t.Errorf("%s: got error %v, want %v", c.name, err, c.err)
+			continue
+		}
+		if len(messages) != len(c.messages) || string(leftover) != c.leftover {
+			t.Errorf("%s: got %q leftover %q, want %q leftover %q", c.name, messages, leftover, c.messages, c.leftover)
+			continue
+		}
+		for i := range messages {
+			if string(messages[i]) != c.messages[i] {
+				t.Errorf("%s: message %d got %q, want %q", c.name, i, messages[i], c.messages[i])
+			}
+		}
+	}
+}
+
+func TestDecodeGrpcJSON(t *testing.T) {
+	r := testRegistry()
+	cases := []struct {
+		name      string
+		path      string
+		isRequest bool
+		data      string
+		want      string
+		fail      bool
+	}{
+		{"unary", "/foo.Greeter/SayHello", true, grpcMessage(false, "hi"), `{"data":"hi"}`, false},
+		{"server streaming response", "/foo.Echo/Echo", false, grpcMessage(false, "a") + grpcMessage(false, "b"), `[{"data":"a"},{"data":"b"}]`, false},
+		{"unary request of streaming response", "/foo.Echo/Echo", true, grpcMessage(false, "a"), `{"data":"a"}`, false},
+		{"bidi with leftover", "/foo.Greeter/Chat", true, grpcMessage(false, "a") + "\x00", `[{"data":"a"}]`, false},
+		{"bad message", "/foo.Greeter/SayHello", true, grpcMessage(false, "bad"), "", true},
+		{"unknown path", "/foo.Greeter/Nope", true, grpcMessage(false, "a"), "", true},
+	}
+	for _, c := range cases {
+		data, err := r.DecodeGrpcJSON(c.path, c.isRequest, []byte(c.data))
+		if (err != nil) != c.fail {
+			t.Errorf("%s: got error %v", c.name, err)
+			continue
+		}
+		if string(data) != c.want {
+			t.Errorf("%s: got %s, want %s", c.name, data, c.want)
+		}
+	}
+}
+
+func TestNatsPending(t *testing.T) {
+	h := NewNatsHandler(testRegistry())
+	h.MaxPending = 2
+	m := &Method{Name: "SayHello"}
+	for _, reply := range []string{"_INBOX.1", "_INBOX.2", "_INBOX.1", "_INBOX.3"} {
+		h.addPending(reply, m)
+	}
+	if len(h.pending) != 2 || h.pending["_INBOX.1"] != nil || h.pending["_INBOX.3"] == nil {
+		t.Errorf("the oldest pending is not dropped: %v %v", h.pending, h.order)
+	}
+	h.removePending("_INBOX.2")
+	if len(h.pending) != 1 || len(h.order) != 1 || h.order[0] != "_INBOX.3" {
+		t.Errorf("unexpected pending after remove: %v %v", h.pending, h.order)
+	}
+}
+
+func TestFillHelpers(t *testing.T) {
+	info := &sdk.L7ProtocolInfo{}
+	SetTraceID(info, "")
+	AddAttr(info, "k", "")
+	SetException(info, "")
+	SetRespCodeString(info, "x")
+	if info.Trace != nil || info.Kv != nil || info.Resp != nil {
+		t.Errorf("the empty values should be ignored: %+v", info)
+	}
+	SetTraceID(info, "t")
+	SetSpanID(info, "s")
+	AddAttr(info, "k", "v")
+	SetBizCode(info, "B1")
+	SetRespCodeString(info, "-5")
+	SetException(info, "e")
+	if info.Trace.TraceID != "t" || info.Trace.SpanID != "s" || info.Kv[0].Val != "v" || info.BizCode != "B1" ||
+		*info.Resp.Code != -5 || info.Resp.Exception != "e" {
+		t.Errorf("unexpected info %+v", info)
+	}
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pbwire
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"strconv"
+)
+
+const DEFAULT_MAX_OUTPUT = 4096
+
+// the key added to the object where the output is truncated
+const TRUNCATED_KEY = "..."
+
+/*
+JSON render the fields as compact json, the keys are the field numbers and the repeated fields are merged into array:
+
+	{"1":"order-1","2":150,"3":{"1":"apple","2":[1,2]},"4":"AAEC"}
+
+the varint larger than the max int64 is rendered as negative, because it is usually a negative int32 or int64. the
+bytes are rendered as base64. when the output exceed maxSize, the remaining fields are replaced by {"...":true} and
+truncated is true, so the output is always valid json but may slightly exceed maxSize. maxSize 0 indicate unlimited.
+*/
+func JSON(fields []*Field, maxSize int) (b []byte, truncated bool) {
+	r := renderer{maxSize: maxSize}
+	b = r.appendMessage(nil, fields)
+	return b, r.truncated
+}
+
+// DecodeJSON decode the payload and render it with the default limits
+func DecodeJSON(payload []byte) ([]byte, error) {
+	fields, err := Decode(payload)
+	if err != nil {
+		return nil, err
+	}
+	b, _ := JSON(fields, DEFAULT_MAX_OUTPUT)
+	return b, nil
+}
+
+type renderer struct {
+	maxSize   int
+	truncated bool
+}
+
+func (r *renderer) full(b []byte) bool {
+	if r.truncated || (r.maxSize > 0 && len(b) >= r.maxSize) {
+		r.truncated = true
+		return true
+	}
+	return false
+}
+
+func (r *renderer) appendMessage(b []byte, fields []*Field) []byte {
+	// group the repeated fields in the order of the first appearance
+	var numbers []int32
+	groups := make(map[int32][]*Field)
+	for _, f := range fields {
+		if _, ok := groups[f.Number]; !ok {
+			numbers = append(numbers, f.Number)
+		}
+		groups[f.Number] = append(groups[f.Number], f)
+	}
+
+	b = append(b, '{')
+	for i, num := range numbers {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		if r.full(b) {
+			b = append(b, `"`+TRUNCATED_KEY+`":true`...)
+			break
+		}
+		b = append(b, '"')
+		b = strconv.AppendInt(b, int64(num), 10)
+		b = append(b, '"', ':')
+		group := groups[num]
+		if len(group) == 1 {
+			b = r.appendField(b, group[0])
+			continue
+		}
+		b = append(b, '[')
+		for j, f := range group {
+			if j > 0 {
+				b = append(b, ',')
+			}
+			b = r.appendField(b, f)
+		}
+		b = append(b, ']')
+	}
+	return append(b, '}')
+}
+
+func (r *renderer) appendField(b []byte, f *Field) []byte {
+	switch f.Kind {
+	case KindVarint, KindFixed64:
+		if f.Uint > math.MaxInt64 {
+			return strconv.AppendInt(b, int64(f.Uint), 10)
+		}
+		return strconv.AppendUint(b, f.Uint, 10)
+	case KindFixed32:
+		return strconv.AppendUint(b, f.Uint, 10)
+	case KindString:
+		s, _ := json.Marshal(string(r.clip(b, f.Bytes)))
+		return append(b, s...)
+	case KindMessage, KindGroup:
+		return r.appendMessage(b, f.Message)
+	default:
+		b = append(b, '"')
+		b = append(b, base64.StdEncoding.EncodeToString(r.clip(b, f.Bytes))...)
+		return append(b, '"')
+	}
+}
+
+// clip the long string or bytes to the remaining size
+func (r *renderer) clip(b []byte, v []byte) []byte {
+	if r.maxSize <= 0 {
+		return v
+	}
+	remain := r.maxSize - len(b)
+	if remain < 0 {
+		remain = 0
+	}
+	if len(v) > remain {
+		r.truncated = true
+		return v[:remain]
+	}
+	return v
+}
@@ -0,0 +1,193 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Package pbwire decode the protobuf wire format without schema, for the inspection of the payload whose .proto is not
+available. the length delimited field is ambiguous in wire format, it is guessed as:
+
+ 1. a string, if the bytes are printable utf8 and not start with a control character
+ 2. a nested message, if the bytes are parsed as a message completely
+ 3. a string, if the bytes are valid utf8
+ 4. the raw bytes otherwise
+
+the control character is checked because the message usually start with 0x0a, the tag of the string field 1.
+*/
+package pbwire
+
+import (
+	"errors"
+	"unicode"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	DEFAULT_MAX_DEPTH  = 16
+	DEFAULT_MAX_FIELDS = 1024
+)
+
+var (
+	ErrInvalid       = errors.New("pbwire: invalid wire format")
+	ErrTooManyFields = errors.New("pbwire: too many fields")
+)
+
+type Kind uint8
+
+const (
+	KindVarint Kind = iota
+	KindFixed32
+	KindFixed64
+	KindString
+	KindBytes
+	KindMessage
+	// the start group and end group are deprecated, the content of group is decoded as message
+	KindGroup
+)
+
+type Field struct {
+	Number   int32
+	WireType protowire.Type
+	Kind     Kind
+	// the value of varint, fixed32 and fixed64
+	Uint    uint64
+	Bytes   []byte
+	Message []*Field
+}
+
+type Decoder struct {
+	// the max depth of the nested message, the deeper bytes are kept as bytes
+	MaxDepth int
+	// the max number of fields in total, the decode fail when exceed
+	MaxFields int
+	fields    int
+}
+
+func NewDecoder() *Decoder {
+	return &Decoder{MaxDepth: DEFAULT_MAX_DEPTH, MaxFields: DEFAULT_MAX_FIELDS}
+}
+
+// Decode the message with the default limits
+func Decode(b []byte) ([]*Field, error) {
+	return NewDecoder().Decode(b)
+}
+
+func (d *Decoder) Decode(b []byte) ([]*Field, error) {
+	d.fields = 0
+	return d.decode(b, 0)
+}
+
+func (d *Decoder) decode(b []byte, depth int) ([]*Field, error) {
+	var fields []*Field
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, ErrInvalid
+		}
+		b = b[n:]
+		if d.MaxFields > 0 && d.fields >= d.MaxFields {
+			return nil, ErrTooManyFields
+		}
+		d.fields++
+		f := &Field{Number: int32(num), WireType: typ}
+		switch typ {
+		case protowire.VarintType:
+			f.Kind = KindVarint
+			f.Uint, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed32Type:
+			var v uint32
+			f.Kind = KindFixed32
+			v, n = protowire.ConsumeFixed32(b)
+			f.Uint = uint64(v)
+		case protowire.Fixed64Type:
+			f.Kind = KindFixed64
+			f.Uint, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			f.Bytes, n = protowire.ConsumeBytes(b)
+			if n >= 0 {
+				d.guess(f, depth)
+			}
+		case protowire.StartGroupType:
+			var v []byte
+			v, n = protowire.ConsumeGroup(num, b)
+			if n >= 0 {
+				f.Kind = KindGroup
+				// the end group tag is excluded by ConsumeGroup
+				if f.Message, n = d.decodeNested(v, depth, n); n < 0 {
+					return nil, ErrInvalid
+				}
+			}
+		default:
+			return nil, ErrInvalid
+		}
+		if n < 0 {
+			return nil, ErrInvalid
+		}
+		b = b[n:]
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func (d *Decoder) decodeNested(b []byte, depth int, n int) ([]*Field, int) {
+	if d.MaxDepth > 0 && depth+1 >= d.MaxDepth {
+		return nil, n
+	}
+	fields, err := d.decode(b, depth+1)
+	if err != nil {
+		return nil, -1
+	}
+	return fields, n
+}
+
+func (d *Decoder) guess(f *Field, depth int) {
+	if isPrintable(f.Bytes) {
+		f.Kind = KindString
+		return
+	}
+	if len(f.Bytes) > 0 && (d.MaxDepth <= 0 || depth+1 < d.MaxDepth) {
+		saved := d.fields
+		if message, err := d.decode(f.Bytes, depth+1); err == nil {
+			f.Kind = KindMessage
+			f.Message = message
+			return
+		}
+		d.fields = saved
+	}
+	if utf8.Valid(f.Bytes) {
+		f.Kind = KindString
+		return
+	}
+	f.Kind = KindBytes
+}
+
+// the printable utf8 include the whitespaces, which is unlikely a message
+func isPrintable(b []byte) bool {
+	if len(b) > 0 && b[0] < 0x20 {
+		return false
+	}
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		if r == utf8.RuneError && size == 1 {
+			return false
+		}
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+		b = b[size:]
+	}
+	return true
+}
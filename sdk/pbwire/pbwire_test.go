@@ -0,0 +1,136 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pbwire
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func appendBytesField(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendVarintField(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func TestDecodeJSON(t *testing.T) {
+	item := appendVarintField(appendBytesField(nil, 1, []byte("apple")), 2, 3)
+	group := protowire.AppendTag(nil, 7, protowire.StartGroupType)
+	group = appendVarintField(group, 1, 9)
+	group = protowire.AppendTag(group, 7, protowire.EndGroupType)
+	fixed := protowire.AppendFixed32(protowire.AppendTag(nil, 5, protowire.Fixed32Type), 7)
+	fixed = protowire.AppendFixed64(protowire.AppendTag(fixed, 6, protowire.Fixed64Type), 8)
+
+	cases := []struct {
+		name    string
+		payload []byte
+		want    string
+	}{
+		{"empty", nil, `{}`},
+		{"string", appendBytesField(nil, 1, []byte("order-1")), `{"1":"order-1"}`},
+		{"nested message", appendBytesField(nil, 3, item), `{"3":{"1":"apple","2":3}}`},
+		{"repeated", appendVarintField(appendVarintField(nil, 2, 1), 2, 2), `{"2":[1,2]}`},
+		{"negative varint", appendVarintField(nil, 2, ^uint64(0)), `{"2":-1}`},
+		{"bytes", appendBytesField(nil, 4, []byte{0x00, 0x01, 0xff}), `{"4":"AAH/"}`},
+		{"fixed", fixed, `{"5":7,"6":8}`},
+		{"group", group, `{"7":{"1":9}}`},
+		// the bytes start with a control character but not a message is utf8 string
+		{"control string", appendBytesField(nil, 1, []byte("\x01\x02")), `{"1":"\u0001\u0002"}`},
+	}
+	for _, c := range cases {
+		got, err := DecodeJSON(c.payload)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		}
+		if string(got) != c.want {
+			t.Errorf("%s: got %s, want %s", c.name, got, c.want)
+		}
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	cases := []struct {
+		name    string
+		payload []byte
+	}{
+		{"truncated tag", []byte{0x80}},
+		{"truncated varint", []byte{0x08, 0x80}},
+		{"truncated length", []byte{0x0a, 0x05, 'a'}},
+		{"truncated fixed32", []byte{0x2d, 0x01}},
+		{"unterminated group", protowire.AppendTag(nil, 7, protowire.StartGroupType)},
+		{"end group without start", protowire.AppendTag(nil, 7, protowire.EndGroupType)},
+		{"field number 0", []byte{0x00, 0x01}},
+	}
+	for _, c := range cases {
+		if _, err := Decode(c.payload); err != ErrInvalid {
+			t.Errorf("%s: got error %v, want %v", c.name, err, ErrInvalid)
+		}
+	}
+
+	var many []byte
+	for i := 0; i < 10; i++ {
+		many = appendVarintField(many, 1, 1)
+	}
+	d := &Decoder{MaxFields: 5}
+	if _, err := d.Decode(many); err != ErrTooManyFields {
+		t.Errorf("too many fields: got error %v", err)
+	}
+}
+
+func TestDecodeMaxDepth(t *testing.T) {
+	payload := appendVarintField(nil, 1, 1)
+	for i := 0; i < 4; i++ {
+		payload = appendBytesField(nil, 2, payload)
+	}
+	d := &Decoder{MaxDepth: 3}
+	fields, err := d.Decode(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the message deeper than MaxDepth is not decoded, here it is valid utf8 so rendered as string
+	got, _ := JSON(fields, 0)
+	if string(got) != `{"2":{"2":{"2":"\u0012\u0002\b\u0001"}}}` {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestJSONTruncated(t *testing.T) {
+	var payload []byte
+	for i := 1; i <= 50; i++ {
+		payload = appendBytesField(payload, protowire.Number(i), []byte(strings.Repeat("x", 20)))
+	}
+	fields, err := Decode(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, truncated := JSON(fields, 200)
+	var v map[string]interface{}
+	if !truncated || json.Unmarshal(got, &v) != nil || v[TRUNCATED_KEY] != true || len(got) > 300 {
+		t.Errorf("truncated %v, got %s", truncated, got)
+	}
+	if _, truncated := JSON(fields, 0); truncated {
+		t.Error("unlimited output should not be truncated")
+	}
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxyclient
+
+import (
+	"strings"
+)
+
+// the multiple headers are joined in order, as the same as a single header separated by comma
+func parseXForwardedFor(values []string) []string {
+	var chain []string
+	for _, v := range values {
+		for _, hop := range strings.Split(v, ",") {
+			if hop = stripPort(strings.TrimSpace(hop)); hop != "" {
+				chain = append(chain, hop)
+			}
+		}
+	}
+	return chain
+}
+
+/*
+parseForwarded parse the for parameter of RFC 7239:
+
+	Forwarded: for=192.0.2.60;proto=http;by=203.0.113.43, for="[2001:db8:cafe::17]:4711", for=unknown
+
+the element without for parameter is skipped.
+*/
+func parseForwarded(values []string) []string {
+	var chain []string
+	for _, v := range values {
+		for _, element := range splitQuoted(v, ',') {
+			for _, pair := range splitQuoted(element, ';') {
+				key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(key), "for") {
+					continue
+				}
+				val = strings.TrimSpace(val)
+				if len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"' {
+					val = strings.ReplaceAll(val[1:len(val)-1], `\"`, `"`)
+				}
+				if val = stripPort(val); val != "" {
+					chain = append(chain, val)
+				}
+				break
+			}
+		}
+	}
+	return chain
+}
+
+// split s by sep outside the quoted string
+func splitQuoted(s string, sep byte) []string {
+	var (
+		parts  []string
+		quoted bool
+		start  int
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case c == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// stripPort remove the port of 1.2.3.4:80 and [::1]:80, the bare ipv6 and the obfuscated node are kept
+func stripPort(hop string) string {
+	hop = strings.TrimSpace(hop)
+	if strings.HasPrefix(hop, "[") {
+		if end := strings.IndexByte(hop, ']'); end > 0 {
+			return hop[1:end]
+		}
+		return hop
+	}
+	if strings.Count(hop, ":") == 1 {
+		host, _, _ := strings.Cut(hop, ":")
+		return host
+	}
+	return hop
+}
@@ -0,0 +1,226 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Package proxyclient find the real client of the http request forwarded by proxies, and fill Trace.HttpProxyClient and
+Trace.XRequestID. the hop chain is read from the following headers in priority:
+
+	Forwarded:                for=192.0.2.60;proto=http, for="[2001:db8::17]:4711"
+	X-Forwarded-For:          192.0.2.60, 10.0.0.1
+	X-Real-IP:                192.0.2.60
+	X-Envoy-External-Address: 192.0.2.60
+
+the chain is in the order of forwarding, the origin client first. the client is the right most hop which is not a
+trusted proxy, because the hops on the left of an untrusted hop can be forged by the client. for the same reason,
+the forwarding headers are ignored unless the peer of the connection is a trusted proxy, and only the hop in ip is
+accepted as the client, the unknown or obfuscated node such as for=unknown and for=_hidden stop the search. the
+usage as follows:
+
+	result, err := proxyclient.ResolvePayload(ctx.Src().Addr(), payload)
+	if err == nil {
+		result.Fill(info)
+	}
+*/
+package proxyclient
+
+import (
+	"net/netip"
+	"net/textproto"
+	"strings"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/http1"
+)
+
+const DEFAULT_CHAIN_KEY = "proxy_chain"
+
+// the private and loopback networks, which are usually the proxies inside the cluster
+var DEFAULT_TRUSTED_PROXIES = []string{
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"127.0.0.0/8",
+	"fc00::/7",
+	"::1/128",
+}
+
+type Resolver struct {
+	TrustedProxies []netip.Prefix
+	// the attribute key of the hop chain, empty indicate no attribute
+	ChainKey string
+}
+
+// NewResolver accept the cidrs or single ips of the trusted proxies
+func NewResolver(trusted ...string) (*Resolver, error) {
+	r := &Resolver{ChainKey: DEFAULT_CHAIN_KEY}
+	for _, s := range trusted {
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return nil, err
+			}
+			r.TrustedProxies = append(r.TrustedProxies, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, err
+		}
+		r.TrustedProxies = append(r.TrustedProxies, prefix.Masked())
+	}
+	return r, nil
+}
+
+var DefaultResolver, _ = NewResolver(DEFAULT_TRUSTED_PROXIES...)
+
+// Resolve by DefaultResolver
+func Resolve(peer netip.Addr, h map[string][]string) *Result {
+	return DefaultResolver.Resolve(peer, h)
+}
+
+// ResolveFunc by DefaultResolver
+func ResolveFunc(peer netip.Addr, get func(name string) []string) *Result {
+	return DefaultResolver.ResolveFunc(peer, get)
+}
+
+// ResolvePayload by DefaultResolver
+func ResolvePayload(peer netip.Addr, payload []byte) (*Result, error) {
+	return DefaultResolver.ResolvePayload(peer, payload)
+}
+
+type Result struct {
+	// empty if no forwarding header is found, the peer is not a trusted proxy or the client is not an ip
+	Client string
+	// from the origin client to the last proxy, the unknown or obfuscated node of Forwarded is kept as is, empty if
+	// the peer is not a trusted proxy
+	Chain     []string
+	RequestID string
+	chainKey  string
+}
+
+// Resolve the header in the form of http.Header
+func (r *Resolver) Resolve(peer netip.Addr, h map[string][]string) *Result {
+	return r.ResolveFunc(peer, func(name string) []string {
+		return h[textproto.CanonicalMIMEHeaderKey(name)]
+	})
+}
+
+/*
+ResolveFunc resolve by the function return all values of the header, such as http1.Message.Values. the peer is the
+source ip of the connection such as ParseCtx.Src().Addr(), the forwarding headers are ignored if the peer is invalid
+or not a trusted proxy, because the client connect directly can forge them.
+*/
+func (r *Resolver) ResolveFunc(peer netip.Addr, get func(name string) []string) *Result {
+	result := &Result{chainKey: r.ChainKey}
+	if v := get("X-Request-Id"); len(v) > 0 {
+		result.RequestID = strings.TrimSpace(v[0])
+	}
+	if !r.trustedIP(peer) {
+		return result
+	}
+	if v := get("Forwarded"); len(v) > 0 {
+		result.Chain = parseForwarded(v)
+	}
+	if len(result.Chain) == 0 {
+		result.Chain = parseXForwardedFor(get("X-Forwarded-For"))
+	}
+	result.Client = r.client(result.Chain)
+	if result.Client == "" && len(result.Chain) == 0 {
+		for _, name := range []string{"X-Real-Ip", "X-Envoy-External-Address"} {
+			if v := get(name); len(v) > 0 && isIP(stripPort(v[0])) {
+				result.Client = stripPort(v[0])
+				break
+			}
+		}
+	}
+	return result
+}
+
+// ResolvePayload parse the header of the request in payload, the truncated header is resolved as far as read
+func (r *Resolver) ResolvePayload(peer netip.Addr, payload []byte) (*Result, error) {
+	m, err := http1.ParseRequest(payload)
+	if err != nil {
+		return nil, err
+	}
+	return r.ResolveFunc(peer, m.Values), nil
+}
+
+// the v4-mapped v6 address is matched as v4
+func (r *Resolver) trustedIP(ip netip.Addr) bool {
+	if !ip.IsValid() {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, p := range r.TrustedProxies {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func isIP(s string) bool {
+	_, err := netip.ParseAddr(s)
+	return err == nil
+}
+
+// the right most untrusted hop, or the left most if all hops are trusted, empty if the hop found is not an ip
+func (r *Resolver) client(chain []string) string {
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip, err := netip.ParseAddr(chain[i])
+		if err != nil {
+			// the unknown or obfuscated node, the client behind it can not be identified
+			return ""
+		}
+		if !r.trustedIP(ip) {
+			return chain[i]
+		}
+	}
+	if len(chain) > 0 {
+		return chain[0]
+	}
+	return ""
+}
+
+// Apply fill the HttpProxyClient and XRequestID of trace, a new trace is returned if trace is nil
+func (r *Result) Apply(trace *sdk.Trace) *sdk.Trace {
+	if r.Client == "" && r.RequestID == "" {
+		return trace
+	}
+	if trace == nil {
+		trace = &sdk.Trace{}
+	}
+	if r.Client != "" {
+		trace.HttpProxyClient = r.Client
+	}
+	if r.RequestID != "" {
+		trace.XRequestID = r.RequestID
+	}
+	return trace
+}
+
+// Attrs return the hop chain attribute, nil if no chain or the key is empty
+func (r *Result) Attrs() []sdk.KeyVal {
+	if len(r.Chain) == 0 || r.chainKey == "" {
+		return nil
+	}
+	return []sdk.KeyVal{{Key: r.chainKey, Val: strings.Join(r.Chain, ", ")}}
+}
+
+func (r *Result) Fill(info *sdk.L7ProtocolInfo) {
+	info.Trace = r.Apply(info.Trace)
+	info.Kv = append(info.Kv, r.Attrs()...)
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxyclient
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+)
+
+func TestParseForwarded(t *testing.T) {
+	cases := []struct {
+		name   string
+		values []string
+		want   []string
+	}{
+		{"simple", []string{"for=192.0.2.60;proto=http;by=203.0.113.43"}, []string{"192.0.2.60"}},
+		{"quoted ipv6 with port", []string{`for="[2001:db8:cafe::17]:4711"`}, []string{"2001:db8:cafe::17"}},
+		{"quoted ipv4 with port", []string{`For="192.0.2.60:8080"`}, []string{"192.0.2.60"}},
+		{"multi elements", []string{"for=192.0.2.60, for=10.0.0.1"}, []string{"192.0.2.60", "10.0.0.1"}},
+		{"multi headers", []string{"for=192.0.2.60", "for=10.0.0.1"}, []string{"192.0.2.60", "10.0.0.1"}},
+		{"comma in quoted value", []string{`for="_a,b";proto=http, for=10.0.0.1`}, []string{"_a,b", "10.0.0.1"}},
+		{"semicolon in quoted value", []string{`by="x;y";for=192.0.2.60`}, []string{"192.0.2.60"}},
+		{"escaped quote", []string{`for="_a\"b"`}, []string{`_a"b`}},
+		{"obfuscated and unknown", []string{"for=_hidden, for=unknown"}, []string{"_hidden", "unknown"}},
+		{"without for", []string{"proto=https;by=10.0.0.1, for=192.0.2.60"}, []string{"192.0.2.60"}},
+		{"empty", []string{""}, nil},
+	}
+	for _, c := range cases {
+		got := parseForwarded(c.values)
+		if strings.Join(got, "|") != strings.Join(c.want, "|") {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestStripPort(t *testing.T) {
+	cases := map[string]string{
+		"1.2.3.4:80":  "1.2.3.4",
+		"1.2.3.4":     "1.2.3.4",
+		"[::1]:80":    "::1",
+		"[::1]":       "::1",
+		"2001:db8::1": "2001:db8::1",
+		"_hidden":     "_hidden",
+		" 10.0.0.1 ":  "10.0.0.1",
+	}
+	for in, want := range cases {
+		if got := stripPort(in); got != want {
+			t.Errorf("stripPort(%q) got %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	proxy := netip.MustParseAddr("10.0.0.2")
+	cases := []struct {
+		name    string
+		peer    netip.Addr
+		headers map[string][]string
+		client  string
+		chain   string
+	}{
+		{"xff right most untrusted", proxy, map[string][]string{"X-Forwarded-For": {"1.1.1.1, 2.2.2.2, 10.0.0.1"}}, "2.2.2.2", "1.1.1.1, 2.2.2.2, 10.0.0.1"},
+		{"xff all trusted", proxy, map[string][]string{"X-Forwarded-For": {"192.168.0.1", "10.0.0.1"}}, "192.168.0.1", "192.168.0.1, 10.0.0.1"},
+		{"forwarded take precedence", proxy, map[string][]string{"Forwarded": {"for=3.3.3.3"}, "X-Forwarded-For": {"1.1.1.1"}}, "3.3.3.3", "3.3.3.3"},
+		{"forwarded without for fall back to xff", proxy, map[string][]string{"Forwarded": {"proto=https"}, "X-Forwarded-For": {"1.1.1.1"}}, "1.1.1.1", "1.1.1.1"},
+		{"unknown hop stop the search", proxy, map[string][]string{"Forwarded": {"for=1.1.1.1, for=unknown, for=10.0.0.1"}}, "", "1.1.1.1, unknown, 10.0.0.1"},
+		{"obfuscated hop", proxy, map[string][]string{"Forwarded": {"for=_hidden"}}, "", "_hidden"},
+		{"garbage xff hop", proxy, map[string][]string{"X-Forwarded-For": {"1.1.1.1, <script>"}}, "", "1.1.1.1, <script>"},
+		{"real ip fallback", proxy, map[string][]string{"X-Real-Ip": {"4.4.4.4:1234"}}, "4.4.4.4", ""},
+		{"envoy fallback", proxy, map[string][]string{"X-Envoy-External-Address": {"5.5.5.5"}}, "5.5.5.5", ""},
+		{"real ip not used with chain", proxy, map[string][]string{"Forwarded": {"for=unknown"}, "X-Real-Ip": {"4.4.4.4"}}, "", "unknown"},
+		{"real ip not an ip", proxy, map[string][]string{"X-Real-Ip": {"localhost"}}, "", ""},
+		{"untrusted peer", netip.MustParseAddr("8.8.8.8"), map[string][]string{"X-Forwarded-For": {"1.1.1.1"}, "X-Real-Ip": {"4.4.4.4"}}, "", ""},
+		{"invalid peer", netip.Addr{}, map[string][]string{"X-Forwarded-For": {"1.1.1.1"}}, "", ""},
+		{"ipv6 loopback peer", netip.MustParseAddr("::1"), map[string][]string{"Forwarded": {`for="[2001:db8::17]:4711"`}}, "2001:db8::17", "2001:db8::17"},
+		{"v4-mapped peer", netip.MustParseAddr("::ffff:10.0.0.2"), map[string][]string{"X-Forwarded-For": {"1.1.1.1, ::ffff:10.0.0.1"}}, "1.1.1.1", "1.1.1.1, ::ffff:10.0.0.1"},
+	}
+	for _, c := range cases {
+		c.headers["X-Request-Id"] = []string{" req-1 "}
+		r := Resolve(c.peer, c.headers)
+		if r.Client != c.client || strings.Join(r.Chain, ", ") != c.chain || r.RequestID != "req-1" {
+			t.Errorf("%s: got client %q chain %q request id %q, want client %q chain %q", c.name, r.Client, r.Chain, r.RequestID, c.client, c.chain)
+		}
+	}
+}
+
+func TestNewResolver(t *testing.T) {
+	r, err := NewResolver("203.0.113.7", "2001:db8::1", "198.51.100.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ip, want := range map[string]bool{"203.0.113.7": true, "203.0.113.8": false, "2001:db8::1": true, "198.51.100.9": true, "10.0.0.1": false, "::ffff:198.51.100.9": true} {
+		if got := r.trustedIP(netip.MustParseAddr(ip)); got != want {
+			t.Errorf("trusted %s got %v, want %v", ip, got, want)
+		}
+	}
+	for _, bad := range []string{"not-ip", "10.0.0.0/33"} {
+		if _, err := NewResolver(bad); err == nil {
+			t.Errorf("%s: expect fail", bad)
+		}
+	}
+}
+
+func TestResolvePayload(t *testing.T) {
+	payload := []byte("GET / HTTP/1.1\r\nHost: a\r\nX-Forwarded-For: 1.1.1.1\r\nX-Request-Id: r1\r\nX-Forwarded-For: 2.2.2.2\r\nUser-Ag")
+	r, err := ResolvePayload(netip.MustParseAddr("127.0.0.1"), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &sdk.L7ProtocolInfo{Trace: &sdk.Trace{TraceID: "t"}}
+	r.Fill(info)
+	if info.Trace.TraceID != "t" || info.Trace.HttpProxyClient != "2.2.2.2" || info.Trace.XRequestID != "r1" {
+		t.Errorf("unexpected trace %+v", info.Trace)
+	}
+	if len(info.Kv) != 1 || info.Kv[0] != (sdk.KeyVal{Key: DEFAULT_CHAIN_KEY, Val: "1.1.1.1, 2.2.2.2"}) {
+		t.Errorf("unexpected attrs %v", info.Kv)
+	}
+	if _, err := ResolvePayload(netip.Addr{}, []byte("\x00\x01")); err == nil {
+		t.Error("not http: expect fail")
+	}
+	if trace := (&Result{}).Apply(nil); trace != nil {
+		t.Errorf("empty result should keep the nil trace, got %+v", trace)
+	}
+}
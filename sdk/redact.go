@@ -0,0 +1,546 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// the names of the built-in detectors
+const (
+	DETECTOR_EMAIL = "email"
+	// the phone number with + or in (xxx) xxx-xxxx, or follow the keywords such as phone=
+	DETECTOR_PHONE = "phone"
+	// the card number pass the luhn check, grouped by the separators or follow the keywords such as card_no=
+	DETECTOR_CARD   = "card"
+	DETECTOR_JWT    = "jwt"
+	DETECTOR_BEARER = "bearer"
+	// the value of the deny keys in query string such as password=xxx and json such as "password": "xxx"
+	DETECTOR_SECRET_PARAM = "secret_param"
+)
+
+// the keys whose value is always masked, the key is compared in lower case with - replaced by _
+var DEFAULT_DENY_KEYS = []string{
+	"password", "passwd", "pwd", "pass",
+	"secret", "client_secret",
+	"token", "access_token", "refresh_token", "id_token",
+	"api_key", "apikey", "private_key",
+	"authorization", "proxy_authorization",
+	"cookie", "set_cookie",
+	"session", "sessionid", "session_id",
+	"credential", "credentials",
+}
+
+const MASK = "******"
+
+// Detector find the sensitive values in the text
+type Detector struct {
+	Name string
+	// return the spans [start, end) to mask
+	Find func(s string) [][2]int
+	// MaskSecret by default
+	Mask func(v string) string
+}
+
+/*
+Redactor mask the personal data and secrets in the attributes, the resource and endpoint of request, the result and
+exception of response before sending to the agent:
+
+  - the value of the allowed keys is kept as is
+  - the value of the denied keys is replaced by MASK
+  - the other values and texts are masked by the detectors, such as a@example.com -> a***@example.com
+
+the redaction is disabled by default, because the masked value can not be restored and the detectors cost cpu on
+every result. enable it by SetRedactor(DefaultRedactor) or a Redactor of the plugin's own.
+*/
+type Redactor struct {
+	allow     map[string]bool
+	deny      map[string]bool
+	detectors []Detector
+}
+
+// NewRedactor return the Redactor with DEFAULT_DENY_KEYS and all the built-in detectors
+func NewRedactor() *Redactor {
+	r := NewEmptyRedactor()
+	r.Deny(DEFAULT_DENY_KEYS...)
+	for _, name := range []string{DETECTOR_JWT, DETECTOR_BEARER, DETECTOR_SECRET_PARAM, DETECTOR_EMAIL, DETECTOR_CARD, DETECTOR_PHONE} {
+		d, _ := r.builtinDetector(name)
+		r.AddDetector(d)
+	}
+	return r
+}
+
+// NewEmptyRedactor return the Redactor without deny keys and detectors
+func NewEmptyRedactor() *Redactor {
+	return &Redactor{allow: map[string]bool{}, deny: map[string]bool{}}
+}
+
+var (
+	DefaultRedactor = NewRedactor()
+	// nil indicate the redaction is disabled
+	vmRedactor *Redactor
+)
+
+// SetRedactor set the redactor applied in serialization, nil disable the redaction
+func SetRedactor(r *Redactor) {
+	vmRedactor = r
+}
+
+func normalizeKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "-", "_")
+}
+
+// Allow the keys, the allowed key take precedence over the denied key
+func (r *Redactor) Allow(keys ...string) {
+	for _, k := range keys {
+		r.allow[normalizeKey(k)] = true
+	}
+}
+
+func (r *Redactor) Deny(keys ...string) {
+	for _, k := range keys {
+		r.deny[normalizeKey(k)] = true
+	}
+}
+
+// AddDetector append the detector, the detectors are applied in order on the text masked by the previous ones
+func (r *Redactor) AddDetector(d Detector) {
+	if d.Mask == nil {
+		d.Mask = MaskSecret
+	}
+	r.detectors = append(r.detectors, d)
+}
+
+func (r *Redactor) denied(key string) bool {
+	k := normalizeKey(key)
+	return r.deny[k] && !r.allow[k]
+}
+
+// Redact mask the sensitive values in s by the detectors
+func (r *Redactor) Redact(s string) string {
+	for i := range r.detectors {
+		if s == "" {
+			return s
+		}
+		d := &r.detectors[i]
+		spans := d.Find(s)
+		if len(spans) == 0 {
+			continue
+		}
+		var b strings.Builder
+		b.Grow(len(s))
+		last := 0
+		for _, span := range spans {
+			if span[0] < last {
+				continue
+			}
+			b.WriteString(s[last:span[0]])
+			b.WriteString(d.Mask(s[span[0]:span[1]]))
+			last = span[1]
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+	}
+	return s
+}
+
+// RedactValue mask the value of the key
+func (r *Redactor) RedactValue(key, val string) string {
+	k := normalizeKey(key)
+	switch {
+	case val == "" || r.allow[k]:
+		return val
+	case r.deny[k]:
+		return MASK
+	}
+	return r.Redact(val)
+}
+
+// redactInfo return the redacted copy, the info of the plugin is not modified
+func (r *Redactor) redactInfo(info *L7ProtocolInfo) *L7ProtocolInfo {
+	c := *info
+	if info.Req != nil {
+		req := *info.Req
+		req.Resource = r.Redact(req.Resource)
+		req.Endpoint = r.Redact(req.Endpoint)
+		c.Req = &req
+	}
+	if info.Resp != nil {
+		resp := *info.Resp
+		resp.Result = r.Redact(resp.Result)
+		resp.Exception = r.Redact(resp.Exception)
+		c.Resp = &resp
+	}
+	if len(info.Kv) > 0 {
+		c.Kv = make([]KeyVal, len(info.Kv))
+		for i, kv := range info.Kv {
+			c.Kv[i] = KeyVal{Key: kv.Key, Val: r.RedactValue(kv.Key, kv.Val)}
+		}
+	}
+	return &c
+}
+
+// RedactConfig is the json configuration of the Redactor
+type RedactConfig struct {
+	Disabled  bool     `json:"disabled,omitempty"`
+	AllowKeys []string `json:"allow_keys,omitempty"`
+	// appended to DEFAULT_DENY_KEYS
+	DenyKeys []string `json:"deny_keys,omitempty"`
+	// the names of the built-in detectors, all of them by default
+	Detectors []string `json:"detectors,omitempty"`
+}
+
+// Build return nil if the redaction is disabled
+func (c *RedactConfig) Build() (*Redactor, error) {
+	if c.Disabled {
+		return nil, nil
+	}
+	if len(c.Detectors) == 0 {
+		r := NewRedactor()
+		r.Allow(c.AllowKeys...)
+		r.Deny(c.DenyKeys...)
+		return r, nil
+	}
+	r := NewEmptyRedactor()
+	r.Allow(c.AllowKeys...)
+	r.Deny(DEFAULT_DENY_KEYS...)
+	r.Deny(c.DenyKeys...)
+	for _, name := range c.Detectors {
+		d, ok := r.builtinDetector(name)
+		if !ok {
+			return nil, fmt.Errorf("redact: unknown detector %q", name)
+		}
+		r.AddDetector(d)
+	}
+	return r, nil
+}
+
+/*
+LoadRedactor build the Redactor by the json config, such as:
+
+	{
+	  "allow_keys": ["user_id"],
+	  "deny_keys": ["username", "id_card"],
+	  "detectors": ["email", "card", "jwt"]
+	}
+*/
+func LoadRedactor(config []byte) (*Redactor, error) {
+	var c RedactConfig
+	if err := json.Unmarshal(config, &c); err != nil {
+		return nil, fmt.Errorf("redact: %v", err)
+	}
+	return c.Build()
+}
+
+func (r *Redactor) builtinDetector(name string) (Detector, bool) {
+	switch name {
+	case DETECTOR_EMAIL:
+		return Detector{Name: name, Find: findEmails, Mask: MaskEmail}, true
+	case DETECTOR_PHONE:
+		return Detector{Name: name, Find: findPhones, Mask: MaskDigits}, true
+	case DETECTOR_CARD:
+		return Detector{Name: name, Find: findCards, Mask: MaskDigits}, true
+	case DETECTOR_JWT:
+		return Detector{Name: name, Find: findJWTs, Mask: MaskSecret}, true
+	case DETECTOR_BEARER:
+		return Detector{Name: name, Find: findBearerTokens, Mask: MaskSecret}, true
+	case DETECTOR_SECRET_PARAM:
+		return Detector{Name: name, Find: r.findSecretParams, Mask: MaskSecret}, true
+	}
+	return Detector{}, false
+}
+
+// MaskSecret replace the whole value by MASK
+func MaskSecret(string) string {
+	return MASK
+}
+
+// MaskDigits mask the digits except the last 4, the separators are kept, such as 4111-****-****-1111
+func MaskDigits(v string) string {
+	keep := 4
+	b := []byte(v)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < '0' || b[i] > '9' {
+			continue
+		}
+		if keep > 0 {
+			keep--
+			continue
+		}
+		b[i] = '*'
+	}
+	return string(b)
+}
+
+// MaskEmail keep the first char of the local part and the domain, such as a***@example.com
+func MaskEmail(v string) string {
+	at := strings.LastIndexByte(v, '@')
+	if at <= 0 {
+		return MASK
+	}
+	return v[:1] + "***" + v[at:]
+}
+
+// lazyRegexp compile the expression on the first use, so the plugin disabled the redaction pay nothing
+type lazyRegexp struct {
+	once sync.Once
+	expr string
+	re   *regexp.Regexp
+}
+
+func (l *lazyRegexp) get() *regexp.Regexp {
+	l.once.Do(func() {
+		l.re = regexp.MustCompile(l.expr)
+	})
+	return l.re
+}
+
+/*
+the number after the keywords such as card_no=, "phone": and mobile:, the keyword is only followed by the suffixes
+such as _card, _phone, _no and _number, so the keys such as telemetry_count, cellular_id and panel are not matched.
+*/
+const keywordValue = `(?:[_\-]?(?:card|phone))?(?:[_\-]?(?:no|num|number))?\\?["']?\s*[:=]\s*\\?["']?(\+?\d(?:[ \-.]?\d){6,18})`
+
+var (
+	emailRegexp  = &lazyRegexp{expr: `[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`}
+	jwtRegexp    = &lazyRegexp{expr: `eyJ[A-Za-z0-9_\-]+\.eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`}
+	bearerRegexp = &lazyRegexp{expr: `(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`}
+	// the digits grouped by space or dash such as 4111 1111 1111 1111 and 3782-822463-10005
+	cardRegexp        = &lazyRegexp{expr: `\b\d{4}[ \-]\d{4,6}[ \-]\d{4,5}(?:[ \-]\d{1,7})?\b`}
+	cardKeywordRegexp = &lazyRegexp{expr: `(?i)(?:^|[^a-z])(?:card|pan|credit|debit)` + keywordValue}
+	// e.164 with + and (xxx) xxx-xxxx, the bare digits are too ambiguous with the ids and timestamps
+	phoneRegexp        = &lazyRegexp{expr: `\+\d{1,3}[ \-]?\d(?:[ \-]?\d){6,13}\b|\(\d{3}\) ?\d{3}[\-. ]\d{4}\b`}
+	phoneKeywordRegexp = &lazyRegexp{expr: `(?i)(?:^|[^a-z])(?:phone|mobile|telephone|tel|cell)` + keywordValue}
+)
+
+func findEmails(s string) [][2]int {
+	if strings.IndexByte(s, '@') < 0 {
+		return nil
+	}
+	return spans(emailRegexp.get().FindAllStringIndex(s, -1))
+}
+
+func findJWTs(s string) [][2]int {
+	if !strings.Contains(s, "eyJ") {
+		return nil
+	}
+	return spans(jwtRegexp.get().FindAllStringIndex(s, -1))
+}
+
+// mask the token only, the scheme is kept
+func findBearerTokens(s string) [][2]int {
+	if !strings.Contains(s, "earer") && !strings.Contains(s, "EARER") {
+		return nil
+	}
+	return submatches(bearerRegexp.get().FindAllStringSubmatchIndex(s, -1))
+}
+
+/*
+findCards find the card numbers pass the luhn check, which are grouped by the separators or follow the keywords:
+
+	4111 1111 1111 1111
+	card_no=4111111111111111
+	"credit_card": "4111111111111111"
+
+the bare digits without the context are not masked, because the ids and the timestamps in milliseconds pass the luhn
+check by chance.
+*/
+func findCards(s string) [][2]int {
+	if !hasDigitRun(s, 4) {
+		return nil
+	}
+	var result [][2]int
+	candidates := spans(cardRegexp.get().FindAllStringIndex(s, -1))
+	candidates = append(candidates, submatches(cardKeywordRegexp.get().FindAllStringSubmatchIndex(s, -1))...)
+	for _, m := range candidates {
+		if luhn(s[m[0]:m[1]]) {
+			result = append(result, m)
+		}
+	}
+	return sortSpans(result)
+}
+
+// findPhones find the phone numbers in international or north american format, or follow the keywords
+func findPhones(s string) [][2]int {
+	if !hasDigitRun(s, 3) {
+		return nil
+	}
+	result := spans(phoneRegexp.get().FindAllStringIndex(s, -1))
+	result = append(result, submatches(phoneKeywordRegexp.get().FindAllStringSubmatchIndex(s, -1))...)
+	return sortSpans(result)
+}
+
+// the spans of the first submatch
+func submatches(matches [][]int) [][2]int {
+	var result [][2]int
+	for _, m := range matches {
+		if m[2] >= 0 {
+			result = append(result, [2]int{m[2], m[3]})
+		}
+	}
+	return result
+}
+
+// sort the spans by the start, the overlapped one is skipped by Redact
+func sortSpans(s [][2]int) [][2]int {
+	sort.Slice(s, func(i, j int) bool {
+		return s[i][0] < s[j][0]
+	})
+	return s
+}
+
+func spans(matches [][]int) [][2]int {
+	if len(matches) == 0 {
+		return nil
+	}
+	result := make([][2]int, len(matches))
+	for i, m := range matches {
+		result[i] = [2]int{m[0], m[1]}
+	}
+	return result
+}
+
+func hasDigitRun(s string, n int) bool {
+	run := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			if run++; run >= n {
+				return true
+			}
+		} else {
+			run = 0
+		}
+	}
+	return false
+}
+
+// the digits without separators is 13 to 19 and pass the luhn check
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && n <= 19 && sum%10 == 0
+}
+
+func isKeyChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_' || c == '-' || c == '.'
+}
+
+/*
+findSecretParams find the value of the denied keys in:
+
+	query string:   password=xxx&user=foo
+	json:           "password": "xxx"
+	escaped json:   {\"password\":\"xxx\"}
+*/
+func (r *Redactor) findSecretParams(s string) [][2]int {
+	if len(r.deny) == 0 {
+		return nil
+	}
+	var result [][2]int
+	for i := 0; i < len(s); i++ {
+		if s[i] != '=' && s[i] != ':' {
+			continue
+		}
+		quoted, escaped := s[i] == ':', false
+		keyEnd := i
+		if quoted {
+			// "key" : "value" or \"key\":\"value\" in the json string
+			for keyEnd > 0 && s[keyEnd-1] == ' ' {
+				keyEnd--
+			}
+			if keyEnd == 0 || s[keyEnd-1] != '"' {
+				continue
+			}
+			keyEnd--
+			if keyEnd > 0 && s[keyEnd-1] == '\\' {
+				keyEnd--
+				escaped = true
+			}
+		}
+		keyStart := keyEnd
+		for keyStart > 0 && isKeyChar(s[keyStart-1]) {
+			keyStart--
+		}
+		if keyStart == keyEnd || !r.denied(s[keyStart:keyEnd]) {
+			continue
+		}
+		if quoted && (keyStart == 0 || s[keyStart-1] != '"') {
+			continue
+		}
+		start := i + 1
+		if quoted {
+			for start < len(s) && s[start] == ' ' {
+				start++
+			}
+			if escaped && strings.HasPrefix(s[start:], `\"`) {
+				start++
+			}
+			if start >= len(s) || s[start] != '"' {
+				continue
+			}
+			start++
+		}
+		end := start
+		if quoted {
+			end = quotedEnd(s, start, escaped)
+		} else {
+			for end < len(s) && strings.IndexByte("&;# \"',\n\r", s[end]) < 0 {
+				end++
+			}
+		}
+		if end > start {
+			result = append(result, [2]int{start, end})
+		}
+		i = end
+	}
+	return result
+}
+
+// the end of the json string value start at s[start], the escaped value is unescaped once before checking the quote
+func quotedEnd(s string, start int, escaped bool) int {
+	end, backslash := start, false
+	for end < len(s) {
+		c, n := s[end], 1
+		if escaped && c == '\\' && end+1 < len(s) {
+			c, n = s[end+1], 2
+		}
+		if c == '"' && !backslash {
+			break
+		}
+		backslash = c == '\\' && !backslash
+		end += n
+	}
+	return end
+}
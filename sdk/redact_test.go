@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+import (
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	r := NewRedactor()
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		// positive
+		{"email", "mail to alice@example.com", "mail to a***@example.com"},
+		{"card grouped", "card 4111 1111 1111 1111 paid", "card **** **** **** 1111 paid"},
+		{"card dashed amex", "3782-822463-10005", "****-******-*0005"},
+		{"card keyword", "card_no=4111111111111111&x=1", "card_no=************1111&x=1"},
+		{"card keyword json", `{"credit_card": "4111111111111111"}`, `{"credit_card": "************1111"}`},
+		{"phone e164", "call +86 138 0013 8000", "call +** *** **** 8000"},
+		{"phone nanp", "call (415) 555-2671", "call (***) ***-2671"},
+		{"phone keyword", "mobile=13800138000", "mobile=*******8000"},
+		{"phone keyword suffix", "mobile_phone_number=13800138000 tel_no=13800138000", "mobile_phone_number=*******8000 tel_no=*******8000"},
+		{"phone keyword telephone", `{"telephone": "13800138000", "cellphone": "13800138000"}`, `{"telephone": "*******8000", "cellphone": "*******8000"}`},
+		{"card keyword suffix", "pan=4111111111111111&debit_card_number=4111111111111111", "pan=************1111&debit_card_number=************1111"},
+		{"phone keyword escaped json", `{\"phone\":\"13800138000\"}`, `{\"phone\":\"*******8000\"}`},
+		{"jwt", "token eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig-_x", "token ******"},
+		{"bearer", "Authorization: Bearer abc.def-123", "Authorization: Bearer ******"},
+		{"query secret", "user=foo&password=abc123&x=1", "user=foo&password=******&x=1"},
+		{"json secret", `{"password" : "a\"b", "user": "foo"}`, `{"password" : "******", "user": "foo"}`},
+		{"escaped json secret", `{\"password\":\"abc\",\"user\":\"foo\"}`, `{\"password\":\"******\",\"user\":\"foo\"}`},
+		{"escaped json secret with quote", `{\"token\":\"a\\\"b\"}`, `{\"token\":\"******\"}`},
+		// negative
+		{"timestamp ms", "ts=1700000000004 done", "ts=1700000000004 done"},
+		{"luhn id", "order_id=4111111111111111", "order_id=4111111111111111"},
+		{"mobile like id", "uid=13800138000", "uid=13800138000"},
+		{"bare nanp", "ref 123-456-7890", "ref 123-456-7890"},
+		{"card not luhn", "4111 1111 1111 1112", "4111 1111 1111 1112"},
+		{"keyword inside word", "company=4111111111111111 hotel=13800138000", "company=4111111111111111 hotel=13800138000"},
+		{"not denied key", `{"passenger":"bob"}`, `{"passenger":"bob"}`},
+		{"keyword prefix telemetry", "telemetry_count=13800138000", "telemetry_count=13800138000"},
+		{"keyword prefix cellular", `{"cellular_id": "13800138000"}`, `{"cellular_id": "13800138000"}`},
+		{"keyword prefix pane", "pane_id=4111111111111111", "pane_id=4111111111111111"},
+		{"keyword prefix panel", "panel=4111111111111111", "panel=4111111111111111"},
+		{"empty", "", ""},
+	}
+	for _, c := range cases {
+		if got := r.Redact(c.in); got != c.want {
+			t.Errorf("%s: Redact(%q) got %q, want %q", c.name, c.in, got, c.want)
+		}
+	}
+}
+
+func TestRedactValue(t *testing.T) {
+	r := NewRedactor()
+	r.Allow("token")
+	r.Deny("Id-Card")
+	cases := []struct {
+		key, val, want string
+	}{
+		{"password", "abc", MASK},
+		{"ID_CARD", "110101199003077777", MASK},
+		{"token", "abc", "abc"},
+		{"user_id", "1700000000004", "1700000000004"},
+		{"email", "bob@example.com", "b***@example.com"},
+		{"password", "", ""},
+	}
+	for _, c := range cases {
+		if got := r.RedactValue(c.key, c.val); got != c.want {
+			t.Errorf("RedactValue(%q, %q) got %q, want %q", c.key, c.val, got, c.want)
+		}
+	}
+}
+
+func TestRedactInfoCopy(t *testing.T) {
+	info := &L7ProtocolInfo{
+		Req:  &Request{Resource: "/login?password=abc"},
+		Resp: &Response{Exception: "bad password for bob@example.com"},
+		Kv:   []KeyVal{{Key: "token", Val: "abc"}},
+	}
+	redacted := NewRedactor().redactInfo(info)
+	if redacted.Req.Resource != "/login?password=******" ||
+		redacted.Resp.Exception != "bad password for b***@example.com" ||
+		redacted.Kv[0].Val != MASK {
+		t.Errorf("unexpected redacted info %+v %+v %+v", redacted.Req, redacted.Resp, redacted.Kv)
+	}
+	if info.Req.Resource != "/login?password=abc" || info.Kv[0].Val != "abc" {
+		t.Error("the info of plugin is modified")
+	}
+}
+
+func TestLoadRedactor(t *testing.T) {
+	r, err := LoadRedactor([]byte(`{"disabled": true}`))
+	if err != nil || r != nil {
+		t.Errorf("disabled: got %v, %v", r, err)
+	}
+	if _, err := LoadRedactor([]byte(`{"detectors": ["nope"]}`)); err == nil {
+		t.Error("unknown detector: expect fail")
+	}
+	r, err = LoadRedactor([]byte(`{"deny_keys": ["username"], "detectors": ["email"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Redact("card 4111 1111 1111 1111 bob@example.com"); got != "card 4111 1111 1111 1111 b***@example.com" {
+		t.Errorf("email only: got %q", got)
+	}
+	if got := r.RedactValue("username", "bob"); got != MASK {
+		t.Errorf("deny key: got %q", got)
+	}
+}
+
+func TestLuhn(t *testing.T) {
+	cases := []struct {
+		in   string
+		want bool
+	}{
+		{"4111111111111111", true},
+		{"4111-1111-1111-1111", true},
+		{"378282246310005", true},
+		{"4111111111111112", false},
+		// too short or too long
+		{"424242424242", false},
+		{"42424242424242424242", false},
+	}
+	for _, c := range cases {
+		if got := luhn(c.in); got != c.want {
+			t.Errorf("luhn(%q) got %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestRedactorDisabledByDefault(t *testing.T) {
+	if vmRedactor != nil {
+		t.Error("the redaction is enabled by default")
+	}
+}
@@ -0,0 +1,499 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Package rewrite rewrite the status of http response by declarative rules, for the services encode the business
+failure in the body or headers. the rules are loaded from json config, such as:
+
+	[
+	  {
+	    "name": "biz_code",
+	    "match": {
+	      "path": "/api/*",
+	      "status": "2xx",
+	      "body": [{"key": "$.code", "op": "ne", "value": "0"}]
+	    },
+	    "set": {
+	      "code": "500",
+	      "exception": "${body:$.msg}",
+	      "biz_code": "${body:$.code}",
+	      "attrs": {"err_code": "${header:X-Err-Code}"}
+	    }
+	  }
+	]
+
+the rules are matched in order and the first matched rule is applied. the values of set are templates, the references
+are ${body}, ${body:<json path>}, ${header:<name>}, ${status}, ${path} and ${host}, the value expanded to empty is
+skipped. the response has no host, so the host of the request is remembered by the flow if a rule match or
+reference the host, and Engine.Handler hook in the requests only in this case:
+
+	engine, err := rewrite.Load(config)
+	sdk.SetHandler(engine.Handler())
+*/
+package rewrite
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/body"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/http1"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/jsonpath"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/status"
+)
+
+// the operators of Cond, the operators except absent do not match the absent value
+const (
+	OP_EQ       = "eq"
+	OP_NE       = "ne"
+	OP_IN       = "in"
+	OP_NOT_IN   = "not_in"
+	OP_PREFIX   = "prefix"
+	OP_CONTAINS = "contains"
+	OP_EXISTS   = "exists"
+	OP_ABSENT   = "absent"
+)
+
+type Rule struct {
+	Name  string `json:"name,omitempty"`
+	Match Match  `json:"match"`
+	Set   Set    `json:"set"`
+}
+
+// Match is matched when all the fields are matched, the empty field match anything
+type Match struct {
+	// the exact host or the suffix such as *.example.com
+	Host string `json:"host,omitempty"`
+	// the exact path or the prefix such as /api/*
+	Path string `json:"path,omitempty"`
+	// the status code such as 500, 2xx, 400-499 and the combination of them separated by comma
+	Status  string `json:"status,omitempty"`
+	Headers []Cond `json:"headers,omitempty"`
+	Body    []Cond `json:"body,omitempty"`
+}
+
+type Cond struct {
+	// the header name or the json path of the body
+	Key string `json:"key"`
+	Op  string `json:"op"`
+	// the values separated by comma for in and not_in
+	Value string `json:"value,omitempty"`
+}
+
+// Set is the templates of the fields to rewrite
+type Set struct {
+	Code string `json:"code,omitempty"`
+	// ok, client_error, server_error, timeout or unknown, derived from the code if empty
+	Status    string `json:"status,omitempty"`
+	Exception string `json:"exception,omitempty"`
+	Result    string `json:"result,omitempty"`
+	// the integer of biz type
+	BizType         string            `json:"biz_type,omitempty"`
+	BizCode         string            `json:"biz_code,omitempty"`
+	BizScenario     string            `json:"biz_scenario,omitempty"`
+	BizResponseCode string            `json:"biz_response_code,omitempty"`
+	Attrs           map[string]string `json:"attrs,omitempty"`
+}
+
+// Input is the response to match, the Header and Body are optional
+type Input struct {
+	Host   string
+	Path   string
+	Status int
+	// return the value of header, empty if absent
+	Header func(name string) string
+	// the decoded body
+	Body []byte
+}
+
+func (in *Input) header(name string) string {
+	if in.Header == nil {
+		return ""
+	}
+	return in.Header(name)
+}
+
+type cond struct {
+	Cond
+	path   jsonpath.Path
+	values []string
+}
+
+type attr struct {
+	key string
+	val template
+}
+
+type rule struct {
+	name    string
+	host    string
+	path    string
+	codes   ranges
+	headers []cond
+	body    []cond
+
+	code, exception, result                    template
+	bizType, bizCode, bizScenario, bizRespCode template
+	respStatus                                 *sdk.RespStatus
+	attrs                                      []attr
+}
+
+const DEFAULT_MAX_FLOWS = 4096
+
+type Engine struct {
+	rules []*rule
+	// the body is decoded only if a rule reference the body
+	needBody bool
+	Decoder  *body.Decoder
+	// the host of request is remembered only if a rule match or reference the host
+	needHost bool
+	// the max number of flows waiting for the response, the oldest one is dropped when exceed
+	MaxFlows int
+	hosts    map[uint64]string
+	// the flow ids in the order of the requests
+	order []uint64
+}
+
+// Load the rules from the json array
+func Load(config []byte) (*Engine, error) {
+	var rules []Rule
+	if err := json.Unmarshal(config, &rules); err != nil {
+		return nil, fmt.Errorf("rewrite: bad config: %w", err)
+	}
+	return NewEngine(rules)
+}
+
+func NewEngine(rules []Rule) (*Engine, error) {
+	e := &Engine{Decoder: body.DefaultDecoder, MaxFlows: DEFAULT_MAX_FLOWS, hosts: make(map[uint64]string)}
+	for i := range rules {
+		r, err := e.compile(&rules[i])
+		if err != nil {
+			name := rules[i].Name
+			if name == "" {
+				name = strconv.Itoa(i)
+			}
+			return nil, fmt.Errorf("rewrite: rule %s: %w", name, err)
+		}
+		e.rules = append(e.rules, r)
+	}
+	return e, nil
+}
+
+func (e *Engine) compile(r *Rule) (*rule, error) {
+	c := &rule{name: r.Name, host: r.Match.Host, path: r.Match.Path}
+	e.needHost = e.needHost || c.host != ""
+	var err error
+	if c.codes, err = status.ParseRanges(r.Match.Status); err != nil {
+		return nil, err
+	}
+	for _, h := range r.Match.Headers {
+		hc, err := compileCond(h, false)
+		if err != nil {
+			return nil, err
+		}
+		c.headers = append(c.headers, hc)
+	}
+	for _, b := range r.Match.Body {
+		bc, err := compileCond(b, true)
+		if err != nil {
+			return nil, err
+		}
+		c.body = append(c.body, bc)
+		e.needBody = true
+	}
+
+	fields := []struct {
+		dst *template
+		src string
+	}{
+		{&c.code, r.Set.Code},
+		{&c.exception, r.Set.Exception},
+		{&c.result, r.Set.Result},
+		{&c.bizType, r.Set.BizType},
+		{&c.bizCode, r.Set.BizCode},
+		{&c.bizScenario, r.Set.BizScenario},
+		{&c.bizRespCode, r.Set.BizResponseCode},
+	}
+	for _, f := range fields {
+		if *f.dst, err = parseTemplate(f.src); err != nil {
+			return nil, err
+		}
+		e.needBody = e.needBody || f.dst.refBody()
+		e.needHost = e.needHost || f.dst.refers(refHost)
+	}
+	keys := make([]string, 0, len(r.Set.Attrs))
+	for k := range r.Set.Attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		t, err := parseTemplate(r.Set.Attrs[k])
+		if err != nil {
+			return nil, err
+		}
+		c.attrs = append(c.attrs, attr{key: k, val: t})
+		e.needBody = e.needBody || t.refBody()
+		e.needHost = e.needHost || t.refers(refHost)
+	}
+	if r.Set.Status != "" {
+		s, ok := status.Parse(r.Set.Status)
+		if !ok {
+			return nil, fmt.Errorf("unknown status %q", r.Set.Status)
+		}
+		c.respStatus = &s
+	}
+	return c, nil
+}
+
+func compileCond(c Cond, isBody bool) (cond, error) {
+	compiled := cond{Cond: c}
+	switch c.Op {
+	case OP_EQ, OP_NE, OP_PREFIX, OP_CONTAINS, OP_EXISTS, OP_ABSENT:
+	case OP_IN, OP_NOT_IN:
+		for _, v := range strings.Split(c.Value, ",") {
+			compiled.values = append(compiled.values, strings.TrimSpace(v))
+		}
+	default:
+		return compiled, fmt.Errorf("unknown op %q", c.Op)
+	}
+	if isBody {
+		path, err := jsonpath.ParsePath(c.Key)
+		if err != nil {
+			return compiled, err
+		}
+		compiled.path = path
+	}
+	return compiled, nil
+}
+
+func (c *cond) match(v string, found bool) bool {
+	if c.Op == OP_ABSENT {
+		return !found
+	}
+	if !found {
+		return false
+	}
+	switch c.Op {
+	case OP_EQ:
+		return v == c.Value
+	case OP_NE:
+		return v != c.Value
+	case OP_IN, OP_NOT_IN:
+		in := false
+		for _, value := range c.values {
+			if v == value {
+				in = true
+				break
+			}
+		}
+		return in == (c.Op == OP_IN)
+	case OP_PREFIX:
+		return strings.HasPrefix(v, c.Value)
+	case OP_CONTAINS:
+		return strings.Contains(v, c.Value)
+	}
+	// exists
+	return true
+}
+
+func (r *rule) match(in *Input) bool {
+	if r.host != "" && !matchHost(r.host, in.Host) {
+		return false
+	}
+	if r.path != "" && !matchPath(r.path, in.Path) {
+		return false
+	}
+	if r.codes != nil && !r.codes.contains(in.Status) {
+		return false
+	}
+	for i := range r.headers {
+		v := in.header(r.headers[i].Key)
+		if !r.headers[i].match(v, v != "") {
+			return false
+		}
+	}
+	for i := range r.body {
+		v, found := jsonpath.Lookup(in.Body, r.body[i].path)
+		if found && v.Kind == jsonpath.KindNull {
+			found = false
+		}
+		if !r.body[i].match(v.String(), found) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchHost(pattern, host string) bool {
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(strings.ToLower(host), strings.ToLower(pattern[1:]))
+	}
+	return strings.EqualFold(pattern, host)
+}
+
+func matchPath(pattern, path string) bool {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(path, pattern[:len(pattern)-1])
+	}
+	return pattern == path
+}
+
+// Apply the first matched rule to info, return the name of the rule and whether a rule is matched
+func (e *Engine) Apply(in *Input, info *sdk.L7ProtocolInfo) (string, bool) {
+	for _, r := range e.rules {
+		if r.match(in) {
+			r.apply(in, info)
+			return r.name, true
+		}
+	}
+	return "", false
+}
+
+func (r *rule) apply(in *Input, info *sdk.L7ProtocolInfo) {
+	if info.Resp == nil {
+		info.Resp = &sdk.Response{}
+	}
+	resp := info.Resp
+	code := int32(in.Status)
+	if v := r.code.expand(in); v != "" {
+		if c, err := strconv.ParseInt(v, 10, 32); err == nil {
+			code = int32(c)
+		}
+	}
+	resp.Code = &code
+	respStatus := status.HTTP(code)
+	if r.respStatus != nil {
+		respStatus = *r.respStatus
+	}
+	resp.Status = &respStatus
+
+	set := func(dst *string, t template) {
+		if v := t.expand(in); v != "" {
+			*dst = v
+		}
+	}
+	set(&resp.Exception, r.exception)
+	set(&resp.Result, r.result)
+	if v := r.bizType.expand(in); v != "" {
+		if t, err := strconv.ParseUint(v, 10, 8); err == nil {
+			info.BizType = uint8(t)
+		}
+	}
+	set(&info.BizCode, r.bizCode)
+	set(&info.BizScenario, r.bizScenario)
+	set(&info.BizResponseCode, r.bizRespCode)
+	for _, a := range r.attrs {
+		if v := a.val.expand(in); v != "" {
+			info.Kv = append(info.Kv, sdk.KeyVal{Key: a.key, Val: v})
+		}
+	}
+}
+
+func (e *Engine) addHost(flowID uint64, host string) {
+	if e.hosts == nil {
+		e.hosts = make(map[uint64]string)
+	}
+	if _, ok := e.hosts[flowID]; !ok {
+		for len(e.order) > 0 && len(e.order) >= e.MaxFlows {
+			delete(e.hosts, e.order[0])
+			e.order = e.order[1:]
+		}
+		e.order = append(e.order, flowID)
+	}
+	e.hosts[flowID] = host
+}
+
+func (e *Engine) takeHost(flowID uint64) string {
+	host, ok := e.hosts[flowID]
+	if !ok {
+		return ""
+	}
+	delete(e.hosts, flowID)
+	for i, id := range e.order {
+		if id == flowID {
+			e.order = append(e.order[:i], e.order[i+1:]...)
+			break
+		}
+	}
+	return host
+}
+
+// respHandler hook in the responses only, for the rules not need the host
+type respHandler struct {
+	e *Engine
+}
+
+func (h respHandler) OnHttpResp(ctx *sdk.HttpRespCtx) sdk.Action {
+	return h.e.OnHttpResp(ctx)
+}
+
+/*
+Handler return the handler for sdk.SetHandler, which hook in HOOK_POINT_HTTP_REQ only if a rule need the host. the
+Engine itself always hook in both, because the hook points of the handler are derived from the methods.
+*/
+func (e *Engine) Handler() interface{} {
+	if e.needHost {
+		return e
+	}
+	return respHandler{e: e}
+}
+
+// OnHttpReq remember the host of the request for the response, the request is left to the agent
+func (e *Engine) OnHttpReq(ctx *sdk.HttpReqCtx) sdk.Action {
+	if e.needHost && ctx.Host != "" {
+		e.addHost(ctx.BaseCtx.FlowID, ctx.Host)
+	}
+	return sdk.ActionNext()
+}
+
+// OnHttpResp parse the response and apply the rules, the response matched no rule is left to the agent
+func (e *Engine) OnHttpResp(ctx *sdk.HttpRespCtx) sdk.Action {
+	var host string
+	if e.needHost {
+		host = e.takeHost(ctx.BaseCtx.FlowID)
+	}
+	payload, err := ctx.BaseCtx.GetPayload()
+	if err != nil {
+		return sdk.ActionAbortWithErr(err)
+	}
+	m, err := http1.ParseResponse(payload)
+	if err != nil {
+		return sdk.ActionNext()
+	}
+	in := &Input{Host: host, Path: ctx.Endpoint, Status: m.StatusCode, Header: m.HeaderString}
+	if e.needBody {
+		if decoded, err := e.Decoder.DecodeMessage(m); err == nil {
+			in.Body = decoded.Data
+		} else {
+			sdk.Warn("rewrite: decode body fail: %v", err)
+		}
+	}
+	info := &sdk.L7ProtocolInfo{}
+	if _, ok := e.Apply(in, info); !ok {
+		return sdk.ActionNext()
+	}
+	return sdk.ParseActionAbortWithL7Info([]*sdk.L7ProtocolInfo{info})
+}
@@ -0,0 +1,344 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rewrite
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+)
+
+// the rules of example/http_status_rewrite
+const exampleRules = `[
+  {
+    "name": "opt_status_fail_2xx",
+    "match": {"status": "2xx", "body": [{"key": "$.OPT_STATUS", "op": "ne", "value": "SUCCESS"}]},
+    "set": {"code": "500", "result": "${body}", "attrs": {"op_stat": "${body:$.OPT_STATUS}"}}
+  },
+  {
+    "name": "opt_status_fail",
+    "match": {"body": [{"key": "$.OPT_STATUS", "op": "ne", "value": "SUCCESS"}]},
+    "set": {"result": "${body}", "attrs": {"op_stat": "${body:$.OPT_STATUS}"}}
+  },
+  {
+    "name": "opt_status_success",
+    "match": {"body": [{"key": "$.OPT_STATUS", "op": "eq", "value": "SUCCESS"}]},
+    "set": {"attrs": {"op_stat": "${body:$.OPT_STATUS}"}}
+  },
+  {"name": "http_status", "match": {}, "set": {}}
+]`
+
+const bizRules = `[
+  {
+    "name": "biz_code",
+    "match": {
+      "host": "*.example.com",
+      "path": "/api/*",
+      "status": "2xx",
+      "headers": [{"key": "X-Internal", "op": "absent"}],
+      "body": [{"key": "$.code", "op": "not_in", "value": "0, 200"}]
+    },
+    "set": {
+      "code": "${body:$.code}",
+      "exception": "${body:$.code}: ${body:$.msg}",
+      "biz_type": "3",
+      "biz_code": "${body:$.code}",
+      "attrs": {"err_code": "${header:X-Err-Code}", "host": "${host}", "path": "${path}", "status": "${status}"}
+    }
+  },
+  {
+    "name": "timeout",
+    "match": {"headers": [{"key": "X-Timeout", "op": "exists"}]},
+    "set": {"status": "timeout"}
+  }
+]`
+
+func attrsOf(info *sdk.L7ProtocolInfo) string {
+	var kv []string
+	for _, v := range info.Kv {
+		kv = append(kv, v.Key+"="+v.Val)
+	}
+	return strings.Join(kv, ",")
+}
+
+func TestApply(t *testing.T) {
+	example, err := Load([]byte(exampleRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	biz, err := Load([]byte(bizRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !example.needBody || example.needHost || !biz.needBody || !biz.needHost {
+		t.Errorf("unexpected needBody/needHost %v/%v %v/%v", example.needBody, example.needHost, biz.needBody, biz.needHost)
+	}
+
+	cases := []struct {
+		name      string
+		engine    *Engine
+		in        Input
+		rule      string
+		code      int32
+		status    sdk.RespStatus
+		result    string
+		exception string
+		bizType   uint8
+		bizCode   string
+		attrs     string
+	}{
+		{"fail 2xx", example, Input{Status: 200, Body: []byte(`{"OPT_STATUS": "FAIL"}`)},
+			"opt_status_fail_2xx", 500, sdk.RespStatusServerErr, `{"OPT_STATUS": "FAIL"}`, "", 0, "", "op_stat=FAIL"},
+		{"fail truncated", example, Input{Status: 200, Body: []byte(`{"OPT_STATUS": "SOME STATUS", "DA`)},
+			"opt_status_fail_2xx", 500, sdk.RespStatusServerErr, `{"OPT_STATUS": "SOME STATUS", "DA`, "", 0, "", "op_stat=SOME STATUS"},
+		{"fail 4xx", example, Input{Status: 404, Body: []byte(`{"OPT_STATUS": "FAIL"}`)},
+			"opt_status_fail", 404, sdk.RespStatusClientErr, `{"OPT_STATUS": "FAIL"}`, "", 0, "", "op_stat=FAIL"},
+		{"not string", example, Input{Status: 200, Body: []byte(`{"OPT_STATUS": 1}`)},
+			"opt_status_fail_2xx", 500, sdk.RespStatusServerErr, `{"OPT_STATUS": 1}`, "", 0, "", "op_stat=1"},
+		{"success", example, Input{Status: 200, Body: []byte(`{"OPT_STATUS": "SUCCESS"}`)},
+			"opt_status_success", 200, sdk.RespStatusOk, "", "", 0, "", "op_stat=SUCCESS"},
+		{"null", example, Input{Status: 503, Body: []byte(`{"OPT_STATUS": null}`)},
+			"http_status", 503, sdk.RespStatusServerErr, "", "", 0, "", ""},
+		{"no body", example, Input{Status: 302},
+			"http_status", 302, sdk.RespStatusOk, "", "", 0, "", ""},
+
+		{"biz", biz, Input{Host: "api.example.com:8080", Path: "/api/pay?id=1", Status: 200,
+			Header: func(name string) string {
+				if name == "X-Err-Code" {
+					return "E1"
+				}
+				return ""
+			},
+			Body: []byte(`{"code": 4001, "msg": "no \"money\""}`)},
+			"biz_code", 4001, sdk.RespStatusServerErr, "", `4001: no "money"`, 3, "4001",
+			"err_code=E1,host=api.example.com:8080,path=/api/pay?id=1,status=200"},
+		{"biz ok code", biz, Input{Host: "api.example.com", Path: "/api/pay", Status: 200, Body: []byte(`{"code": 200}`)},
+			"", 0, 0, "", "", 0, "", ""},
+		{"biz other host", biz, Input{Host: "example.org", Path: "/api/pay", Status: 200, Body: []byte(`{"code": 1}`)},
+			"", 0, 0, "", "", 0, "", ""},
+		{"biz other path", biz, Input{Host: "a.example.com", Path: "/web/pay", Status: 200, Body: []byte(`{"code": 1}`)},
+			"", 0, 0, "", "", 0, "", ""},
+		{"biz 5xx", biz, Input{Host: "a.example.com", Path: "/api/pay", Status: 500, Body: []byte(`{"code": 1}`)},
+			"", 0, 0, "", "", 0, "", ""},
+		{"biz internal", biz, Input{Host: "a.example.com", Path: "/api/pay", Status: 200, Body: []byte(`{"code": 1}`),
+			Header: func(name string) string { return "1" }},
+			"timeout", 200, sdk.RespStatusTimeout, "", "", 0, "", ""},
+		{"biz absent code", biz, Input{Host: "a.example.com", Path: "/api/pay", Status: 200, Body: []byte(`{}`)},
+			"", 0, 0, "", "", 0, "", ""},
+	}
+	for _, c := range cases {
+		info := &sdk.L7ProtocolInfo{}
+		name, ok := c.engine.Apply(&c.in, info)
+		if name != c.rule || ok != (c.rule != "") {
+			t.Errorf("%s: got rule %q, want %q", c.name, name, c.rule)
+			continue
+		}
+		if !ok {
+			if info.Resp != nil {
+				t.Errorf("%s: info is filled without rule", c.name)
+			}
+			continue
+		}
+		resp := info.Resp
+		if *resp.Code != c.code || *resp.Status != c.status || resp.Result != c.result || resp.Exception != c.exception ||
+			info.BizType != c.bizType || info.BizCode != c.bizCode || attrsOf(info) != c.attrs {
+			t.Errorf("%s: got code %d status %d result %q exception %q biz %d %q attrs %q", c.name, *resp.Code,
+				*resp.Status, resp.Result, resp.Exception, info.BizType, info.BizCode, attrsOf(info))
+		}
+	}
+}
+
+func TestCondMatch(t *testing.T) {
+	cases := []struct {
+		op    string
+		value string
+		v     string
+		found bool
+		want  bool
+	}{
+		{OP_EQ, "a", "a", true, true},
+		{OP_EQ, "a", "b", true, false},
+		{OP_NE, "a", "b", true, true},
+		{OP_NE, "a", "", false, false},
+		{OP_IN, "a, b", "b", true, true},
+		{OP_IN, "a, b", "c", true, false},
+		{OP_NOT_IN, "a, b", "c", true, true},
+		{OP_NOT_IN, "a, b", "c", false, false},
+		{OP_PREFIX, "ab", "abc", true, true},
+		{OP_PREFIX, "ab", "cab", true, false},
+		{OP_CONTAINS, "ab", "cabc", true, true},
+		{OP_EXISTS, "", "", true, true},
+		{OP_EXISTS, "", "", false, false},
+		{OP_ABSENT, "", "", false, true},
+		{OP_ABSENT, "", "x", true, false},
+	}
+	for _, c := range cases {
+		compiled, err := compileCond(Cond{Key: "k", Op: c.op, Value: c.value}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := compiled.match(c.v, c.found); got != c.want {
+			t.Errorf("%s %q on %q found %v: got %v, want %v", c.op, c.value, c.v, c.found, got, c.want)
+		}
+	}
+}
+
+func TestMatchHostPath(t *testing.T) {
+	hosts := []struct {
+		pattern, host string
+		want          bool
+	}{
+		{"example.com", "Example.COM", true},
+		{"example.com", "example.com:80", true},
+		{"*.example.com", "a.b.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"*.example.com", "badexample.com", false},
+		{"::1", "[::1]:80", false},
+		{"[::1]", "[::1]", true},
+	}
+	for _, c := range hosts {
+		if got := matchHost(c.pattern, c.host); got != c.want {
+			t.Errorf("host %q on %q: got %v, want %v", c.pattern, c.host, got, c.want)
+		}
+	}
+	paths := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/api", "/api?x=1", true},
+		{"/api", "/api/x", false},
+		{"/api/*", "/api/x", true},
+		{"/api/*", "/apix", false},
+		{"*", "/", true},
+	}
+	for _, c := range paths {
+		if got := matchPath(c.pattern, c.path); got != c.want {
+			t.Errorf("path %q on %q: got %v, want %v", c.pattern, c.path, got, c.want)
+		}
+	}
+}
+
+func TestHosts(t *testing.T) {
+	e, err := Load([]byte(bizRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.MaxFlows = 2
+	req := func(flowID uint64, host string) {
+		ctx := &sdk.HttpReqCtx{Host: host}
+		ctx.BaseCtx.FlowID = flowID
+		e.OnHttpReq(ctx)
+	}
+	req(1, "a")
+	req(2, "b")
+	req(1, "c")
+	req(3, "")
+	if len(e.hosts) != 2 || len(e.order) != 2 {
+		t.Errorf("got %d hosts %d order, want 2", len(e.hosts), len(e.order))
+	}
+	// the oldest flow is dropped
+	req(4, "d")
+	if h := e.takeHost(1); h != "" {
+		t.Errorf("flow 1: got %q, want dropped", h)
+	}
+	if h := e.takeHost(2); h != "b" {
+		t.Errorf("flow 2: got %q, want b", h)
+	}
+	if h := e.takeHost(2); h != "" {
+		t.Errorf("flow 2 again: got %q, want empty", h)
+	}
+	if h := e.takeHost(4); h != "d" || len(e.hosts) != 0 || len(e.order) != 0 {
+		t.Errorf("flow 4: got %q, %d hosts %d order left", h, len(e.hosts), len(e.order))
+	}
+
+	// the host is not remembered without host rules
+	e, _ = Load([]byte(exampleRules))
+	req(1, "a")
+	if len(e.hosts) != 0 {
+		t.Errorf("got %d hosts, want 0", len(e.hosts))
+	}
+}
+
+func TestHandler(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		rules string
+		req   bool
+	}{
+		{"no host", exampleRules, false},
+		{"host", bizRules, true},
+	} {
+		e, err := Load([]byte(c.rules))
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := e.Handler()
+		_, req := h.(sdk.HttpReqHandler)
+		_, resp := h.(sdk.HttpRespHandler)
+		if req != c.req || !resp {
+			t.Errorf("%s: got request %v response %v, want %v true", c.name, req, resp, c.req)
+		}
+	}
+}
+
+func TestLoadError(t *testing.T) {
+	cases := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{"json", `{`, "bad config"},
+		{"status", `[{"name": "a", "match": {"status": "2xy"}}]`, "rule a"},
+		{"op", `[{"match": {"headers": [{"key": "k", "op": "gt"}]}}]`, "rule 0"},
+		{"body path", `[{"match": {"body": [{"key": "$..a", "op": "exists"}]}}]`, "invalid path"},
+		{"template", `[{"set": {"result": "${body"}}]`, "unclosed"},
+		{"reference", `[{"set": {"attrs": {"a": "${query}"}}}]`, "unknown reference"},
+		{"set status", `[{"set": {"status": "bad"}}]`, "unknown status"},
+	}
+	for _, c := range cases {
+		if _, err := Load([]byte(c.config)); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: got error %v, want %q", c.name, err, c.err)
+		}
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	in := &Input{Status: 201, Path: "/p", Body: []byte(`{"a": "x", "n": null}`)}
+	cases := []struct {
+		template string
+		want     string
+	}{
+		{"literal", "literal"},
+		{"${status}", "201"},
+		{"${path}:${status}", "/p:201"},
+		{"[${body:$.a}]", "[x]"},
+		{"${body:$.n}", ""},
+		{"${header:X}", ""},
+		{"${body}", `{"a": "x", "n": null}`},
+		{"", ""},
+	}
+	for _, c := range cases {
+		tmpl, err := parseTemplate(c.template)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tmpl.expand(in); got != c.want {
+			t.Errorf("%q: got %q, want %q", c.template, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rewrite
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/jsonpath"
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/status"
+)
+
+type refKind uint8
+
+const (
+	refNone refKind = iota
+	refBody
+	refBodyPath
+	refHeader
+	refStatus
+	refPath
+	refHost
+)
+
+type part struct {
+	literal string
+	ref     refKind
+	arg     string
+	path    jsonpath.Path
+}
+
+// template is the literals and references such as "${body:$.code}: ${body:$.msg}"
+type template []part
+
+func parseTemplate(s string) (template, error) {
+	var t template
+	for s != "" {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			t = append(t, part{literal: s})
+			break
+		}
+		if start > 0 {
+			t = append(t, part{literal: s[:start]})
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed reference in %q", s)
+		}
+		p, err := parseRef(s[start+2 : start+end])
+		if err != nil {
+			return nil, err
+		}
+		t = append(t, p)
+		s = s[start+end+1:]
+	}
+	return t, nil
+}
+
+func parseRef(ref string) (part, error) {
+	name, arg, _ := strings.Cut(ref, ":")
+	switch name {
+	case "body":
+		if arg == "" {
+			return part{ref: refBody}, nil
+		}
+		path, err := jsonpath.ParsePath(arg)
+		if err != nil {
+			return part{}, err
+		}
+		return part{ref: refBodyPath, path: path}, nil
+	case "header":
+		return part{ref: refHeader, arg: arg}, nil
+	case "status":
+		return part{ref: refStatus}, nil
+	case "path":
+		return part{ref: refPath}, nil
+	case "host":
+		return part{ref: refHost}, nil
+	}
+	return part{}, fmt.Errorf("unknown reference ${%s}", ref)
+}
+
+func (t template) refBody() bool {
+	return t.refers(refBody, refBodyPath)
+}
+
+func (t template) refers(kinds ...refKind) bool {
+	for _, p := range t {
+		for _, k := range kinds {
+			if p.ref == k {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// expand the template, the template with only one reference return the value as is
+func (t template) expand(in *Input) string {
+	if len(t) == 1 {
+		return t[0].expand(in)
+	}
+	var b strings.Builder
+	for _, p := range t {
+		b.WriteString(p.expand(in))
+	}
+	return b.String()
+}
+
+func (p *part) expand(in *Input) string {
+	switch p.ref {
+	case refBody:
+		return string(in.Body)
+	case refBodyPath:
+		if v, ok := jsonpath.Lookup(in.Body, p.path); ok && v.Kind != jsonpath.KindNull {
+			return v.String()
+		}
+		return ""
+	case refHeader:
+		return in.header(p.arg)
+	case refStatus:
+		return strconv.Itoa(in.Status)
+	case refPath:
+		return in.Path
+	case refHost:
+		return in.Host
+	}
+	return p.literal
+}
+
+// ranges of the status code, nil match anything
+type ranges []status.Range
+
+func (r ranges) contains(code int) bool {
+	for _, rng := range r {
+		if rng.Contains(int64(code)) {
+			return true
+		}
+	}
+	return false
+}
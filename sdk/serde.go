@@ -19,8 +19,10 @@ package sdk
 import (
 	"encoding/binary"
 	"net"
+	"net/netip"
 	"strconv"
 
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/bin"
 	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk/pb"
 	"google.golang.org/protobuf/proto"
 )
@@ -59,7 +61,7 @@ dst_ip:      4/16 bytes
 src_port:    2 bytes
 dst_port:    2 bytes
 
-l4 protocol: 1 byte, 6/17 indicate udp/tcp
+l4 protocol: 1 byte, 6/17 indicate tcp/udp
 l7 protocol: 1 byte
 
 ebpf type:   1 byte
@@ -75,87 +77,76 @@ proc name: 		$(proc name len) len
 flow_id:     8 bytes
 
 buf_size:    2 bytes
+
+the extend ctx, only the agent support ABI_VERSION_EXT_CTX will send, newer version may append fields after it:
+
+ext version:      1 byte, 2 indicate ABI_VERSION_EXT_CTX
+pid:              4 bytes
+tid:              4 bytes
+coroutine id:     8 bytes
+socket id:        8 bytes
+cap seq:          8 bytes
+tcp seq:          4 bytes
+tcp ack:          4 bytes
+container id len: 1 byte
+container id:     $(container id len) bytes
+pod name len:     1 byte
+pod name:         $(pod name len) bytes
 */
 func deserializeParseCtx(b []byte) *ParseCtx {
 	ctx := &ParseCtx{}
-	off := 0
-	if off+1 > len(b) {
-		Error("deserialize parse ctx ip type fail")
-		return nil
-	}
-	switch b[0] {
+	r := bin.NewReader(b)
+	switch ipType := r.U8(); ipType {
 	case 4:
-		off += 1
-		if off+12 > len(b) {
+		src, dst := r.Bytes(4), r.Bytes(4)
+		ctx.SrcPort, ctx.DstPort = r.U16(), r.U16()
+		if r.Err() != nil {
 			Error("deserialize parse ctx ipv4 fail")
 			return nil
 		}
 		ctx.SrcIP = net.IPAddr{
-			IP: b[1:5],
+			IP: src,
 		}
 		ctx.DstIP = net.IPAddr{
-			IP: b[5:9],
+			IP: dst,
 		}
-		ctx.SrcPort = binary.BigEndian.Uint16(b[9:11])
-		ctx.DstPort = binary.BigEndian.Uint16(b[11:13])
-		off += 12
+		ctx.srcAddr = netip.AddrFrom4([4]byte(src))
+		ctx.dstAddr = netip.AddrFrom4([4]byte(dst))
 	case 6:
-		off += 1
-		if off+36 > len(b) {
+		src, dst := r.Bytes(16), r.Bytes(16)
+		ctx.SrcPort, ctx.DstPort = r.U16(), r.U16()
+		if r.Err() != nil {
 			Error("deserialize parse ctx ipv6 fail")
 			return nil
 		}
-
 		ctx.SrcIP = net.IPAddr{
-			IP: b[1:17],
+			IP: src,
 		}
 		ctx.DstIP = net.IPAddr{
-			IP: b[17:33],
+			IP: dst,
 		}
-		ctx.SrcPort = binary.BigEndian.Uint16(b[33:35])
-		ctx.DstPort = binary.BigEndian.Uint16(b[35:37])
-		off += 36
+		// the v4-mapped address is the same flow as the v4 one, as toAddr
+		ctx.srcAddr = netip.AddrFrom16([16]byte(src)).Unmap()
+		ctx.dstAddr = netip.AddrFrom16([16]byte(dst)).Unmap()
 	default:
-		Error("receive unexpected ip type " + strconv.FormatInt(int64(b[0]), 10))
+		if r.Err() != nil {
+			Error("deserialize parse ctx ip type fail")
+		} else {
+			Error("receive unexpected ip type " + strconv.FormatInt(int64(ipType), 10))
+		}
 		return nil
 	}
 
-	if off+13 > len(b) {
+	// unknown l4 protocol and ebpf type are preserved, check with IsKnown() if necessary
+	ctx.L4 = L4Protocol(r.U8())
+	ctx.L7 = r.U8()
+	ctx.EbpfType = EbpfType(r.U8())
+	ctx.Time = r.U64()
+	direction := Direction(r.U8())
+	if r.Err() != nil {
 		Error("deserialize parse ctx fail")
 		return nil
 	}
-
-	l4 := L4Protocol(b[off])
-	switch l4 {
-	case UDP, TCP:
-		ctx.L4 = l4
-	default:
-		Error("receive unexpected l4 protocol " + strconv.Itoa(int(l4)))
-		return nil
-	}
-
-	ctx.L7 = b[off+1]
-	off += 2
-
-	ebpfType := EbpfType(b[off])
-	switch ebpfType {
-	case EbpfTypeTracePoint,
-		EbpfTypeTlsUprobe,
-		EbpfTypeGoHttp2UprobeDATA,
-		EbpfTypeGoHttp2Uprobe,
-		EbpfTypeUnixSocket,
-		EbpfTypeNone:
-		ctx.EbpfType = ebpfType
-	default:
-		Error("receive unexpected ebpf type " + strconv.Itoa(int(ebpfType)))
-		return nil
-	}
-	off += 1
-
-	ctx.Time = binary.BigEndian.Uint64(b[off : off+8])
-	off += 8
-
-	direction := Direction(b[off])
 	switch direction {
 	case DirectionRequest, DirectionResponse:
 		ctx.Direction = direction
@@ -163,40 +154,54 @@ func deserializeParseCtx(b []byte) *ParseCtx {
 		Error("receive unexpected direction " + strconv.Itoa(int(direction)))
 		return nil
 	}
-	off += 1
 
-	procNameLen := int(b[off])
-	off += 1
-	if off+procNameLen > len(b) {
+	ctx.ProcName = r.String8()
+	if r.Err() != nil {
 		Error("deserialize parse ctx proc name fail")
 		return nil
 	}
-	if procNameLen != 0 {
-		ctx.ProcName = string(b[off : off+procNameLen])
-		off += procNameLen
-	}
 
-	// flow id
-	if off+8 > len(b) {
+	ctx.FlowID = r.U64()
+	if r.Err() != nil {
 		Error("deserialize parse ctx flow id fail")
 		return nil
 	}
 
-	ctx.FlowID = binary.BigEndian.Uint64(b[off : off+8])
-	off += 8
-
-	// buffer size
-	if off+2 > len(b) {
-		Error("deserialize parse ctx flow id fail")
+	ctx.BufSize = r.U16()
+	if r.Err() != nil {
+		Error("deserialize parse ctx buf size fail")
 		return nil
 	}
 
-	ctx.BufSize = binary.BigEndian.Uint16(b[off : off+2])
-	off += 2
+	if r.Len() > 0 && uint32(r.Peek(1)[0]) >= ABI_VERSION_EXT_CTX {
+		r.Skip(1)
+		deserializeParseCtxExt(ctx, r)
+	}
 
 	return ctx
 }
 
+// the extend ctx is optional, the ctx is still usable without it, so only log the fail
+func deserializeParseCtxExt(ctx *ParseCtx, r *bin.Reader) {
+	pid, tid := r.U32(), r.U32()
+	coroutineID, socketID, capSeq := r.U64(), r.U64(), r.U64()
+	tcpSeq, tcpAck := r.U32(), r.U32()
+	containerID, podName := r.String8(), r.String8()
+	if r.Err() != nil {
+		Error("deserialize parse ctx ext fail")
+		return
+	}
+	ctx.PID = pid
+	ctx.TID = tid
+	ctx.CoroutineID = coroutineID
+	ctx.SocketID = socketID
+	ctx.CapSeq = capSeq
+	ctx.TcpSeq = tcpSeq
+	ctx.TcpAck = tcpAck
+	ctx.ContainerID = containerID
+	ctx.PodName = podName
+}
+
 /*
 hook_point:	  2 byte
 type_code:	  4 byte
@@ -204,8 +209,7 @@ protobuf_len: 4 byte
 protobuf:	  $(protobuf_len) byte
 */
 func deserializeCustomMessageCtx(paramBuf, CustomMessageBuf []byte) *CustomMessageCtx {
-	msgBufLen := len(CustomMessageBuf)
-	if msgBufLen < 10 {
+	if len(CustomMessageBuf) < 10 {
 		return nil
 	}
 
@@ -216,18 +220,48 @@ func deserializeCustomMessageCtx(paramBuf, CustomMessageBuf []byte) *CustomMessa
 	ctx := &CustomMessageCtx{
 		BaseCtx: *baseCtx,
 	}
-	ctx.HookPoint = binary.BigEndian.Uint16(CustomMessageBuf[:2])
-	ctx.TypeCode = binary.BigEndian.Uint32(CustomMessageBuf[2:6])
-	len := binary.BigEndian.Uint32(CustomMessageBuf[6:10])
-	if len+10 > uint32(msgBufLen) {
+	r := bin.NewReader(CustomMessageBuf)
+	ctx.HookPoint = r.U16()
+	ctx.TypeCode = r.U32()
+	ctx.Payload = r.Bytes32()
+	if r.Err() != nil {
 		Error("CustomMessageCtx deserialize fail")
 		return nil
 	}
-	ctx.Payload = CustomMessageBuf[10 : 10+len]
 
 	return ctx
 }
 
+/*
+hook_point: 2 bytes, SessionFilter
+keep:       1 byte, 0/1 indicate drop/keep
+*/
+func serializeSessionFilterResult(keep bool) []byte {
+	buf := make([]byte, 3)
+	binary.BigEndian.PutUint16(buf[:2], SessionFilter)
+	if keep {
+		buf[2] = 1
+	}
+	return buf
+}
+
+/*
+hook_point: 2 bytes, Sampling
+decision:   1 byte, 1/2/3 indicate keep/drop/rate
+rate:       4 bytes, parts per million, only meaningful when decision is rate
+*/
+func serializeSamplingResult(result SamplingResult) []byte {
+	buf := make([]byte, 7)
+	binary.BigEndian.PutUint16(buf[:2], Sampling)
+	buf[2] = uint8(result.Decision)
+	rate := result.Rate
+	if rate > SAMPLING_RATE_SCALE {
+		rate = SAMPLING_RATE_SCALE
+	}
+	binary.BigEndian.PutUint32(buf[3:7], rate)
+	return buf
+}
+
 /*
 path len:  2 byte
 path:      $(path len) byte
@@ -242,8 +276,7 @@ referer len:  2 byte
 referer:      $(referer) byte
 */
 func deserializeHttpReqCtx(paramBuf, httpReqBuf []byte) *HttpReqCtx {
-	reqBufLen := len(httpReqBuf)
-	if reqBufLen < 8 {
+	if len(httpReqBuf) < 8 {
 		return nil
 	}
 
@@ -254,32 +287,16 @@ func deserializeHttpReqCtx(paramBuf, httpReqBuf []byte) *HttpReqCtx {
 	ctx := &HttpReqCtx{
 		BaseCtx: *baseCtx,
 	}
-	off := 0
-	s := [4]string{}
-
-	for i := 0; i < len(s); i++ {
-		if off+2 > reqBufLen {
-			Error("httpReqCtx deserialize fail")
-			return nil
-		}
-		strLen := int(binary.BigEndian.Uint16(httpReqBuf[off : off+2]))
-		off += 2
-		if strLen == 0 {
-			continue
-		}
-		if off+strLen > reqBufLen {
-			Error("httpReqCtx deserialize fail")
-			return nil
-		}
-		s[i] = string(httpReqBuf[off : off+strLen])
-		off += strLen
+	r := bin.NewReader(httpReqBuf)
+	ctx.Path = r.String16()
+	ctx.Host = r.String16()
+	ctx.UserAgent = r.String16()
+	ctx.Referer = r.String16()
+	if r.Err() != nil {
+		Error("httpReqCtx deserialize fail")
+		return nil
 	}
 
-	ctx.Path = s[0]
-	ctx.Host = s[1]
-	ctx.UserAgent = s[2]
-	ctx.Referer = s[3]
-
 	return ctx
 
 }
@@ -292,12 +309,13 @@ endpoint len: 2 bytes
 endpoint:     $(endpoint len) bytes
 */
 func deserializeHttpRespCtx(paramBuf, httpRespBuf []byte) *HttpRespCtx {
-	respBufLen := len(httpRespBuf)
-	if respBufLen < 3 {
+	r := bin.NewReader(httpRespBuf)
+	code := r.U16()
+	status := RespStatus(r.U8())
+	if r.Err() != nil {
 		return nil
 	}
 
-	status := RespStatus(httpRespBuf[2])
 	switch status {
 	case RespStatusOk, RespStatusTimeout, RespStatusServerErr, RespStatusClientErr, RespStatusUnknown:
 	default:
@@ -312,16 +330,13 @@ func deserializeHttpRespCtx(paramBuf, httpRespBuf []byte) *HttpRespCtx {
 	ctx := &HttpRespCtx{
 		BaseCtx: *baseCtx,
 		Status:  status,
-		Code:    binary.BigEndian.Uint16(httpRespBuf[:2]),
+		Code:    code,
 	}
 
 	// endpoint is present only when new agent sends it (buf len > 3)
-	off := 3
-	if off+2 <= respBufLen {
-		endpointLen := int(binary.BigEndian.Uint16(httpRespBuf[off : off+2]))
-		off += 2
-		if endpointLen > 0 && off+endpointLen <= respBufLen {
-			ctx.Endpoint = string(httpRespBuf[off : off+endpointLen])
+	if r.Len() >= 2 {
+		if endpoint := r.String16(); r.Err() == nil {
+			ctx.Endpoint = endpoint
 		}
 	}
 
@@ -341,6 +356,9 @@ func serializeL7ProtocolInfo(infos []*L7ProtocolInfo, direction Direction) []byt
 	}
 
 	for _, info := range infos {
+		if vmRedactor != nil {
+			info = vmRedactor.redactInfo(info)
+		}
 		start := off
 		// leave 2 bytes as length, 2 bytes as magic (PB)
 		off += 4
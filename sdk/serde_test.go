@@ -0,0 +1,190 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+type parseCtxLayout struct {
+	ipv6      bool
+	l4        uint8
+	l7        uint8
+	ebpfType  uint8
+	direction uint8
+	procName  string
+	// the extend ctx appended by newer agent after buf_size
+	trailing []byte
+	// the v6 addresses, fe80::1 and fe80::2 if nil
+	src, dst net.IP
+}
+
+func (l parseCtxLayout) serialize() []byte {
+	var b []byte
+	if l.ipv6 {
+		b = append(b, 6)
+		src, dst := l.src, l.dst
+		if src == nil {
+			src, dst = net.ParseIP("fe80::1"), net.ParseIP("fe80::2")
+		}
+		b = append(b, src.To16()...)
+		b = append(b, dst.To16()...)
+	} else {
+		b = append(b, 4)
+		b = append(b, net.ParseIP("10.0.0.1").To4()...)
+		b = append(b, net.ParseIP("10.0.0.2").To4()...)
+	}
+	b = binary.BigEndian.AppendUint16(b, 12345)
+	b = binary.BigEndian.AppendUint16(b, 80)
+	b = append(b, l.l4, l.l7, l.ebpfType)
+	b = binary.BigEndian.AppendUint64(b, 1700000000000000)
+	b = append(b, l.direction, uint8(len(l.procName)))
+	b = append(b, l.procName...)
+	b = binary.BigEndian.AppendUint64(b, 42)
+	b = binary.BigEndian.AppendUint16(b, 1500)
+	return append(b, l.trailing...)
+}
+
+func serializeParseCtxExt(version uint8, trailing []byte) []byte {
+	b := []byte{version}
+	b = binary.BigEndian.AppendUint32(b, 100)
+	b = binary.BigEndian.AppendUint32(b, 101)
+	b = binary.BigEndian.AppendUint64(b, 102)
+	b = binary.BigEndian.AppendUint64(b, 103)
+	b = binary.BigEndian.AppendUint64(b, 104)
+	b = binary.BigEndian.AppendUint32(b, 105)
+	b = binary.BigEndian.AppendUint32(b, 106)
+	b = append(b, 6)
+	b = append(b, "3f2a9c"...)
+	b = append(b, 9)
+	b = append(b, "web-7d9f5"...)
+	return append(b, trailing...)
+}
+
+func TestDeserializeParseCtx(t *testing.T) {
+	ebpfTypes := []struct {
+		ebpfType uint8
+		known    bool
+	}{
+		{uint8(EbpfTypeTracePoint), true},
+		{uint8(EbpfTypeTlsUprobe), true},
+		{uint8(EbpfTypeGoHttp2Uprobe), true},
+		{uint8(EbpfTypeGoHttp2UprobeDATA), true},
+		{uint8(EbpfTypeIOEvent), true},
+		{uint8(EbpfTypeOtherUprobe), true},
+		{uint8(EbpfTypeUnixSocket), true},
+		{uint8(EbpfTypeNone), true},
+		{3, false},
+		{200, false},
+	}
+	l4s := []struct {
+		l4    uint8
+		known bool
+	}{
+		{uint8(TCP), true},
+		{uint8(UDP), true},
+		{132, false},
+	}
+	trailings := map[string][]byte{
+		"initial layout": nil,
+		"ext layout":     serializeParseCtxExt(uint8(ABI_VERSION_EXT_CTX), nil),
+		"future layout":  serializeParseCtxExt(uint8(ABI_VERSION_EXT_CTX)+1, []byte{0, 0, 0, 1, 0xff}),
+	}
+
+	for layoutName, trailing := range trailings {
+		for _, ipv6 := range []bool{false, true} {
+			for _, e := range ebpfTypes {
+				for _, l4 := range l4s {
+					layout := parseCtxLayout{
+						ipv6:      ipv6,
+						l4:        l4.l4,
+						l7:        uint8(L7ProtocolHttp1),
+						ebpfType:  e.ebpfType,
+						direction: uint8(DirectionResponse),
+						procName:  "nginx",
+						trailing:  trailing,
+					}
+					ctx := deserializeParseCtx(layout.serialize())
+					if ctx == nil {
+						t.Fatalf("%s ipv6=%v ebpf=%d l4=%d: deserialize fail", layoutName, ipv6, e.ebpfType, l4.l4)
+					}
+					if uint8(ctx.EbpfType) != e.ebpfType || ctx.EbpfType.IsKnown() != e.known {
+						t.Errorf("%s: ebpf type got %d known %v, want %d known %v",
+							layoutName, ctx.EbpfType, ctx.EbpfType.IsKnown(), e.ebpfType, e.known)
+					}
+					if uint8(ctx.L4) != l4.l4 || ctx.L4.IsKnown() != l4.known {
+						t.Errorf("%s: l4 got %d known %v, want %d known %v",
+							layoutName, ctx.L4, ctx.L4.IsKnown(), l4.l4, l4.known)
+					}
+					if ctx.SrcPort != 12345 || ctx.DstPort != 80 ||
+						ctx.L7Protocol() != L7ProtocolHttp1 ||
+						ctx.Time != 1700000000000000 ||
+						ctx.Direction != DirectionResponse ||
+						ctx.ProcName != "nginx" ||
+						ctx.FlowID != 42 ||
+						ctx.BufSize != 1500 {
+						t.Errorf("%s ipv6=%v: unexpected ctx %+v", layoutName, ipv6, ctx)
+					}
+					if trailing == nil {
+						if ctx.PID != 0 || ctx.SocketID != 0 || ctx.ContainerID != "" || ctx.PodName != "" {
+							t.Errorf("%s: unexpected ext ctx %+v", layoutName, ctx)
+						}
+					} else if ctx.PID != 100 || ctx.TID != 101 ||
+						ctx.CoroutineID != 102 ||
+						ctx.SocketID != 103 ||
+						ctx.CapSeq != 104 ||
+						ctx.TcpSeq != 105 ||
+						ctx.TcpAck != 106 ||
+						ctx.ContainerID != "3f2a9c" ||
+						ctx.PodName != "web-7d9f5" {
+						t.Errorf("%s: unexpected ext ctx %+v", layoutName, ctx)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDeserializeParseCtxMalformed(t *testing.T) {
+	valid := parseCtxLayout{l4: uint8(TCP), direction: uint8(DirectionRequest), procName: "curl"}.serialize()
+	for i := 0; i < len(valid); i++ {
+		if ctx := deserializeParseCtx(valid[:i]); ctx != nil {
+			t.Errorf("truncated at %d: expect fail", i)
+		}
+	}
+
+	badIPType := append([]byte{}, valid...)
+	badIPType[0] = 5
+	if deserializeParseCtx(badIPType) != nil {
+		t.Error("unexpected ip type: expect fail")
+	}
+
+	badDirection := parseCtxLayout{l4: uint8(TCP), direction: 2}.serialize()
+	if deserializeParseCtx(badDirection) != nil {
+		t.Error("unexpected direction: expect fail")
+	}
+
+	// the truncated extend ctx is dropped but the base ctx is still usable
+	ext := serializeParseCtxExt(uint8(ABI_VERSION_EXT_CTX), nil)
+	truncatedExt := parseCtxLayout{l4: uint8(TCP), trailing: ext[:20]}.serialize()
+	ctx := deserializeParseCtx(truncatedExt)
+	if ctx == nil || ctx.FlowID != 42 || ctx.PID != 0 {
+		t.Errorf("truncated ext: unexpected ctx %+v", ctx)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package status
+
+import (
+	"strconv"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+)
+
+// the grpc status codes, see https://grpc.github.io/grpc/core/md_doc_statuscodes.html
+const (
+	GRPC_OK                  int32 = 0
+	GRPC_CANCELLED           int32 = 1
+	GRPC_UNKNOWN             int32 = 2
+	GRPC_INVALID_ARGUMENT    int32 = 3
+	GRPC_DEADLINE_EXCEEDED   int32 = 4
+	GRPC_NOT_FOUND           int32 = 5
+	GRPC_ALREADY_EXISTS      int32 = 6
+	GRPC_PERMISSION_DENIED   int32 = 7
+	GRPC_RESOURCE_EXHAUSTED  int32 = 8
+	GRPC_FAILED_PRECONDITION int32 = 9
+	GRPC_ABORTED             int32 = 10
+	GRPC_OUT_OF_RANGE        int32 = 11
+	GRPC_UNIMPLEMENTED       int32 = 12
+	GRPC_INTERNAL            int32 = 13
+	GRPC_UNAVAILABLE         int32 = 14
+	GRPC_DATA_LOSS           int32 = 15
+	GRPC_UNAUTHENTICATED     int32 = 16
+)
+
+var grpcNames = [...]string{
+	"OK",
+	"CANCELLED",
+	"UNKNOWN",
+	"INVALID_ARGUMENT",
+	"DEADLINE_EXCEEDED",
+	"NOT_FOUND",
+	"ALREADY_EXISTS",
+	"PERMISSION_DENIED",
+	"RESOURCE_EXHAUSTED",
+	"FAILED_PRECONDITION",
+	"ABORTED",
+	"OUT_OF_RANGE",
+	"UNIMPLEMENTED",
+	"INTERNAL",
+	"UNAVAILABLE",
+	"DATA_LOSS",
+	"UNAUTHENTICATED",
+}
+
+// GRPC classify the grpc-status, CANCELLED, INVALID_ARGUMENT, NOT_FOUND to OUT_OF_RANGE and UNAUTHENTICATED are
+// client error, OK is ok and the others are server error
+func GRPC(code int32) sdk.RespStatus {
+	return lookupOr(TABLE_GRPC, GRPCTable).Status(int64(code))
+}
+
+// GRPCName return the name such as NOT_FOUND, the unknown code return the number
+func GRPCName(code int32) string {
+	if code >= 0 && int(code) < len(grpcNames) {
+		return grpcNames[code]
+	}
+	return strconv.Itoa(int(code))
+}
+
+// GRPCCode is the reverse of GRPCName
+func GRPCCode(name string) (int32, bool) {
+	for i, n := range grpcNames {
+		if n == name {
+			return int32(i), true
+		}
+	}
+	return 0, false
+}
+
+// the status byte of the dubbo response header
+const (
+	DUBBO_OK                                uint8 = 20
+	DUBBO_CLIENT_TIMEOUT                    uint8 = 30
+	DUBBO_SERVER_TIMEOUT                    uint8 = 31
+	DUBBO_BAD_REQUEST                       uint8 = 40
+	DUBBO_BAD_RESPONSE                      uint8 = 50
+	DUBBO_SERVICE_NOT_FOUND                 uint8 = 60
+	DUBBO_SERVICE_ERROR                     uint8 = 70
+	DUBBO_SERVER_ERROR                      uint8 = 80
+	DUBBO_CLIENT_ERROR                      uint8 = 90
+	DUBBO_SERVER_THREADPOOL_EXHAUSTED_ERROR uint8 = 100
+)
+
+var dubboNames = map[uint8]string{
+	DUBBO_OK:                                "OK",
+	DUBBO_CLIENT_TIMEOUT:                    "CLIENT_TIMEOUT",
+	DUBBO_SERVER_TIMEOUT:                    "SERVER_TIMEOUT",
+	DUBBO_BAD_REQUEST:                       "BAD_REQUEST",
+	DUBBO_BAD_RESPONSE:                      "BAD_RESPONSE",
+	DUBBO_SERVICE_NOT_FOUND:                 "SERVICE_NOT_FOUND",
+	DUBBO_SERVICE_ERROR:                     "SERVICE_ERROR",
+	DUBBO_SERVER_ERROR:                      "SERVER_ERROR",
+	DUBBO_CLIENT_ERROR:                      "CLIENT_ERROR",
+	DUBBO_SERVER_THREADPOOL_EXHAUSTED_ERROR: "SERVER_THREADPOOL_EXHAUSTED_ERROR",
+}
+
+// Dubbo classify the status byte, CLIENT_TIMEOUT, BAD_REQUEST and CLIENT_ERROR are client error, OK is ok and the
+// others are server error
+func Dubbo(status uint8) sdk.RespStatus {
+	return lookupOr(TABLE_DUBBO, DubboTable).Status(int64(status))
+}
+
+// DubboName return the name such as SERVICE_NOT_FOUND, the unknown status return the number
+func DubboName(status uint8) string {
+	if n, ok := dubboNames[status]; ok {
+		return n
+	}
+	return strconv.Itoa(int(status))
+}
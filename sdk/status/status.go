@@ -0,0 +1,182 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Package status classify the response code of the protocols into sdk.RespStatus, the classification follow the
+deepflow agent as far as possible:
+
+	status.HTTP(503)           // RespStatusServerErr
+	status.GRPC(5)             // RespStatusClientErr, GRPCName(5) is NOT_FOUND
+	status.Dubbo(20)           // RespStatusOk
+	status.MySQL(1064)         // RespStatusServerErr
+	status.Postgres("23505")   // RespStatusClientErr
+
+the codes of the private protocols are classified by the Table, which can be registered by name from the plugin
+configuration, see Load. the functions above classify by the table registered as TABLE_*, so the built-in table
+can be overridden by Register or Load as well.
+*/
+package status
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+)
+
+// the names of the status used in the configuration
+const (
+	NAME_OK           = "ok"
+	NAME_CLIENT_ERROR = "client_error"
+	NAME_SERVER_ERROR = "server_error"
+	NAME_TIMEOUT      = "timeout"
+	NAME_UNKNOWN      = "unknown"
+)
+
+// Parse the status name such as client_error
+func Parse(name string) (sdk.RespStatus, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case NAME_OK:
+		return sdk.RespStatusOk, true
+	case NAME_CLIENT_ERROR:
+		return sdk.RespStatusClientErr, true
+	case NAME_SERVER_ERROR:
+		return sdk.RespStatusServerErr, true
+	case NAME_TIMEOUT:
+		return sdk.RespStatusTimeout, true
+	case NAME_UNKNOWN:
+		return sdk.RespStatusUnknown, true
+	}
+	return 0, false
+}
+
+// Name is the reverse of Parse
+func Name(s sdk.RespStatus) string {
+	switch s {
+	case sdk.RespStatusOk:
+		return NAME_OK
+	case sdk.RespStatusClientErr:
+		return NAME_CLIENT_ERROR
+	case sdk.RespStatusServerErr:
+		return NAME_SERVER_ERROR
+	case sdk.RespStatusTimeout:
+		return NAME_TIMEOUT
+	}
+	return NAME_UNKNOWN
+}
+
+// Range is the closed interval of the codes
+type Range struct {
+	Low  int64
+	High int64
+}
+
+func (r Range) Contains(code int64) bool {
+	return code >= r.Low && code <= r.High
+}
+
+// ParseRanges parse 500, 2xx, 400-499, -1 and the combination of them separated by comma
+func ParseRanges(s string) ([]Range, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var ranges []Range
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 3 && strings.HasSuffix(strings.ToLower(item), "xx") && item[0] >= '1' && item[0] <= '9' {
+			low := int64(item[0]-'0') * 100
+			ranges = append(ranges, Range{low, low + 99})
+			continue
+		}
+		// the minus of the negative low is not the separator
+		sep := -1
+		if len(item) > 1 {
+			if i := strings.IndexByte(item[1:], '-'); i >= 0 {
+				sep = i + 1
+			}
+		}
+		low, high := item, ""
+		if sep > 0 {
+			low, high = item[:sep], item[sep+1:]
+		}
+		lo, err := strconv.ParseInt(strings.TrimSpace(low), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("status: bad code %q", item)
+		}
+		hi := lo
+		if sep > 0 {
+			if hi, err = strconv.ParseInt(strings.TrimSpace(high), 10, 64); err != nil || hi < lo {
+				return nil, fmt.Errorf("status: bad code %q", item)
+			}
+		}
+		ranges = append(ranges, Range{lo, hi})
+	}
+	return ranges, nil
+}
+
+// HTTP classify the http status code, [200, 400) is ok, [400, 500) is client error and the others are server error
+func HTTP(code int32) sdk.RespStatus {
+	return lookupOr(TABLE_HTTP, HTTPTable).Status(int64(code))
+}
+
+// MySQL classify the error code of the ERR packet, 0 is ok, [2000, 3000) is the client error of the client library
+// and the others are server error
+func MySQL(errno uint16) sdk.RespStatus {
+	return lookupOr(TABLE_MYSQL, MySQLTable).Status(int64(errno))
+}
+
+// the sqlstate classes caused by the request, the others are server error
+var postgresClientClasses = map[string]bool{
+	"0A": true, // feature not supported
+	"0B": true, // invalid transaction initiation
+	"0F": true, // locator exception
+	"0L": true, // invalid grantor
+	"0P": true, // invalid role specification
+	"20": true, // case not found
+	"21": true, // cardinality violation
+	"22": true, // data exception
+	"23": true, // integrity constraint violation
+	"24": true, // invalid cursor state
+	"25": true, // invalid transaction state
+	"26": true, // invalid sql statement name
+	"27": true, // triggered data change violation
+	"28": true, // invalid authorization specification
+	"2B": true, // dependent privilege descriptors still exist
+	"2D": true, // invalid transaction termination
+	"2F": true, // sql routine exception
+	"34": true, // invalid cursor name
+	"3D": true, // invalid catalog name
+	"3F": true, // invalid schema name
+	"42": true, // syntax error or access rule violation
+	"44": true, // with check option violation
+}
+
+// Postgres classify the sqlstate of the ErrorResponse by the class, the first 2 chars, class 00 (successful
+// completion), 01 (warning) and 02 (no data) are ok
+func Postgres(sqlstate string) sdk.RespStatus {
+	if len(sqlstate) < 2 {
+		return sdk.RespStatusUnknown
+	}
+	class := strings.ToUpper(sqlstate[:2])
+	switch {
+	case class == "00" || class == "01" || class == "02":
+		return sdk.RespStatusOk
+	case postgresClientClasses[class]:
+		return sdk.RespStatusClientErr
+	}
+	return sdk.RespStatusServerErr
+}
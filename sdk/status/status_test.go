@@ -0,0 +1,253 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package status
+
+import (
+	"testing"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+)
+
+func TestParseRanges(t *testing.T) {
+	cases := []struct {
+		codes string
+		want  []Range
+		err   bool
+	}{
+		{"", nil, false},
+		{"500", []Range{{500, 500}}, false},
+		{"2xx, 4XX", []Range{{200, 299}, {400, 499}}, false},
+		{"400-499", []Range{{400, 499}}, false},
+		{" 1 - 3 ", []Range{{1, 3}}, false},
+		{"-1", []Range{{-1, -1}}, false},
+		{"-699--600", []Range{{-699, -600}}, false},
+		{"-5-5,0", []Range{{-5, 5}, {0, 0}}, false},
+		{"0xx", nil, true},
+		{"abc", nil, true},
+		{"499-400", nil, true},
+		{"-600--699", nil, true},
+		{"1-", nil, true},
+		{"1,,2", nil, true},
+		{"-", nil, true},
+	}
+	for _, c := range cases {
+		got, err := ParseRanges(c.codes)
+		if c.err {
+			if err == nil {
+				t.Errorf("%q: got %v without error", c.codes, got)
+			}
+			continue
+		}
+		if err != nil || len(got) != len(c.want) {
+			t.Errorf("%q: got %v, error %v, want %v", c.codes, got, err, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("%q: got %v, want %v", c.codes, got, c.want)
+				break
+			}
+		}
+	}
+}
+
+func TestParseName(t *testing.T) {
+	for _, s := range []sdk.RespStatus{
+		sdk.RespStatusOk, sdk.RespStatusClientErr, sdk.RespStatusServerErr, sdk.RespStatusTimeout, sdk.RespStatusUnknown,
+	} {
+		if got, ok := Parse(" " + Name(s) + " "); !ok || got != s {
+			t.Errorf("%s: got %d %v, want %d", Name(s), got, ok, s)
+		}
+	}
+	if got, ok := Parse("Server_Error"); !ok || got != sdk.RespStatusServerErr {
+		t.Errorf("got %d %v", got, ok)
+	}
+	if _, ok := Parse("error"); ok {
+		t.Error("error: parsed")
+	}
+}
+
+func TestBuiltin(t *testing.T) {
+	cases := []struct {
+		name string
+		got  sdk.RespStatus
+		want sdk.RespStatus
+	}{
+		{"http 200", HTTP(200), sdk.RespStatusOk},
+		{"http 302", HTTP(302), sdk.RespStatusOk},
+		{"http 404", HTTP(404), sdk.RespStatusClientErr},
+		{"http 503", HTTP(503), sdk.RespStatusServerErr},
+		{"http 100", HTTP(100), sdk.RespStatusServerErr},
+		{"grpc ok", GRPC(GRPC_OK), sdk.RespStatusOk},
+		{"grpc cancelled", GRPC(GRPC_CANCELLED), sdk.RespStatusClientErr},
+		{"grpc unknown", GRPC(GRPC_UNKNOWN), sdk.RespStatusServerErr},
+		{"grpc not found", GRPC(GRPC_NOT_FOUND), sdk.RespStatusClientErr},
+		{"grpc out of range", GRPC(GRPC_OUT_OF_RANGE), sdk.RespStatusClientErr},
+		{"grpc unimplemented", GRPC(GRPC_UNIMPLEMENTED), sdk.RespStatusServerErr},
+		{"grpc unauthenticated", GRPC(GRPC_UNAUTHENTICATED), sdk.RespStatusClientErr},
+		{"grpc 99", GRPC(99), sdk.RespStatusServerErr},
+		{"dubbo ok", Dubbo(DUBBO_OK), sdk.RespStatusOk},
+		{"dubbo client timeout", Dubbo(DUBBO_CLIENT_TIMEOUT), sdk.RespStatusClientErr},
+		{"dubbo server timeout", Dubbo(DUBBO_SERVER_TIMEOUT), sdk.RespStatusServerErr},
+		{"dubbo bad request", Dubbo(DUBBO_BAD_REQUEST), sdk.RespStatusClientErr},
+		{"dubbo service not found", Dubbo(DUBBO_SERVICE_NOT_FOUND), sdk.RespStatusServerErr},
+		{"dubbo client error", Dubbo(DUBBO_CLIENT_ERROR), sdk.RespStatusClientErr},
+		{"mysql 0", MySQL(0), sdk.RespStatusOk},
+		{"mysql 1064", MySQL(1064), sdk.RespStatusServerErr},
+		{"mysql 2013", MySQL(2013), sdk.RespStatusClientErr},
+		{"postgres ok", Postgres("00000"), sdk.RespStatusOk},
+		{"postgres warning", Postgres("01000"), sdk.RespStatusOk},
+		{"postgres unique", Postgres("23505"), sdk.RespStatusClientErr},
+		{"postgres lower case", Postgres("0a000"), sdk.RespStatusClientErr},
+		{"postgres syntax", Postgres("42601"), sdk.RespStatusClientErr},
+		{"postgres disk full", Postgres("53100"), sdk.RespStatusServerErr},
+		{"postgres short", Postgres("2"), sdk.RespStatusUnknown},
+	}
+	for _, c := range cases {
+		if c.got != c.want {
+			t.Errorf("%s: got %s, want %s", c.name, Name(c.got), Name(c.want))
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	if GRPCName(GRPC_NOT_FOUND) != "NOT_FOUND" || GRPCName(-1) != "-1" || GRPCName(17) != "17" {
+		t.Errorf("got %q %q %q", GRPCName(GRPC_NOT_FOUND), GRPCName(-1), GRPCName(17))
+	}
+	if code, ok := GRPCCode("UNAUTHENTICATED"); !ok || code != GRPC_UNAUTHENTICATED {
+		t.Errorf("got %d %v", code, ok)
+	}
+	if _, ok := GRPCCode("not_found"); ok {
+		t.Error("not_found: found")
+	}
+	if DubboName(DUBBO_SERVICE_NOT_FOUND) != "SERVICE_NOT_FOUND" || DubboName(21) != "21" {
+		t.Errorf("got %q %q", DubboName(DUBBO_SERVICE_NOT_FOUND), DubboName(21))
+	}
+}
+
+func TestTable(t *testing.T) {
+	table := NewTable(sdk.RespStatusUnknown).Add(0, 0, sdk.RespStatusOk).Add(0, 99, sdk.RespStatusClientErr)
+	if err := table.AddCodes("-1,5xx", sdk.RespStatusServerErr); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.AddCodes("5x", sdk.RespStatusServerErr); err == nil {
+		t.Error("5x: no error")
+	}
+	cases := []struct {
+		code int64
+		want sdk.RespStatus
+	}{
+		// the range added first win
+		{0, sdk.RespStatusOk},
+		{50, sdk.RespStatusClientErr},
+		{-1, sdk.RespStatusServerErr},
+		{599, sdk.RespStatusServerErr},
+		{-2, sdk.RespStatusUnknown},
+		{600, sdk.RespStatusUnknown},
+	}
+	for _, c := range cases {
+		if got := table.Status(c.code); got != c.want {
+			t.Errorf("%d: got %s, want %s", c.code, Name(got), Name(c.want))
+		}
+	}
+}
+
+// restore the registered tables after the test
+func saveTables(t *testing.T) {
+	saved := make(map[string]*Table, len(tables))
+	for k, v := range tables {
+		saved[k] = v
+	}
+	t.Cleanup(func() { tables = saved })
+}
+
+func TestLoad(t *testing.T) {
+	saveTables(t)
+	config := `[
+	  {
+	    "name": "krpc",
+	    "default": "timeout",
+	    "codes": [
+	      {"codes": "0", "status": "ok"},
+	      {"codes": "-699--600,400-499", "status": "client_error"}
+	    ]
+	  },
+	  {
+	    "name": "http",
+	    "codes": [{"codes": "2xx,404", "status": "ok"}]
+	  }
+	]`
+	if err := Load([]byte(config)); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		code int64
+		want sdk.RespStatus
+	}{
+		{0, sdk.RespStatusOk},
+		{-650, sdk.RespStatusClientErr},
+		{-600, sdk.RespStatusClientErr},
+		{-700, sdk.RespStatusTimeout},
+		{450, sdk.RespStatusClientErr},
+		{1, sdk.RespStatusTimeout},
+	}
+	for _, c := range cases {
+		if got, ok := Classify("krpc", c.code); !ok || got != c.want {
+			t.Errorf("krpc %d: got %s %v, want %s", c.code, Name(got), ok, Name(c.want))
+		}
+	}
+	// the built-in table is overridden
+	if HTTP(404) != sdk.RespStatusOk || HTTP(302) != sdk.RespStatusServerErr || Lookup(TABLE_HTTP) == HTTPTable {
+		t.Errorf("http is not overridden: 404 %s, 302 %s", Name(HTTP(404)), Name(HTTP(302)))
+	}
+	if _, ok := Classify("nope", 0); ok {
+		t.Error("nope: classified")
+	}
+
+	Register(TABLE_GRPC, NewTable(sdk.RespStatusOk))
+	if GRPC(GRPC_INTERNAL) != sdk.RespStatusOk {
+		t.Errorf("grpc is not overridden: %s", Name(GRPC(GRPC_INTERNAL)))
+	}
+	Register(TABLE_DUBBO, nil)
+	if Dubbo(DUBBO_CLIENT_ERROR) != sdk.RespStatusClientErr {
+		t.Errorf("nil dubbo table: got %s", Name(Dubbo(DUBBO_CLIENT_ERROR)))
+	}
+}
+
+func TestLoadError(t *testing.T) {
+	saveTables(t)
+	cases := []struct {
+		name   string
+		config string
+	}{
+		{"json", `{`},
+		{"no name", `[{"codes": [{"codes": "0", "status": "ok"}]}]`},
+		{"default", `[{"name": "a", "default": "fine"}]`},
+		{"status", `[{"name": "a", "codes": [{"codes": "0", "status": "fine"}]}]`},
+		{"codes", `[{"name": "a", "codes": [{"codes": "1-0", "status": "ok"}]}]`},
+		// the valid table is not registered if the others are invalid
+		{"partial", `[{"name": "ok"}, {"name": "bad", "default": "fine"}]`},
+	}
+	for _, c := range cases {
+		if err := Load([]byte(c.config)); err == nil {
+			t.Errorf("%s: no error", c.name)
+		}
+	}
+	if Lookup("a") != nil || Lookup("ok") != nil {
+		t.Error("the invalid config is registered")
+	}
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+)
+
+type entry struct {
+	Range
+	status sdk.RespStatus
+}
+
+// Table classify the code by the ranges in the order added, the code not in any range is Default
+type Table struct {
+	entries []entry
+	Default sdk.RespStatus
+}
+
+func NewTable(defaultStatus sdk.RespStatus) *Table {
+	return &Table{Default: defaultStatus}
+}
+
+// Add the closed interval [low, high], the range added first win when overlapped
+func (t *Table) Add(low, high int64, s sdk.RespStatus) *Table {
+	t.entries = append(t.entries, entry{Range: Range{low, high}, status: s})
+	return t
+}
+
+// AddCodes add the ranges in the format of ParseRanges
+func (t *Table) AddCodes(codes string, s sdk.RespStatus) error {
+	ranges, err := ParseRanges(codes)
+	if err != nil {
+		return err
+	}
+	for _, r := range ranges {
+		t.entries = append(t.entries, entry{Range: r, status: s})
+	}
+	return nil
+}
+
+func (t *Table) Status(code int64) sdk.RespStatus {
+	for i := range t.entries {
+		if t.entries[i].Contains(code) {
+			return t.entries[i].status
+		}
+	}
+	return t.Default
+}
+
+// the built-in tables, which are also registered by the TABLE_* names
+var (
+	HTTPTable = NewTable(sdk.RespStatusServerErr).
+			Add(200, 399, sdk.RespStatusOk).
+			Add(400, 499, sdk.RespStatusClientErr)
+	GRPCTable = NewTable(sdk.RespStatusServerErr).
+			Add(int64(GRPC_OK), int64(GRPC_OK), sdk.RespStatusOk).
+			Add(int64(GRPC_CANCELLED), int64(GRPC_CANCELLED), sdk.RespStatusClientErr).
+			Add(int64(GRPC_INVALID_ARGUMENT), int64(GRPC_INVALID_ARGUMENT), sdk.RespStatusClientErr).
+			Add(int64(GRPC_NOT_FOUND), int64(GRPC_OUT_OF_RANGE), sdk.RespStatusClientErr).
+			Add(int64(GRPC_UNAUTHENTICATED), int64(GRPC_UNAUTHENTICATED), sdk.RespStatusClientErr)
+	DubboTable = NewTable(sdk.RespStatusServerErr).
+			Add(int64(DUBBO_OK), int64(DUBBO_OK), sdk.RespStatusOk).
+			Add(int64(DUBBO_CLIENT_TIMEOUT), int64(DUBBO_CLIENT_TIMEOUT), sdk.RespStatusClientErr).
+			Add(int64(DUBBO_BAD_REQUEST), int64(DUBBO_BAD_REQUEST), sdk.RespStatusClientErr).
+			Add(int64(DUBBO_CLIENT_ERROR), int64(DUBBO_CLIENT_ERROR), sdk.RespStatusClientErr)
+	MySQLTable = NewTable(sdk.RespStatusServerErr).
+			Add(0, 0, sdk.RespStatusOk).
+			Add(2000, 2999, sdk.RespStatusClientErr)
+)
+
+const (
+	TABLE_HTTP  = "http"
+	TABLE_GRPC  = "grpc"
+	TABLE_DUBBO = "dubbo"
+	TABLE_MYSQL = "mysql"
+)
+
+var tables = map[string]*Table{
+	TABLE_HTTP:  HTTPTable,
+	TABLE_GRPC:  GRPCTable,
+	TABLE_DUBBO: DubboTable,
+	TABLE_MYSQL: MySQLTable,
+}
+
+// Register the table by name, the existing one include the built-in is replaced
+func Register(name string, t *Table) {
+	tables[name] = t
+}
+
+// Lookup the registered table, nil if not found
+func Lookup(name string) *Table {
+	return tables[name]
+}
+
+// the table registered by name, which may override the built-in one
+func lookupOr(name string, builtin *Table) *Table {
+	if t := tables[name]; t != nil {
+		return t
+	}
+	return builtin
+}
+
+// Classify the code by the registered table
+func Classify(name string, code int64) (sdk.RespStatus, bool) {
+	t := tables[name]
+	if t == nil {
+		return sdk.RespStatusUnknown, false
+	}
+	return t.Status(code), true
+}
+
+// TableConfig is the json configuration of the table
+type TableConfig struct {
+	Name string `json:"name"`
+	// the status name of the code not in any range, server_error by default
+	Default string `json:"default,omitempty"`
+	// matched in order
+	Codes []CodesConfig `json:"codes"`
+}
+
+type CodesConfig struct {
+	// the codes in the format of ParseRanges, such as "0" or "1000-1999,-1"
+	Codes  string `json:"codes"`
+	Status string `json:"status"`
+}
+
+func (c *TableConfig) Build() (*Table, error) {
+	def := sdk.RespStatusServerErr
+	if c.Default != "" {
+		var ok bool
+		if def, ok = Parse(c.Default); !ok {
+			return nil, fmt.Errorf("status: table %s: unknown status %q", c.Name, c.Default)
+		}
+	}
+	t := NewTable(def)
+	for _, codes := range c.Codes {
+		s, ok := Parse(codes.Status)
+		if !ok {
+			return nil, fmt.Errorf("status: table %s: unknown status %q", c.Name, codes.Status)
+		}
+		if err := t.AddCodes(codes.Codes, s); err != nil {
+			return nil, fmt.Errorf("status: table %s: %v", c.Name, err)
+		}
+	}
+	return t, nil
+}
+
+/*
+Load build and register the tables in json, the table is registered only if all the tables are valid:
+
+	[
+	  {
+	    "name": "krpc",
+	    "default": "server_error",
+	    "codes": [
+	      {"codes": "0", "status": "ok"},
+	      {"codes": "-699--600,400-499", "status": "client_error"}
+	    ]
+	  }
+	]
+*/
+func Load(config []byte) error {
+	var configs []TableConfig
+	if err := json.Unmarshal(config, &configs); err != nil {
+		return fmt.Errorf("status: %v", err)
+	}
+	built := make([]*Table, len(configs))
+	for i := range configs {
+		if configs[i].Name == "" {
+			return fmt.Errorf("status: table %d without name", i)
+		}
+		t, err := configs[i].Build()
+		if err != nil {
+			return err
+		}
+		built[i] = t
+	}
+	for i, t := range built {
+		Register(configs[i].Name, t)
+	}
+	return nil
+}
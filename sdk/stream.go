@@ -0,0 +1,176 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+import "errors"
+
+const (
+	DEFAULT_STREAM_BUF_SIZE  = 16 * 1024
+	DEFAULT_STREAM_MAX_FLOWS = 1024
+)
+
+var ErrStreamBufferFull = errors.New("stream buffer full")
+
+type stream struct {
+	direction Direction
+	buf       []byte
+	// the tcp seq expected by the next payload, 0 indicate unknown
+	nextSeq uint32
+	// the cap seq of the last payload, 0 indicate unknown
+	capSeq uint64
+	time   uint64
+}
+
+func (s *stream) reset(direction Direction) {
+	s.direction = direction
+	s.buf = s.buf[:0]
+	s.nextSeq = 0
+	s.capSeq = 0
+}
+
+// report whether the payload is not continuous with the buffered data
+func (s *stream) isGap(ctx *ParseCtx) bool {
+	if len(s.buf) == 0 {
+		return false
+	}
+	if ctx.TcpSeq != 0 && s.nextSeq != 0 {
+		return ctx.TcpSeq != s.nextSeq
+	}
+	if ctx.CapSeq != 0 && s.capSeq != 0 {
+		return ctx.CapSeq != s.capSeq+1
+	}
+	return false
+}
+
+/*
+StreamBuffer accumulate the payload of a flow across the parse payload calls, because the agent deliver the payload
+packet by packet and one message may be split into multi packets. the usage as follows:
+
+	data, err := streams.Append(ctx)
+	if err != nil {
+		return sdk.ActionAbortWithErr(err)
+	}
+	n, infos := parse(data) // n is the size of the complete messages
+	streams.Consume(ctx, n)
+
+the buffer of a flow is reset when the direction change or a gap is detected by the tcp seq or cap seq, which are only
+available when the agent support ABI_VERSION_EXT_CTX. the buffer is not safe for concurrent use, but the plugin is
+always called in single thread.
+*/
+type StreamBuffer struct {
+	// max buffered bytes per flow
+	MaxBufSize int
+	// when the number of flows exceed, the least recently updated flow will be evicted
+	MaxFlows int
+	streams  map[uint64]*stream
+}
+
+// zero indicate use the default size
+func NewStreamBuffer(maxBufSize, maxFlows int) *StreamBuffer {
+	if maxBufSize <= 0 {
+		maxBufSize = DEFAULT_STREAM_BUF_SIZE
+	}
+	if maxFlows <= 0 {
+		maxFlows = DEFAULT_STREAM_MAX_FLOWS
+	}
+	return &StreamBuffer{
+		MaxBufSize: maxBufSize,
+		MaxFlows:   maxFlows,
+		streams:    make(map[uint64]*stream),
+	}
+}
+
+/*
+Append the payload of ctx to the flow and return all the buffered data of the flow, the returned slice is only valid
+until the next call. when the buffered data exceed MaxBufSize, the buffered data is dropped and ErrStreamBufferFull
+is returned, the payload is kept as the start of a new message if it is not larger than MaxBufSize.
+*/
+func (b *StreamBuffer) Append(ctx *ParseCtx) ([]byte, error) {
+	payload, err := ctx.GetPayload()
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := b.streams[ctx.FlowID]
+	if !ok {
+		if len(b.streams) >= b.MaxFlows {
+			b.evict()
+		}
+		s = &stream{direction: ctx.Direction}
+		b.streams[ctx.FlowID] = s
+	}
+	if s.direction != ctx.Direction || s.isGap(ctx) {
+		s.reset(ctx.Direction)
+	}
+	s.time = ctx.Time
+	if ctx.TcpSeq != 0 {
+		s.nextSeq = ctx.TcpSeq + uint32(len(payload))
+	}
+	s.capSeq = ctx.CapSeq
+
+	if len(s.buf)+len(payload) > b.MaxBufSize {
+		s.buf = s.buf[:0]
+		if len(payload) <= b.MaxBufSize {
+			s.buf = append(s.buf, payload...)
+		}
+		return nil, ErrStreamBufferFull
+	}
+	s.buf = append(s.buf, payload...)
+	return s.buf, nil
+}
+
+// Consume drop the first n bytes of the buffered data of the flow, the remainder is kept for the next Append
+func (b *StreamBuffer) Consume(ctx *ParseCtx, n int) {
+	s, ok := b.streams[ctx.FlowID]
+	if !ok || n <= 0 {
+		return
+	}
+	if n >= len(s.buf) {
+		s.buf = s.buf[:0]
+		return
+	}
+	s.buf = s.buf[:copy(s.buf, s.buf[n:])]
+}
+
+// Buffered return the buffered data of the flow without append
+func (b *StreamBuffer) Buffered(ctx *ParseCtx) []byte {
+	if s, ok := b.streams[ctx.FlowID]; ok && s.direction == ctx.Direction {
+		return s.buf
+	}
+	return nil
+}
+
+// Remove release the buffer of the flow, call it when the flow end
+func (b *StreamBuffer) Remove(flowID uint64) {
+	delete(b.streams, flowID)
+}
+
+func (b *StreamBuffer) evict() {
+	var (
+		oldest uint64
+		time   uint64
+		found  bool
+	)
+	for id, s := range b.streams {
+		if !found || s.time < time {
+			oldest, time, found = id, s.time, true
+		}
+	}
+	if found {
+		delete(b.streams, oldest)
+	}
+}
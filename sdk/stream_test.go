@@ -0,0 +1,150 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+import (
+	"testing"
+)
+
+type streamPacket struct {
+	flowID    uint64
+	direction Direction
+	tcpSeq    uint32
+	capSeq    uint64
+	payload   string
+	// the bytes consumed after append
+	consume int
+	want    string
+	wantErr error
+}
+
+func (p *streamPacket) ctx(time uint64) *ParseCtx {
+	return &ParseCtx{
+		FlowID:    p.flowID,
+		Direction: p.direction,
+		TcpSeq:    p.tcpSeq,
+		CapSeq:    p.capSeq,
+		Time:      time,
+		payload:   []byte(p.payload),
+	}
+}
+
+func TestStreamBuffer(t *testing.T) {
+	cases := []struct {
+		name    string
+		maxSize int
+		packets []streamPacket
+	}{
+		{
+			name: "split message",
+			packets: []streamPacket{
+				{flowID: 1, tcpSeq: 100, payload: "GET / HT", want: "GET / HT"},
+				{flowID: 1, tcpSeq: 108, payload: "TP/1.1\r\n\r\nGE", consume: 18, want: "GET / HTTP/1.1\r\n\r\nGE"},
+				{flowID: 1, tcpSeq: 120, payload: "T /a", want: "GET /a"},
+			},
+		},
+		{
+			name: "tcp seq gap",
+			packets: []streamPacket{
+				{flowID: 1, tcpSeq: 100, payload: "abc", want: "abc"},
+				{flowID: 1, tcpSeq: 200, payload: "xyz", want: "xyz"},
+			},
+		},
+		{
+			name: "cap seq gap without tcp seq",
+			packets: []streamPacket{
+				{flowID: 1, capSeq: 10, payload: "abc", want: "abc"},
+				{flowID: 1, capSeq: 11, payload: "def", want: "abcdef"},
+				{flowID: 1, capSeq: 13, payload: "xyz", want: "xyz"},
+			},
+		},
+		{
+			name: "no seq is continuous",
+			packets: []streamPacket{
+				{flowID: 1, payload: "abc", want: "abc"},
+				{flowID: 1, payload: "def", want: "abcdef"},
+			},
+		},
+		{
+			name: "direction change",
+			packets: []streamPacket{
+				{flowID: 1, direction: DirectionRequest, payload: "req", want: "req"},
+				{flowID: 1, direction: DirectionResponse, payload: "resp", want: "resp"},
+			},
+		},
+		{
+			name: "flows are separated",
+			packets: []streamPacket{
+				{flowID: 1, payload: "a", want: "a"},
+				{flowID: 2, payload: "b", want: "b"},
+				{flowID: 1, payload: "c", want: "ac"},
+			},
+		},
+		{
+			name:    "buffer full keep the payload",
+			maxSize: 8,
+			packets: []streamPacket{
+				{flowID: 1, payload: "12345", want: "12345"},
+				{flowID: 1, payload: "6789", wantErr: ErrStreamBufferFull},
+				{flowID: 1, payload: "0", want: "67890"},
+			},
+		},
+		{
+			name:    "payload larger than buffer",
+			maxSize: 4,
+			packets: []streamPacket{
+				{flowID: 1, payload: "12", want: "12"},
+				{flowID: 1, payload: "34567", wantErr: ErrStreamBufferFull},
+				{flowID: 1, payload: "8", want: "8"},
+			},
+		},
+	}
+	for _, c := range cases {
+		b := NewStreamBuffer(c.maxSize, 0)
+		for i := range c.packets {
+			p := &c.packets[i]
+			ctx := p.ctx(uint64(i))
+			data, err := b.Append(ctx)
+			if err != p.wantErr {
+				t.Errorf("%s: packet %d got error %v, want %v", c.name, i, err, p.wantErr)
+				continue
+			}
+			if string(data) != p.want {
+				t.Errorf("%s: packet %d got %q, want %q", c.name, i, data, p.want)
+			}
+			b.Consume(ctx, p.consume)
+		}
+	}
+}
+
+func TestStreamBufferEvict(t *testing.T) {
+	b := NewStreamBuffer(0, 2)
+	for i, id := range []uint64{1, 2, 1, 3} {
+		p := streamPacket{flowID: id, payload: "x"}
+		if _, err := b.Append(p.ctx(uint64(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// flow 2 is the least recently updated
+	if len(b.streams) != 2 || b.streams[2] != nil || b.streams[1] == nil || b.streams[3] == nil {
+		t.Errorf("unexpected flows after evict: %v", b.streams)
+	}
+	b.Remove(1)
+	if b.Buffered(&ParseCtx{FlowID: 1}) != nil {
+		t.Error("removed flow is still buffered")
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+import "encoding/binary"
+
+type MessageDirection uint8
+
+const (
+	MessageRequest  MessageDirection = 1 << 0
+	MessageResponse MessageDirection = 1 << 1
+	MessageBoth                      = MessageRequest | MessageResponse
+)
+
+type CustomMessageSubscription struct {
+	Protocol   uint16
+	Directions MessageDirection
+	// one of ProtocolParse, SessionFilter and Sampling
+	HookPoint uint16
+}
+
+// implement by the parser to subscribe multi protocols and directions, take precedence over CustomMessageHookIn
+type CustomMessageSubscriber interface {
+	CustomMessageSubscriptions() []CustomMessageSubscription
+}
+
+func (s CustomMessageSubscription) hooks() []uint64 {
+	var hooks []uint64
+	if s.Directions&MessageRequest != 0 {
+		hooks = append(hooks, CustomMessageHook(s.HookPoint, s.Protocol, true))
+	}
+	if s.Directions&MessageResponse != 0 {
+		hooks = append(hooks, CustomMessageHook(s.HookPoint, s.Protocol, false))
+	}
+	return hooks
+}
+
+func (s CustomMessageSubscription) match(ctx *CustomMessageCtx) bool {
+	if s.HookPoint != ctx.HookPoint || s.Protocol != uint16(ctx.TypeCode) {
+		return false
+	}
+	if ctx.BaseCtx.Direction == DirectionRequest {
+		return s.Directions&MessageRequest != 0
+	}
+	return s.Directions&MessageResponse != 0
+}
+
+func customMessageSubscriptions(p Parser) []CustomMessageSubscription {
+	if s, ok := p.(CustomMessageSubscriber); ok {
+		return s.CustomMessageSubscriptions()
+	}
+	return nil
+}
+
+// return all the subscribed hooks, fallback to CustomMessageHookIn when the parser has no subscription
+func customMessageHooks(p Parser) []uint64 {
+	subs := customMessageSubscriptions(p)
+	if len(subs) == 0 {
+		if hook := p.CustomMessageHookIn(); hook != 0 {
+			return []uint64{hook}
+		}
+		return nil
+	}
+	var hooks []uint64
+	for _, s := range subs {
+		hooks = append(hooks, s.hooks()...)
+	}
+	return hooks
+}
+
+/*
+the single mask for the agent not support get_custom_message_hooks, subscribe all when there are multi hooks and the
+message not subscribed will be filtered in on_custom_message
+*/
+func customMessageHookMask(p Parser) uint64 {
+	hooks := customMessageHooks(p)
+	switch len(hooks) {
+	case 0:
+		return 0
+	case 1:
+		return hooks[0]
+	default:
+		return CUSTOM_MESSAGE_HOOK_ALL
+	}
+}
+
+// report whether the message should deliver to the parser, always true when the parser has no subscription
+func customMessageSubscribed(p Parser, ctx *CustomMessageCtx) bool {
+	subs := customMessageSubscriptions(p)
+	if len(subs) == 0 {
+		return true
+	}
+	for _, s := range subs {
+		if s.match(ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+hook count: 4 bytes
+hooks:      $(hook count) * 8 bytes, each is the same as get_custom_message_hook
+*/
+func serializeCustomMessageHooks(hooks []uint64) []byte {
+	buf := make([]byte, 4+8*len(hooks))
+	binary.BigEndian.PutUint32(buf[:4], uint32(len(hooks)))
+	for i, h := range hooks {
+		binary.BigEndian.PutUint64(buf[4+8*i:], h)
+	}
+	return buf
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sdk
+
+import (
+	"bytes"
+	"testing"
+)
+
+type hookInParser struct {
+	DefaultParser
+	hook uint64
+}
+
+func (p hookInParser) CustomMessageHookIn() uint64 { return p.hook }
+
+type subscriberParser struct {
+	hookInParser
+	subs []CustomMessageSubscription
+}
+
+func (p subscriberParser) CustomMessageSubscriptions() []CustomMessageSubscription { return p.subs }
+
+func TestCustomMessageHooks(t *testing.T) {
+	dubboReq := CustomMessageHookProtocol(PROTOCOL_DUBBO, true)
+	dubboResp := CustomMessageHookProtocol(PROTOCOL_DUBBO, false)
+	natsReq := CustomMessageHookProtocol(PROTOCOL_NATS, true)
+	cases := []struct {
+		name   string
+		parser Parser
+		hooks  []uint64
+		mask   uint64
+	}{
+		{"nothing", DefaultParser{}, nil, 0},
+		{"hook in", hookInParser{hook: natsReq}, []uint64{natsReq}, natsReq},
+		{"empty subscriptions fall back to hook in", subscriberParser{hookInParser: hookInParser{hook: natsReq}}, []uint64{natsReq}, natsReq},
+		{"request only", subscriberParser{subs: []CustomMessageSubscription{
+			{Protocol: PROTOCOL_DUBBO, Directions: MessageRequest, HookPoint: ProtocolParse},
+		}}, []uint64{dubboReq}, dubboReq},
+		{"subscriptions take precedence", subscriberParser{hookInParser: hookInParser{hook: natsReq}, subs: []CustomMessageSubscription{
+			{Protocol: PROTOCOL_DUBBO, Directions: MessageResponse, HookPoint: ProtocolParse},
+		}}, []uint64{dubboResp}, dubboResp},
+		{"both directions", subscriberParser{subs: []CustomMessageSubscription{
+			{Protocol: PROTOCOL_DUBBO, Directions: MessageBoth, HookPoint: ProtocolParse},
+		}}, []uint64{dubboReq, dubboResp}, CUSTOM_MESSAGE_HOOK_ALL},
+		{"multi protocols", subscriberParser{subs: []CustomMessageSubscription{
+			{Protocol: PROTOCOL_DUBBO, Directions: MessageRequest, HookPoint: ProtocolParse},
+			{Protocol: PROTOCOL_NATS, Directions: MessageRequest, HookPoint: SessionFilter},
+		}}, []uint64{dubboReq, CustomMessageHook(SessionFilter, PROTOCOL_NATS, true)}, CUSTOM_MESSAGE_HOOK_ALL},
+		{"no direction", subscriberParser{subs: []CustomMessageSubscription{
+			{Protocol: PROTOCOL_DUBBO, HookPoint: ProtocolParse},
+		}}, nil, 0},
+	}
+	for _, c := range cases {
+		hooks := customMessageHooks(c.parser)
+		if len(hooks) != len(c.hooks) {
+			t.Errorf("%s: got hooks %x, want %x", c.name, hooks, c.hooks)
+			continue
+		}
+		for i := range hooks {
+			if hooks[i] != c.hooks[i] {
+				t.Errorf("%s: got hooks %x, want %x", c.name, hooks, c.hooks)
+				break
+			}
+		}
+		if mask := customMessageHookMask(c.parser); mask != c.mask {
+			t.Errorf("%s: got mask %x, want %x", c.name, mask, c.mask)
+		}
+	}
+}
+
+func TestCustomMessageSubscribed(t *testing.T) {
+	p := subscriberParser{subs: []CustomMessageSubscription{
+		{Protocol: PROTOCOL_DUBBO, Directions: MessageRequest, HookPoint: ProtocolParse},
+		{Protocol: PROTOCOL_NATS, Directions: MessageBoth, HookPoint: Sampling},
+	}}
+	message := func(hookPoint uint16, protocol uint16, direction Direction) *CustomMessageCtx {
+		ctx := &CustomMessageCtx{HookPoint: hookPoint, TypeCode: uint32(protocol)}
+		ctx.BaseCtx.Direction = direction
+		return ctx
+	}
+	cases := []struct {
+		name   string
+		parser Parser
+		ctx    *CustomMessageCtx
+		want   bool
+	}{
+		{"dubbo request", p, message(ProtocolParse, PROTOCOL_DUBBO, DirectionRequest), true},
+		{"dubbo response", p, message(ProtocolParse, PROTOCOL_DUBBO, DirectionResponse), false},
+		{"dubbo sampling", p, message(Sampling, PROTOCOL_DUBBO, DirectionRequest), false},
+		{"nats sampling request", p, message(Sampling, PROTOCOL_NATS, DirectionRequest), true},
+		{"nats sampling response", p, message(Sampling, PROTOCOL_NATS, DirectionResponse), true},
+		{"nats parse", p, message(ProtocolParse, PROTOCOL_NATS, DirectionRequest), false},
+		{"without subscription", hookInParser{hook: CUSTOM_MESSAGE_HOOK_ALL}, message(Sampling, PROTOCOL_DUBBO, DirectionResponse), true},
+	}
+	for _, c := range cases {
+		if got := customMessageSubscribed(c.parser, c.ctx); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSerializeCustomMessageHooks(t *testing.T) {
+	cases := []struct {
+		hooks []uint64
+		want  []byte
+	}{
+		{nil, []byte{0, 0, 0, 0}},
+		{[]uint64{CustomMessageHookProtocol(PROTOCOL_DUBBO, false)}, []byte{0, 0, 0, 1, 0, 0, 0, 0, 0, 1, 0, byte(PROTOCOL_DUBBO)}},
+		{[]uint64{1, CUSTOM_MESSAGE_HOOK_ALL}, []byte{0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0xff, 0, 0, 0, 0, 0, 0}},
+	}
+	for _, c := range cases {
+		if got := serializeCustomMessageHooks(c.hooks); !bytes.Equal(got, c.want) {
+			t.Errorf("%x: got %v, want %v", c.hooks, got, c.want)
+		}
+	}
+}
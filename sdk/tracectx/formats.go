@@ -0,0 +1,216 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracectx
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+/*
+traceparent: {version}-{trace id}-{parent id}-{flags}
+
+	00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+
+the future version may append more fields after flags.
+*/
+func extractW3C(get Getter) (Context, bool) {
+	parts := strings.Split(strings.TrimSpace(get("traceparent")), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || !isHex(parts[0]) || parts[0] == "ff" {
+		return Context{}, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return Context{}, false
+	}
+	if !validID(parts[1], 32) || !validID(parts[2], 16) || len(parts[3]) != 2 || !isHex(parts[3]) {
+		return Context{}, false
+	}
+	return Context{TraceID: strings.ToLower(parts[1]), SpanID: strings.ToLower(parts[2])}, true
+}
+
+/*
+b3: {trace id}-{span id}-{sampling state}-{parent span id}
+
+	80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90
+
+the sampling state and parent span id are optional, and the header only contain the sampling state such as 0 is not
+a context.
+*/
+func extractB3(get Getter) (Context, bool) {
+	parts := strings.Split(strings.TrimSpace(get("b3")), "-")
+	if len(parts) < 2 || len(parts) > 4 {
+		return Context{}, false
+	}
+	if !validID(parts[0], 16, 32) || !validID(parts[1], 16) {
+		return Context{}, false
+	}
+	ctx := Context{TraceID: strings.ToLower(parts[0]), SpanID: strings.ToLower(parts[1])}
+	if len(parts) == 4 {
+		if !validID(parts[3], 16) {
+			return Context{}, false
+		}
+		ctx.ParentSpanID = strings.ToLower(parts[3])
+	}
+	return ctx, true
+}
+
+func extractB3Multi(get Getter) (Context, bool) {
+	traceID := strings.TrimSpace(get("x-b3-traceid"))
+	spanID := strings.TrimSpace(get("x-b3-spanid"))
+	if !validID(traceID, 16, 32) || !validID(spanID, 16) {
+		return Context{}, false
+	}
+	ctx := Context{TraceID: strings.ToLower(traceID), SpanID: strings.ToLower(spanID)}
+	if parent := strings.TrimSpace(get("x-b3-parentspanid")); validID(parent, 16) {
+		ctx.ParentSpanID = strings.ToLower(parent)
+	}
+	return ctx, true
+}
+
+/*
+uber-trace-id: {trace id}:{span id}:{parent span id}:{flags}
+
+	3a5c5e3b2a2c1d0e:1f2e3d4c5b6a7980:0:1
+
+the ids are hex without leading zeros, the deprecated parent span id is usually 0, and the colons may be url encoded.
+*/
+func extractJaeger(get Getter) (Context, bool) {
+	v := strings.TrimSpace(get("uber-trace-id"))
+	if strings.Contains(v, "%") {
+		if decoded, err := url.PathUnescape(v); err == nil {
+			v = decoded
+		}
+	}
+	parts := strings.Split(v, ":")
+	if len(parts) != 4 {
+		return Context{}, false
+	}
+	if len(parts[0]) > 32 || len(parts[1]) > 16 || !isHex(parts[0]) || !isHex(parts[1]) || isZero(parts[0]) || isZero(parts[1]) {
+		return Context{}, false
+	}
+	ctx := Context{TraceID: strings.ToLower(parts[0]), SpanID: strings.ToLower(parts[1])}
+	if len(parts[2]) <= 16 && isHex(parts[2]) && !isZero(parts[2]) {
+		ctx.ParentSpanID = strings.ToLower(parts[2])
+	}
+	return ctx, true
+}
+
+func decodeBase64(s string) (string, bool) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		if b, err = base64.RawStdEncoding.DecodeString(s); err != nil {
+			return "", false
+		}
+	}
+	return string(b), true
+}
+
+/*
+sw8: {sample}-{trace id}-{segment id}-{parent span id}-{parent service}-{parent instance}-{parent endpoint}-{peer}
+
+	1-TRACEID-SEGMENTID-3-PARENT_SERVICE-PARENT_INSTANCE-PARENT_ENDPOINT-IPPORT
+
+the trace id, segment id, services and endpoint are base64 encoded. the span id is {segment id}-{parent span id}, the
+same as the deepflow agent.
+*/
+func extractSkyWalking(get Getter) (Context, bool) {
+	parts := strings.Split(strings.TrimSpace(get("sw8")), "-")
+	if len(parts) != 8 {
+		return Context{}, false
+	}
+	traceID, ok := decodeBase64(parts[1])
+	if !ok || traceID == "" {
+		return Context{}, false
+	}
+	segmentID, ok := decodeBase64(parts[2])
+	if !ok || segmentID == "" {
+		return Context{}, false
+	}
+	if _, err := strconv.Atoi(parts[3]); err != nil {
+		return Context{}, false
+	}
+	return Context{TraceID: traceID, SpanID: segmentID + "-" + parts[3]}, true
+}
+
+// the ids of datadog are unsigned 64 bit integer in decimal
+func extractDatadog(get Getter) (Context, bool) {
+	traceID := strings.TrimSpace(get("x-datadog-trace-id"))
+	if id, err := strconv.ParseUint(traceID, 10, 64); err != nil || id == 0 {
+		return Context{}, false
+	}
+	ctx := Context{TraceID: traceID}
+	parentID := strings.TrimSpace(get("x-datadog-parent-id"))
+	if id, err := strconv.ParseUint(parentID, 10, 64); err == nil && id != 0 {
+		ctx.SpanID = parentID
+	}
+	return ctx, true
+}
+
+// baggage: key1=value1;property1,key2=value2, the value is percent encoded
+func parseW3CBaggage(v string, add func(key, val string) bool) {
+	for _, member := range strings.Split(v, ",") {
+		member, _, _ = strings.Cut(member, ";")
+		key, val, ok := strings.Cut(member, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		if decoded, err := url.PathUnescape(val); err == nil {
+			val = decoded
+		}
+		if !add(key, val) {
+			return
+		}
+	}
+}
+
+// jaeger-baggage: key1=value1, key2=value2
+func parseJaegerBaggage(v string, add func(key, val string) bool) {
+	for _, member := range strings.Split(v, ",") {
+		key, val, ok := strings.Cut(member, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		if !add(key, strings.TrimSpace(val)) {
+			return
+		}
+	}
+}
+
+// sw8-correlation: base64(key1):base64(value1),base64(key2):base64(value2)
+func parseSkyWalkingCorrelation(v string, add func(key, val string) bool) {
+	for _, member := range strings.Split(v, ",") {
+		k, val, ok := strings.Cut(strings.TrimSpace(member), ":")
+		if !ok {
+			continue
+		}
+		key, ok := decodeBase64(k)
+		if !ok || key == "" {
+			continue
+		}
+		if val, ok = decodeBase64(val); !ok {
+			continue
+		}
+		if !add(key, val) {
+			return
+		}
+	}
+}
@@ -0,0 +1,257 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/*
+Package tracectx extract the trace context propagated in the headers into sdk.Trace, the supported formats are:
+
+	W3C:        traceparent, tracestate, baggage
+	B3:         b3
+	B3 multi:   x-b3-traceid, x-b3-spanid, x-b3-parentspanid
+	Jaeger:     uber-trace-id, jaeger-baggage
+	SkyWalking: sw8, sw8-correlation
+	Datadog:    x-datadog-trace-id, x-datadog-parent-id
+
+the usage as follows:
+
+	req, err := http1.ParseRequest(payload)
+	if err != nil {
+		return sdk.ActionAbortWithErr(err)
+	}
+	result := tracectx.Extract(req.HeaderString)
+	result.Fill(info)
+
+FromHeader and FromMap adapt the headers parsed by the other libraries, such as the http.Header of net/http.
+
+the baggage propagated by header prefix such as uberctx- is not supported, because the headers can not be enumerated
+by the lookup function.
+*/
+package tracectx
+
+import (
+	"net/textproto"
+	"strings"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+)
+
+// Getter return the value of the header, the name is in lower case and the lookup should be case-insensitive
+type Getter func(name string) string
+
+// FromHeader adapt the header in the form of http.Header
+func FromHeader(h map[string][]string) Getter {
+	return func(name string) string {
+		if v := h[textproto.CanonicalMIMEHeaderKey(name)]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+}
+
+// FromMap adapt a map whose keys are in lower case
+func FromMap(m map[string]string) Getter {
+	return func(name string) string {
+		return m[name]
+	}
+}
+
+type Format uint8
+
+const (
+	FormatW3C Format = iota
+	FormatB3
+	FormatB3Multi
+	FormatJaeger
+	FormatSkyWalking
+	FormatDatadog
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatW3C:
+		return "w3c"
+	case FormatB3:
+		return "b3"
+	case FormatB3Multi:
+		return "b3multi"
+	case FormatJaeger:
+		return "jaeger"
+	case FormatSkyWalking:
+		return "skywalking"
+	case FormatDatadog:
+		return "datadog"
+	}
+	return "unknown"
+}
+
+var AllFormats = []Format{FormatW3C, FormatB3, FormatB3Multi, FormatJaeger, FormatSkyWalking, FormatDatadog}
+
+type Context struct {
+	Format  Format
+	TraceID string
+	// the span of the caller, which is the parent of the span of this call
+	SpanID       string
+	ParentSpanID string
+}
+
+type Result struct {
+	// in the order of Extractor.Formats
+	Contexts []Context
+	Baggage  []sdk.KeyVal
+}
+
+const DEFAULT_MAX_BAGGAGE = 16
+
+type Extractor struct {
+	// the formats in priority, the first found context fill TraceID and SpanID
+	Formats []Format
+	// the key prefix of the baggage attributes
+	BaggagePrefix string
+	// the max number of baggage attributes, 0 indicate no baggage
+	MaxBaggage int
+}
+
+var DefaultExtractor = &Extractor{
+	Formats:       AllFormats,
+	BaggagePrefix: "baggage.",
+	MaxBaggage:    DEFAULT_MAX_BAGGAGE,
+}
+
+// Extract by DefaultExtractor
+func Extract(get Getter) *Result {
+	return DefaultExtractor.Extract(get)
+}
+
+func (e *Extractor) Extract(get Getter) *Result {
+	r := &Result{}
+	for _, f := range e.Formats {
+		var (
+			ctx Context
+			ok  bool
+		)
+		switch f {
+		case FormatW3C:
+			ctx, ok = extractW3C(get)
+		case FormatB3:
+			ctx, ok = extractB3(get)
+		case FormatB3Multi:
+			ctx, ok = extractB3Multi(get)
+		case FormatJaeger:
+			ctx, ok = extractJaeger(get)
+		case FormatSkyWalking:
+			ctx, ok = extractSkyWalking(get)
+		case FormatDatadog:
+			ctx, ok = extractDatadog(get)
+		}
+		if ok {
+			ctx.Format = f
+			r.Contexts = append(r.Contexts, ctx)
+		}
+		if e.MaxBaggage > 0 {
+			r.Baggage = e.appendBaggage(r.Baggage, f, get)
+		}
+	}
+	return r
+}
+
+func (e *Extractor) appendBaggage(kv []sdk.KeyVal, f Format, get Getter) []sdk.KeyVal {
+	add := func(key, val string) bool {
+		if len(kv) >= e.MaxBaggage {
+			return false
+		}
+		kv = append(kv, sdk.KeyVal{Key: e.BaggagePrefix + key, Val: val})
+		return true
+	}
+	switch f {
+	case FormatW3C:
+		if v := get("tracestate"); v != "" {
+			add("tracestate", v)
+		}
+		parseW3CBaggage(get("baggage"), add)
+	case FormatJaeger:
+		parseJaegerBaggage(get("jaeger-baggage"), add)
+	case FormatSkyWalking:
+		parseSkyWalkingCorrelation(get("sw8-correlation"), add)
+	}
+	return kv
+}
+
+// Trace return nil if no context is found, TraceIDs is filled when the contexts have different trace ids
+func (r *Result) Trace() *sdk.Trace {
+	if len(r.Contexts) == 0 {
+		return nil
+	}
+	first := r.Contexts[0]
+	trace := &sdk.Trace{
+		TraceID:      first.TraceID,
+		SpanID:       first.SpanID,
+		ParentSpanID: first.ParentSpanID,
+	}
+	var ids []string
+	for _, c := range r.Contexts {
+		if !contains(ids, c.TraceID) {
+			ids = append(ids, c.TraceID)
+		}
+	}
+	if len(ids) > 1 {
+		trace.TraceIDs = ids
+	}
+	return trace
+}
+
+func contains(s []string, v string) bool {
+	for _, i := range s {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Fill the trace of info and append the baggage to the Kv, the XRequestID and HttpProxyClient of info are kept
+func (r *Result) Fill(info *sdk.L7ProtocolInfo) {
+	if trace := r.Trace(); trace != nil {
+		if info.Trace != nil {
+			trace.XRequestID = info.Trace.XRequestID
+			trace.HttpProxyClient = info.Trace.HttpProxyClient
+		}
+		info.Trace = trace
+	}
+	info.Kv = append(info.Kv, r.Baggage...)
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+func isZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+// a valid id is non-zero hex of the expected size
+func validID(s string, sizes ...int) bool {
+	for _, size := range sizes {
+		if len(s) == size {
+			return isHex(s) && !isZero(s)
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2025 Yunshan Networks
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracectx
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/deepflowio/deepflow-wasm-go-sdk/sdk"
+)
+
+func b64(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func TestExtractFormats(t *testing.T) {
+	sw8 := "1-" + b64("trace.1.2") + "-" + b64("segment.3") + "-3-" + b64("svc") + "-" + b64("inst") + "-" + b64("/api") + "-" + b64("10.0.0.1:80")
+	cases := []struct {
+		name    string
+		headers map[string]string
+		want    *Context
+	}{
+		{"w3c", map[string]string{"traceparent": "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+			&Context{Format: FormatW3C, TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"}},
+		{"w3c future version", map[string]string{"traceparent": "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-xyz"},
+			&Context{Format: FormatW3C, TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"}},
+		{"w3c version 00 with extra", map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-xyz"}, nil},
+		{"w3c invalid version", map[string]string{"traceparent": "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}, nil},
+		{"w3c zero trace id", map[string]string{"traceparent": "00-00000000000000000000000000000000-00f067aa0ba902b7-01"}, nil},
+		{"w3c short span id", map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa-01"}, nil},
+		{"b3", map[string]string{"b3": "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90"},
+			&Context{Format: FormatB3, TraceID: "80f198ee56343ba864fe8b2a57d3eff7", SpanID: "e457b5a2e4d86bd1", ParentSpanID: "05e3ac9a4f6e3b90"}},
+		{"b3 64 bit trace id", map[string]string{"b3": "64fe8b2a57d3eff7-e457b5a2e4d86bd1"},
+			&Context{Format: FormatB3, TraceID: "64fe8b2a57d3eff7", SpanID: "e457b5a2e4d86bd1"}},
+		{"b3 sampling only", map[string]string{"b3": "0"}, nil},
+		{"b3 bad parent", map[string]string{"b3": "64fe8b2a57d3eff7-e457b5a2e4d86bd1-1-xyz"}, nil},
+		{"b3 too many parts", map[string]string{"b3": "64fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90-1"}, nil},
+		{"b3 multi", map[string]string{"x-b3-traceid": "64fe8b2a57d3eff7", "x-b3-spanid": "E457B5A2E4D86BD1", "x-b3-parentspanid": "bad"},
+			&Context{Format: FormatB3Multi, TraceID: "64fe8b2a57d3eff7", SpanID: "e457b5a2e4d86bd1"}},
+		{"b3 multi without span", map[string]string{"x-b3-traceid": "64fe8b2a57d3eff7"}, nil},
+		{"jaeger", map[string]string{"uber-trace-id": "3a5c5e3b2a2c1d0e:1f2e3d4c5b6a7980:0:1"},
+			&Context{Format: FormatJaeger, TraceID: "3a5c5e3b2a2c1d0e", SpanID: "1f2e3d4c5b6a7980"}},
+		{"jaeger url encoded", map[string]string{"uber-trace-id": "abc%3Adef%3A12%3A1"},
+			&Context{Format: FormatJaeger, TraceID: "abc", SpanID: "def", ParentSpanID: "12"}},
+		{"jaeger zero span", map[string]string{"uber-trace-id": "abc:0:0:1"}, nil},
+		{"sw8", map[string]string{"sw8": sw8},
+			&Context{Format: FormatSkyWalking, TraceID: "trace.1.2", SpanID: "segment.3-3"}},
+		{"sw8 raw base64", map[string]string{"sw8": "1-" + base64.RawStdEncoding.EncodeToString([]byte("t1")) + "-" + base64.RawStdEncoding.EncodeToString([]byte("s1")) + "-0-a-b-c-d"},
+			&Context{Format: FormatSkyWalking, TraceID: "t1", SpanID: "s1-0"}},
+		{"sw8 bad span id", map[string]string{"sw8": "1-" + b64("t") + "-" + b64("s") + "-x-a-b-c-d"}, nil},
+		{"sw8 bad base64", map[string]string{"sw8": "1-!!!-" + b64("s") + "-1-a-b-c-d"}, nil},
+		{"sw8 missing parts", map[string]string{"sw8": "1-" + b64("t") + "-" + b64("s") + "-1"}, nil},
+		{"datadog", map[string]string{"x-datadog-trace-id": "1234567890123456789", "x-datadog-parent-id": "987"},
+			&Context{Format: FormatDatadog, TraceID: "1234567890123456789", SpanID: "987"}},
+		{"datadog without parent", map[string]string{"x-datadog-trace-id": "12"},
+			&Context{Format: FormatDatadog, TraceID: "12"}},
+		{"datadog overflow", map[string]string{"x-datadog-trace-id": "18446744073709551616"}, nil},
+		{"none", map[string]string{}, nil},
+	}
+	for _, c := range cases {
+		result := Extract(FromMap(c.headers))
+		if c.want == nil {
+			if len(result.Contexts) != 0 {
+				t.Errorf("%s: unexpected contexts %+v", c.name, result.Contexts)
+			}
+			continue
+		}
+		if len(result.Contexts) != 1 || result.Contexts[0] != *c.want {
+			t.Errorf("%s: got %+v, want %+v", c.name, result.Contexts, *c.want)
+		}
+	}
+}
+
+func TestExtractBaggage(t *testing.T) {
+	headers := map[string]string{
+		"tracestate":      "congo=t61rcWkgMzE",
+		"baggage":         "user=alice%20b;ttl=1, =skip,broken, region=eu",
+		"jaeger-baggage":  "k1=v1, k2 = v2",
+		"sw8-correlation": b64("tenant") + ":" + b64("t1") + ",bad," + b64("x") + ":!!!",
+	}
+	result := Extract(FromMap(headers))
+	want := []sdk.KeyVal{
+		{Key: "baggage.tracestate", Val: "congo=t61rcWkgMzE"},
+		{Key: "baggage.user", Val: "alice b"},
+		{Key: "baggage.region", Val: "eu"},
+		{Key: "baggage.k1", Val: "v1"},
+		{Key: "baggage.k2", Val: "v2"},
+		{Key: "baggage.tenant", Val: "t1"},
+	}
+	if len(result.Baggage) != len(want) {
+		t.Fatalf("got %v, want %v", result.Baggage, want)
+	}
+	for i := range want {
+		if result.Baggage[i] != want[i] {
+			t.Errorf("baggage %d got %v, want %v", i, result.Baggage[i], want[i])
+		}
+	}
+
+	limited := &Extractor{Formats: AllFormats, MaxBaggage: 2}
+	if got := limited.Extract(FromMap(headers)).Baggage; len(got) != 2 || got[1].Key != "user" {
+		t.Errorf("limited baggage got %v", got)
+	}
+}
+
+func TestResultFill(t *testing.T) {
+	headers := FromHeader(map[string][]string{
+		"Traceparent":  {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		"X-B3-Traceid": {"64fe8b2a57d3eff7"},
+		"X-B3-Spanid":  {"e457b5a2e4d86bd1"},
+		"B3":           {"64fe8b2a57d3eff7-e457b5a2e4d86bd1"},
+	})
+	info := &sdk.L7ProtocolInfo{Trace: &sdk.Trace{XRequestID: "req-1", HttpProxyClient: "1.1.1.1"}}
+	Extract(headers).Fill(info)
+	tr := info.Trace
+	if tr.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || tr.SpanID != "00f067aa0ba902b7" ||
+		tr.XRequestID != "req-1" || tr.HttpProxyClient != "1.1.1.1" {
+		t.Errorf("unexpected trace %+v", tr)
+	}
+	if len(tr.TraceIDs) != 2 || tr.TraceIDs[1] != "64fe8b2a57d3eff7" {
+		t.Errorf("unexpected trace ids %v", tr.TraceIDs)
+	}
+
+	empty := &sdk.L7ProtocolInfo{}
+	Extract(FromMap(nil)).Fill(empty)
+	if empty.Trace != nil || empty.Kv != nil {
+		t.Errorf("unexpected info %+v", empty)
+	}
+}